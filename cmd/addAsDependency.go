@@ -18,7 +18,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/CycloneDX/cyclonedx-go"
 	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
 	"github.com/spf13/cobra"
 )
@@ -45,48 +44,18 @@ var addAsDependencyCmd = &cobra.Command{
 		if err != nil {
 			panic(err)
 		}
-		var comp []cyclonedx.Component
-		if rootBom.Components == nil {
-			comp = make([]cyclonedx.Component, 0)
-		} else {
-			comp = *rootBom.Components
-		}
-		// Add the component in the leafBom's metadata as a component
-		comp = append(comp, *leafBom.Metadata.Component)
-		// Add each component in the leaf bom as a component
-		for _, c := range *leafBom.Components {
-			comp = append(comp, c)
+		if rootBom.Metadata == nil || rootBom.Metadata.Component == nil {
+			panic(fmt.Errorf("input BOM %v does not have a metadata component", inputFilename))
 		}
-		rootBom.Components = &comp
-
-		rootDeps := *rootBom.Dependencies
-
-		// add the objects of Bom as components
-		for i, dep := range rootDeps {
-			//find the dependncy for the root and add the leaf as a dependency
-			if dep.Ref == rootBom.Metadata.Component.BOMRef {
-				var tmp []cyclonedx.Dependency
-				if dep.Dependencies == nil {
-					tmp = make([]cyclonedx.Dependency, 0)
-				} else {
-					tmp = *dep.Dependencies
-				}
-				tmp = append(tmp, cyclonedx.Dependency{
-					Ref: leafBom.Metadata.Component.BOMRef,
-				})
-				rootDeps[i].Dependencies = &tmp
-
-			}
+		err = sbom.AddCycloneDXDependency(rootBom, leafBom, rootBom.Metadata.Component.BOMRef)
+		if err != nil {
+			panic(err)
 		}
-		// add the dependency object of the BOM as a depdnency of the top level
-
-		rootDeps = append(rootDeps, cyclonedx.Dependency{
-			Ref:          leafBom.Metadata.Component.BOMRef,
-			Dependencies: leafBom.Dependencies,
-		})
-		rootBom.Dependencies = &rootDeps
 
 		outFilename, _ := cmd.Flags().GetString("output")
+		if validate, _ := cmd.Flags().GetBool("validate"); validate {
+			checkBeforeWrite(sbom.ValidateCycloneDX(rootBom))
+		}
 		if outFilename != "" {
 			sbom.WriteCycloneDX(outFilename, rootBom)
 		} else {
@@ -112,4 +81,5 @@ func init() {
 	addAsDependencyCmd.Flags().String("input", "", "input file to load BOM from")
 	addAsDependencyCmd.Flags().String("output", "", "output file to write new BOM")
 	addAsDependencyCmd.Flags().Bool("overwrite", false, "Should the output file be overwritten?")
+	addAsDependencyCmd.Flags().Bool("validate", false, "validate the new BOM before writing it")
 }
@@ -62,6 +62,9 @@ to quickly create a Cobra application.`,
 		}
 		merged, _ := sbom.MergeCycloneDX(boms, "istio")
 		oFile, _ := cmd.Flags().GetString("output-file")
+		if validate, _ := cmd.Flags().GetBool("validate"); validate {
+			checkBeforeWrite(sbom.ValidateCycloneDX(merged))
+		}
 		if oFile != "" {
 			sbom.WriteCycloneDX(oFile, merged)
 		} else {
@@ -81,6 +84,7 @@ func init() {
 	combineCmd.Flags().StringSlice("input-files", []string{}, "A help for foo")
 	combineCmd.Flags().String("format", "spdx", "BOM Format, spdx or cyclonedx")
 	combineCmd.Flags().String("output-file", "", "output file for merged content")
+	combineCmd.Flags().Bool("validate", false, "validate the merged BOM before writing it")
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
@@ -15,18 +15,23 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/CycloneDX/cyclonedx-go"
-	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/inventory"
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
 	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
-	"github.com/defenseunicorns/spdx-cli/pkg/syft"
-
-	"github.com/spdx/tools-golang/spdx"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // createCmd represents the create command
@@ -44,110 +49,38 @@ to quickly create a Cobra application.`,
 
 		p, _ := cmd.Flags().GetString("path")
 		fmt.Printf("Helm chart at path %v\n", p)
-		chart, err := helm.Read(p)
-		if err != nil {
-			panic(err)
-		}
 
-		imageList := helm.Images(chart)
-		if len(imageList) == 0 {
-			panic(fmt.Errorf("Did not find any images in helm chart"))
+		format := generator.FormatSPDX
+		if f, _ := cmd.Flags().GetString("output-format"); f == "cyclonedx" {
+			format = generator.FormatCycloneDX
 		}
-		imageMap := make(map[string]*spdx.Document2_2)
-		chartBom := spdx.Document2_2{
-			CreationInfo: &spdx.CreationInfo2_2{
-				// 2.1: SPDX Version; should be in the format "SPDX-2.2"
-				// Cardinality: mandatory, one
-				SPDXVersion: "SPDX-2.2",
-
-				// 2.2: Data License; should be "CC0-1.0"
-				// Cardinality: mandatory, one
-				DataLicense: "CC0-1.0",
-
-				// 2.3: SPDX Identifier; should be "DOCUMENT" to represent mandatory identifier of SPDXRef-DOCUMENT
-				// Cardinality: mandatory, one
-				SPDXIdentifier: spdx.ElementID("DOCUMENT"),
-
-				// 2.4: Document Name
-				// Cardinality: mandatory, one
-				DocumentName: chart.Metadata.Name,
-
-				// 2.5: Document Namespace
-				// Cardinality: mandatory, one
-				// Purpose: Provide an SPDX document specific namespace as a unique absolute Uniform Resource
-				// Identifier (URI) as specified in RFC-3986, with the exception of the ‘#’ delimiter. The SPDX
-				// Document URI cannot contain a URI "part" (e.g. the "#" character), since the ‘#’ is used in SPDX
-				// element URIs (packages, files, snippets, etc) to separate the document namespace from the
-				// element’s SPDX identifier. Additionally, a scheme (e.g. “https:”) is required.
-
-				// The URI must be unique for the SPDX document including the specific version of the SPDX document.
-				// If the SPDX document is updated, thereby creating a new version, a new URI for the updated
-				// document must be used. There can only be one URI for an SPDX document and only one SPDX document
-				// for a given URI.
-
-				// Note that the URI does not have to be accessible. It is only intended to provide a unique ID.
-				// In many cases, the URI will point to a web accessible document, but this should not be assumed
-				// to be the case.
-
-				DocumentNamespace: fmt.Sprintf("https://bigbang.dev/chart/%s", chart.Metadata.Name),
-
-				// 2.6: External Document References
-				// Cardinality: optional, one or many
-				ExternalDocumentReferences: nil,
-
-				// 2.7: License List Version
-				// Cardinality: optional, one
-				// LicenseListVersion: spdxlicense.Version,
-
-				// 2.8: Creators: may have multiple keys for Person, Organization
-				//      and/or Tool
-				// Cardinality: mandatory, one or many
-				CreatorPersons:       nil,
-				CreatorOrganizations: []string{"Defenuse Unicorns"},
-				CreatorTools:         []string{"sbom-cli"},
-
-				// 2.9: Created: data format YYYY-MM-DDThh:mm:ssZ
-				// Cardinality: mandatory, one
-				Created: time.Now().UTC().Format(time.RFC3339),
-
-				// 2.10: Creator Comment
-				// Cardinality: optional, one
-				CreatorComment: "",
-
-				// 2.11: Document Comment
-				// Cardinality: optional, one
-				DocumentComment: "",
-			},
-			Packages: make(map[spdx.ElementID]*spdx.Package2_2),
+		keyring, _ := cmd.Flags().GetString("keyring")
+		requireProvenance, _ := cmd.Flags().GetBool("require-provenance")
+		imageDocuments, _ := cmd.Flags().GetString("image-documents")
+		chartFiles, _ := cmd.Flags().GetBool("chart-files")
+		if imageDocuments != "" && format != generator.FormatSPDX {
+			panic(fmt.Errorf("--image-documents only works with spdx output"))
 		}
-		for _, image := range imageList {
-			fmt.Printf("Found an image: %v\n", image)
-			doc, err := syft.Scan(image)
-			if err != nil {
-				panic(err)
-			}
-			imageMap[image] = doc
-			//add entry for the image
-			chartBom.Packages[spdx.ElementID(fmt.Sprintf("image-%v", image))] = sbom.ImageToPackage(doc.CreationInfo.DocumentName)
-			// Add all the packages from the image too
-			fmt.Printf("The image %v has %v packages inside of it\n", image, len(doc.Packages))
-			for k, v := range doc.Packages {
-				chartBom.Packages[k] = v
-			}
+		opts := []generator.Option{generator.WithFormats(format), generator.WithScanner(scannerFromFlags(cmd)), generator.WithProgress(os.Stdout),
+			generator.WithKeyring(keyring), generator.WithRequireProvenance(requireProvenance), timestampFromFlags(cmd),
+			generator.WithImageDocuments(imageDocuments != ""), generator.WithChartFiles(chartFiles)}
+		opts = append(opts, creatorsFromFlags(cmd)...)
+		opts = append(opts, resolverFromFlags(cmd)...)
+		gen := generator.New(append(opts, namespaceFromFlags(cmd)...)...)
+		res, err := gen.Generate(context.Background(), p)
+		if err != nil {
+			panic(err)
 		}
-		// Add CPEs:
-
-		for _, cpe := range helm.CPEs(chart) {
-			ext := spdx.PackageExternalReference2_2{
-				RefType: string(syft.Cpe23ExternalRefType),
-				Locator: cpe,
+		if imageDocuments != "" {
+			if err := os.MkdirAll(imageDocuments, 0755); err != nil {
+				panic(err)
 			}
-			parts := strings.Split(cpe, ":")
-			ar := []*spdx.PackageExternalReference2_2{&ext}
-			chartBom.Packages[spdx.ElementID(fmt.Sprintf("cpe-%v", cpe))] = &spdx.Package2_2{
-				PackageName:               parts[3],
-				PackageSPDXIdentifier:     spdx.ElementID(fmt.Sprintf("cpe-%v", cpe)),
-				PackageExternalReferences: ar,
+			for image, b := range res.EncodedImageSPDX {
+				file := filepath.Join(imageDocuments, scanner.FileName(image)+".spdx")
+				if err := ioutil.WriteFile(file, b, 0644); err != nil {
+					panic(err)
+				}
+				fmt.Printf("Wrote the document of %v to %v\n", image, file)
 			}
 		}
 
@@ -156,67 +89,183 @@ to quickly create a Cobra application.`,
 			panic(err)
 		}
 		if file != "" {
-			//check  output format
-			format, _ := cmd.Flags().GetString("output-format")
-			if format == "cyclonedx" {
-				cycloneBom := sbom.ToCycloneDX(&chartBom)
-				cycloneBom.Metadata.Component = &cyclonedx.Component{
-					Name:    chart.Metadata.Name,
-					Version: chart.Metadata.Version,
-					//Authors, etc
-					BOMRef: chart.Metadata.Name,
+			if format == generator.FormatCycloneDX {
+				if validate, _ := cmd.Flags().GetBool("validate"); validate {
+					checkBeforeWrite(sbom.ValidateCycloneDX(res.CycloneDX))
 				}
-
-				// chartDependency.Dependencies = &tmpDep
-				// imageRefs := make([]cyclonedx.Dependency, len(imageList))
-
-				// For each image in the list, make an entry that the image is a dependency of the chart
-				chartDependency := cyclonedx.Dependency{
-					Ref: chart.Metadata.Name,
-				}
-				imagesInChart := make([]cyclonedx.Dependency, 0)
-				for _, image := range imageList {
-					// imageRefs[i].Ref = image
-					imagesInChart = append(imagesInChart, cyclonedx.Dependency{
-						Ref: fmt.Sprintf("image-%v", image), //matches the ElementID for spdx
-					})
-				}
-				chartDependency.Dependencies = &imagesInChart
-
-				//Add the images as a dependency of the chart
-				cycloneBom.Dependencies = &[]cyclonedx.Dependency{chartDependency}
-
-				// For each image in the list, make a list of dependencies of the image.
-				for image, doc := range imageMap {
-					imageDep := cyclonedx.Dependency{
-						Ref: fmt.Sprintf("image-%v", image), //matches the ElementID for spdx
-					}
-					packageRefs := make([]cyclonedx.Dependency, 0)
-					//add this image as a dependency of the package
-					//Adding dependencies for image
-					for k := range doc.Packages {
-						tmp := append(packageRefs, cyclonedx.Dependency{
-							Ref: string(k),
-						})
-						packageRefs = tmp
-					}
-					imageDep.Dependencies = &packageRefs
-					tmp := append(*cycloneBom.Dependencies, imageDep)
-					cycloneBom.Dependencies = &tmp
-				}
-
-				sbom.WriteCycloneDX(file, cycloneBom)
+				sbom.WriteCycloneDX(file, res.CycloneDX)
 			} else {
-				sbom.WriteSPDX(file, &chartBom)
+				if validate, _ := cmd.Flags().GetBool("validate"); validate {
+					checkBeforeWrite(sbom.ValidateSPDX(res.SPDX))
+				}
+				sbom.WriteSPDX(file, res.SPDX)
 			}
-			//
 		} else { //stdout
-			b, _ := json.MarshalIndent(chartBom, "", "\t")
+			b, _ := json.MarshalIndent(res.SPDX, "", "\t")
 			fmt.Printf("%v\n", string(b))
 		}
+
+		if db, _ := cmd.Flags().GetString("inventory"); db != "" {
+			store, err := inventory.Open(db)
+			if err != nil {
+				panic(err)
+			}
+			defer store.Close()
+			e, err := store.Add(res.Encoded[format], res.Chart.Metadata.Name, res.Chart.Metadata.Version, p)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Printf("Added %v to the inventory %v\n", e.Key, db)
+		}
 	},
 }
 
+// addScannerFlags adds the flags that pick how images are scanned
+func addScannerFlags(cmd *cobra.Command) {
+	cmd.Flags().String("scanner", scanner.Syft, fmt.Sprintf("how to find the packages in the images, one of %v", strings.Join(scanner.Names, ", ")))
+	cmd.Flags().StringToString("image-sbom", map[string]string{}, "with --scanner import, the SBOM of an image, as image=file")
+	cmd.Flags().String("image-sbom-dir", "", "with --scanner import, a directory of image SBOMs named after the image with /, : and @ replaced by _")
+	cmd.Flags().Bool("attached-sboms", false, "use the SBOM attached to an image in its registry, as a referrer or under the cosign sbom tag, before scanning it")
+	cmd.Flags().Bool("insecure-registry", false, "talk to registries over plain http")
+	cmd.Flags().Bool("resolve-digests", false, "resolve image tags to the digests of their manifests through the registries")
+	cmd.Flags().String("image-layout", "", "OCI image layout to resolve image tags in before asking the registries")
+	cmd.Flags().StringSlice("platforms", nil, "scan the image of each of these platforms, as os/arch[/variant], in the image indexes the images point at")
+}
+
+// resolverFromFlags resolves image digests in the layout of --image-layout
+// and, with --resolve-digests or --platforms, the registries, and expands
+// image indexes into the images of the platforms of --platforms
+func resolverFromFlags(cmd *cobra.Command) []generator.Option {
+	resolve, _ := cmd.Flags().GetBool("resolve-digests")
+	layout, _ := cmd.Flags().GetString("image-layout")
+	names, _ := cmd.Flags().GetStringSlice("platforms")
+	if !resolve && layout == "" && len(names) == 0 {
+		return []generator.Option{generator.WithResolver(nil)}
+	}
+	r := &imageref.Resolver{Layout: layout}
+	if resolve || len(names) > 0 {
+		r.Client = registryClientFromFlags(cmd)
+	}
+	platforms := make([]oci.Platform, 0, len(names))
+	for _, name := range names {
+		p, err := imageref.ParsePlatform(name)
+		if err != nil {
+			panic(err)
+		}
+		platforms = append(platforms, p)
+	}
+	return []generator.Option{generator.WithResolver(r), generator.WithPlatforms(platforms...)}
+}
+
+func scannerFromFlags(cmd *cobra.Command) scanner.Scanner {
+	name, _ := cmd.Flags().GetString("scanner")
+	files, _ := cmd.Flags().GetStringToString("image-sbom")
+	dir, _ := cmd.Flags().GetString("image-sbom-dir")
+	s, err := scanner.New(name, files, dir)
+	if err != nil {
+		panic(err)
+	}
+	if attached, _ := cmd.Flags().GetBool("attached-sboms"); attached {
+		s = &scanner.AttachedScanner{Client: registryClientFromFlags(cmd), Fallback: s}
+	}
+	return s
+}
+
+// addNamespaceFlags adds the flags that set how SPDX document namespaces are
+// made, which the namespace.base and namespace.strategy keys of the config
+// file set too
+func addNamespaceFlags(cmd *cobra.Command) {
+	cmd.Flags().String("namespace-base", "", "URI SPDX document namespaces are made from; namespace.base in the config file, else https://bigbang.dev")
+	cmd.Flags().String("namespace-strategy", "", fmt.Sprintf("how namespaces are made unique, one of %v; namespace.strategy in the config file, else hash", strings.Join(generator.NamespaceStrategies, ", ")))
+}
+
+func namespaceFromFlags(cmd *cobra.Command) []generator.Option {
+	base, _ := cmd.Flags().GetString("namespace-base")
+	if base == "" {
+		base = viper.GetString("namespace.base")
+	}
+	strategy, _ := cmd.Flags().GetString("namespace-strategy")
+	if strategy == "" {
+		strategy = viper.GetString("namespace.strategy")
+	}
+	opts := make([]generator.Option, 0)
+	if base != "" {
+		opts = append(opts, generator.WithNamespaceBase(base))
+	}
+	if strategy != "" {
+		opts = append(opts, generator.WithNamespaceStrategy(strategy))
+	}
+	return opts
+}
+
+// addCreatorFlags adds the flags that set who the SBOM names as its creators
+// and as the chart's supplier and originator, which the creator.persons,
+// creator.organizations, supplier and originator keys of the config file set
+// too. Entities are written the SPDX way, e.g. "Person: Jane Doe
+// (jane@example.com)" or "Organization: Acme"; without a prefix they are
+// organizations.
+func addCreatorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("creator-person", nil, "person who created the SBOM, as \"Jane Doe (jane@example.com)\"; creator.persons in the config file")
+	cmd.Flags().StringSlice("creator-organization", nil, "organization that created the SBOM; creator.organizations in the config file, else Defense Unicorns")
+	cmd.Flags().String("supplier", "", "who distributes the chart, as \"Person: ...\" or \"Organization: ...\"; supplier in the config file, else the chart's first maintainer")
+	cmd.Flags().String("originator", "", "who originally made the chart, as \"Person: ...\" or \"Organization: ...\"; originator in the config file, else the chart's first maintainer")
+}
+
+// creatorsFromFlags are the creators, supplier and originator from the flags
+// or, where they are unset, the config file
+func creatorsFromFlags(cmd *cobra.Command) []generator.Option {
+	persons, _ := cmd.Flags().GetStringSlice("creator-person")
+	if len(persons) == 0 {
+		persons = viper.GetStringSlice("creator.persons")
+	}
+	organizations, _ := cmd.Flags().GetStringSlice("creator-organization")
+	if len(organizations) == 0 {
+		organizations = viper.GetStringSlice("creator.organizations")
+	}
+	creators := make([]sbom.Entity, 0, len(persons)+len(organizations))
+	for _, p := range persons {
+		e := sbom.ParseEntity(p)
+		e.Kind = sbom.EntityPerson
+		creators = append(creators, e)
+	}
+	for _, o := range organizations {
+		creators = append(creators, sbom.ParseEntity(o))
+	}
+	opts := []generator.Option{generator.WithCreators(creators...), generator.WithToolVersion(Version)}
+	for flag, with := range map[string]func(sbom.Entity) generator.Option{"supplier": generator.WithSupplier, "originator": generator.WithOriginator} {
+		value, _ := cmd.Flags().GetString(flag)
+		if value == "" {
+			value = viper.GetString(flag)
+		}
+		if value != "" {
+			opts = append(opts, with(sbom.ParseEntity(value)))
+		}
+	}
+	return opts
+}
+
+// addTimestampFlag adds --timestamp, see timestampFromFlags
+func addTimestampFlag(cmd *cobra.Command) {
+	cmd.Flags().String("timestamp", "", "creation time to record, RFC 3339 or seconds since the epoch; SOURCE_DATE_EPOCH if empty, else now")
+}
+
+// timestampFromFlags is the creation time from --timestamp or
+// SOURCE_DATE_EPOCH, so rebuilds of the same chart produce the same SBOM
+func timestampFromFlags(cmd *cobra.Command) generator.Option {
+	ts, _ := cmd.Flags().GetString("timestamp")
+	if ts == "" {
+		ts = os.Getenv("SOURCE_DATE_EPOCH")
+	}
+	if ts == "" {
+		return generator.WithTimestamp(time.Time{})
+	}
+	t, err := generator.ParseTimestamp(ts)
+	if err != nil {
+		panic(err)
+	}
+	return generator.WithTimestamp(t)
+}
+
 func init() {
 	rootCmd.AddCommand(createCmd)
 
@@ -227,6 +276,16 @@ func init() {
 	createCmd.Flags().String("path", "", "for the chart")
 	createCmd.Flags().String("output-file", "", "output file for merged content")
 	createCmd.Flags().String("output-format", "spdx", "output file format, spdx or cyclonedx")
+	createCmd.Flags().Bool("validate", false, "validate the BOM before writing it")
+	createCmd.Flags().String("inventory", "", "inventory database to add the SBOM to")
+	createCmd.Flags().String("keyring", "", "verify a chart archive against its .prov file with this keyring and record the result")
+	createCmd.Flags().Bool("require-provenance", false, "fail charts whose .prov file is missing or does not verify, with --keyring or helm's default keyring")
+	addScannerFlags(createCmd)
+	addTimestampFlag(createCmd)
+	addNamespaceFlags(createCmd)
+	addCreatorFlags(createCmd)
+	createCmd.Flags().Bool("chart-files", false, "catalog every file of the chart, e.g. templates, values.yaml and subchart archives, with SHA1 and SHA256 checksums")
+	createCmd.Flags().String("image-documents", "", "directory to write an SPDX document per image to, which the chart document refers to instead of listing the images' packages")
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
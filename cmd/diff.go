@@ -0,0 +1,74 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Shows what changed between two SBOMs",
+	Long: `Compares two SBOMs (SPDX or CycloneDX, in any encoding) and reports the
+components that were added, removed, changed version or changed license in each
+image, along with the dependency edges that changed.
+
+Use --fail-on to exit non-zero in CI, e.g.
+  sbom-cli diff --old istio-1.11.2-bb.0.xml --new istio-1.11.3-bb.0.xml --fail-on downgrade,license:GPL-*`,
+	Run: func(cmd *cobra.Command, args []string) {
+		oldFilename, _ := cmd.Flags().GetString("old")
+		newFilename, _ := cmd.Flags().GetString("new")
+		oldDoc, err := sbom.Load(oldFilename)
+		if err != nil {
+			panic(err)
+		}
+		newDoc, err := sbom.Load(newFilename)
+		if err != nil {
+			panic(err)
+		}
+
+		diff := sbom.Compare(oldDoc, newDoc)
+		format, _ := cmd.Flags().GetString("output-format")
+		if err := diff.Write(os.Stdout, format); err != nil {
+			panic(err)
+		}
+
+		failOn, _ := cmd.Flags().GetStringSlice("fail-on")
+		failures, err := diff.Failures(failOn)
+		if err != nil {
+			panic(err)
+		}
+		if len(failures) > 0 {
+			for _, f := range failures {
+				fmt.Fprintf(os.Stderr, "FAIL: %v\n", f)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().String("old", "", "the SBOM to compare from")
+	diffCmd.Flags().String("new", "", "the SBOM to compare to")
+	diffCmd.Flags().String("output-format", "text", "output format, text, json or markdown")
+	diffCmd.Flags().StringSlice("fail-on", []string{}, "exit non-zero on: added, removed, version-change, downgrade, license-change, dependency-change, license:<glob>")
+}
@@ -0,0 +1,228 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/inventory"
+	"github.com/spf13/cobra"
+)
+
+// inventoryCmd represents the inventory command
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Keeps SBOMs in a local database to search and track over time",
+	Long: `Groups the commands that work with the inventory, an embedded database of SBOMs
+keyed by chart name and version, with indexes on PURL, CPE, license, image digest and
+name and a history of when each package appeared in a chart.  create --inventory adds
+the SBOMs it generates, e.g.
+  sbom-cli inventory add --input istio.xml
+  sbom-cli inventory search --purl 'pkg:deb/debian/openssl@*'
+  sbom-cli inventory history --chart istio --package openssl`,
+}
+
+func openInventory(cmd *cobra.Command) *inventory.Store {
+	path, _ := cmd.Flags().GetString("db")
+	store, err := inventory.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	return store
+}
+
+// inventoryAddCmd represents the inventory add command
+var inventoryAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Adds an SBOM to the inventory",
+	Long: `Adds an SPDX or CycloneDX SBOM to the inventory.  The chart name and version come
+from the SBOM unless given; SPDX documents do not carry the chart version, so give
+--version for them.  Adding a chart version that is already there replaces it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		chart, _ := cmd.Flags().GetString("chart")
+		version, _ := cmd.Flags().GetString("version")
+		raw, err := ioutil.ReadFile(input)
+		if err != nil {
+			panic(err)
+		}
+		store := openInventory(cmd)
+		defer store.Close()
+		e, err := store.Add(raw, chart, version, input)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("Added %v with %v components\n", e.Key, len(e.Document.Components))
+	},
+}
+
+// inventoryLsCmd represents the inventory ls command
+var inventoryLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists the SBOMs in the inventory",
+	Run: func(cmd *cobra.Command, args []string) {
+		chart, _ := cmd.Flags().GetString("chart")
+		format, _ := cmd.Flags().GetString("output-format")
+		store := openInventory(cmd)
+		defer store.Close()
+		entries, err := store.List(chart)
+		if err != nil {
+			panic(err)
+		}
+		if err := inventory.WriteEntries(os.Stdout, entries, format); err != nil {
+			panic(err)
+		}
+	},
+}
+
+// inventoryShowCmd represents the inventory show command
+var inventoryShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Shows an SBOM in the inventory",
+	Long:  `Shows the components of a chart version, or with --raw the SBOM exactly as it was added.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		chart, _ := cmd.Flags().GetString("chart")
+		version, _ := cmd.Flags().GetString("version")
+		format, _ := cmd.Flags().GetString("output-format")
+		store := openInventory(cmd)
+		defer store.Close()
+		if raw, _ := cmd.Flags().GetBool("raw"); raw {
+			b, err := store.Raw(chart, version)
+			if err != nil {
+				panic(err)
+			}
+			os.Stdout.Write(b)
+			return
+		}
+		e, err := store.Get(chart, version)
+		if err != nil {
+			panic(err)
+		}
+		if err := inventory.WriteEntry(os.Stdout, e, format); err != nil {
+			panic(err)
+		}
+	},
+}
+
+// inventorySearchCmd represents the inventory search command
+var inventorySearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Finds components across the SBOMs in the inventory",
+	Long: `Looks components up in the indexes.  Values match case insensitively, exactly or
+as a prefix when they end in *.  License searches match the license IDs in the
+expressions, so --license GPL-2.0-only finds "GPL-2.0-only OR MIT".  Digest searches
+find the images, e.g.
+  sbom-cli inventory search --digest sha256:4a5b*`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("output-format")
+		field, value := "", ""
+		for _, f := range inventory.Fields {
+			if v, _ := cmd.Flags().GetString(f); v != "" {
+				if field != "" {
+					panic(fmt.Errorf("search on one of --%v or --%v, not both", field, f))
+				}
+				field, value = f, v
+			}
+		}
+		if field == "" {
+			panic(fmt.Errorf("give one of --purl, --cpe, --license, --digest or --name to search on"))
+		}
+		store := openInventory(cmd)
+		defer store.Close()
+		matches, err := store.Search(field, value)
+		if err != nil {
+			panic(err)
+		}
+		if err := inventory.WriteMatches(os.Stdout, matches, format); err != nil {
+			panic(err)
+		}
+	},
+}
+
+// inventoryRmCmd represents the inventory rm command
+var inventoryRmCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "Removes an SBOM from the inventory",
+	Long:  `Removes a chart version from the inventory, with its index entries and history.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		chart, _ := cmd.Flags().GetString("chart")
+		version, _ := cmd.Flags().GetString("version")
+		store := openInventory(cmd)
+		defer store.Close()
+		if err := store.Remove(chart, version); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Removed %v\n", inventory.Key(chart, version))
+	},
+}
+
+// inventoryHistoryCmd represents the inventory history command
+var inventoryHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Shows when packages appeared in a chart",
+	Long: `Lists the packages of a chart with the chart version they first appeared in and
+the version of them in every chart version since, in the order the chart versions were
+added.  Packages are tracked by PURL without the version, so an upgrade is the same
+package.  --package narrows it down by name or PURL, with a trailing * for a prefix.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		chart, _ := cmd.Flags().GetString("chart")
+		pkg, _ := cmd.Flags().GetString("package")
+		format, _ := cmd.Flags().GetString("output-format")
+		store := openInventory(cmd)
+		defer store.Close()
+		timelines, err := store.History(chart, pkg)
+		if err != nil {
+			panic(err)
+		}
+		if err := inventory.WriteHistory(os.Stdout, timelines, format); err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryCmd)
+	inventoryCmd.AddCommand(inventoryAddCmd, inventoryLsCmd, inventoryShowCmd, inventorySearchCmd, inventoryRmCmd, inventoryHistoryCmd)
+
+	inventoryCmd.PersistentFlags().String("db", "inventory.db", "inventory database file")
+
+	inventoryAddCmd.Flags().String("input", "", "SBOM to add")
+	inventoryAddCmd.Flags().String("chart", "", "chart name, from the SBOM if empty")
+	inventoryAddCmd.Flags().String("version", "", "chart version, from the SBOM if empty")
+
+	inventoryLsCmd.Flags().String("chart", "", "only list the versions of this chart")
+	inventoryLsCmd.Flags().String("output-format", "table", "output format, table or json")
+
+	inventoryShowCmd.Flags().String("chart", "", "chart name")
+	inventoryShowCmd.Flags().String("version", "", "chart version")
+	inventoryShowCmd.Flags().Bool("raw", false, "print the SBOM as it was added")
+	inventoryShowCmd.Flags().String("output-format", "table", "output format, table or json")
+
+	inventorySearchCmd.Flags().String(inventory.PURL, "", "PURL to find")
+	inventorySearchCmd.Flags().String(inventory.CPE, "", "CPE to find")
+	inventorySearchCmd.Flags().String(inventory.License, "", "license ID to find")
+	inventorySearchCmd.Flags().String(inventory.Digest, "", "image digest to find, e.g. sha256:...")
+	inventorySearchCmd.Flags().String(inventory.Name, "", "component name to find")
+	inventorySearchCmd.Flags().String("output-format", "table", "output format, table or json")
+
+	inventoryRmCmd.Flags().String("chart", "", "chart name")
+	inventoryRmCmd.Flags().String("version", "", "chart version")
+
+	inventoryHistoryCmd.Flags().String("chart", "", "chart name")
+	inventoryHistoryCmd.Flags().String("package", "", "package name or PURL without version, a trailing * matches a prefix")
+	inventoryHistoryCmd.Flags().String("output-format", "table", "output format, table or json")
+}
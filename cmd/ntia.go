@@ -0,0 +1,75 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/ntia"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// ntiaCmd represents the check ntia command
+var ntiaCmd = &cobra.Command{
+	Use:   "ntia",
+	Short: "Scores an SBOM against the NTIA minimum elements",
+	Long: `Checks every component of an SPDX or CycloneDX SBOM for the NTIA minimum
+elements (supplier, component name, version, unique identifier, dependency
+relationship) and the document for an author and timestamp.  Each gap is listed with
+a suggested fix.  Output is text, json or a JUnit report for CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		doc, err := sbom.Load(input)
+		if err != nil {
+			panic(err)
+		}
+		report := ntia.Check(doc)
+
+		format, _ := cmd.Flags().GetString("output-format")
+		out := os.Stdout
+		if file, _ := cmd.Flags().GetString("output-file"); file != "" {
+			out, err = os.Create(file)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if err := report.Write(out, format); err != nil {
+			panic(err)
+		}
+		// closed here, not deferred, as os.Exit doesn't run deferred calls
+		if out != os.Stdout {
+			if err := out.Close(); err != nil {
+				panic(err)
+			}
+		}
+
+		failUnder, _ := cmd.Flags().GetFloat64("fail-under")
+		if report.Score < failUnder {
+			fmt.Fprintf(os.Stderr, "NTIA score %.1f%% is under %.1f%%\n", report.Score, failUnder)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	checkCmd.AddCommand(ntiaCmd)
+
+	ntiaCmd.Flags().String("input", "", "SBOM to check")
+	ntiaCmd.Flags().String("output-format", "text", "output format, text, json or junit")
+	ntiaCmd.Flags().String("output-file", "", "file to write the report to, stdout if empty")
+	ntiaCmd.Flags().Float64("fail-under", 0, "exit non-zero if the overall score (percent) is under this")
+}
@@ -0,0 +1,109 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/policy"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// policyCmd represents the policy command
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Checks the licenses in an SBOM against a license policy",
+	Long: `Evaluates the license expression of every package in an SPDX or CycloneDX SBOM
+against a policy of allowed, denied and review-required licenses, and exits non-zero
+if any package violates it.  The policy comes from a YAML file given with --policy, or
+from the policy key of the config file, e.g.
+
+policy:
+  allow: [MIT, Apache-2.0, BSD-*, ISC]
+  deny: [AGPL-*, SSPL-*]
+  review: [GPL-*, LGPL-*]
+  default: review
+  noLicense: review
+  failOnReview: false
+  exceptions:
+    - component: pkg:deb/debian/bash@*
+      licenses: [GPL-3.0-*]
+      reason: shell in the base image, not linked`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		policyFile, _ := cmd.Flags().GetString("policy")
+
+		var p *policy.Policy
+		var err error
+		switch {
+		case policyFile != "":
+			p, err = policy.Load(policyFile)
+		case viper.IsSet("policy"):
+			p = &policy.Policy{}
+			if err = viper.UnmarshalKey("policy", p); err == nil {
+				err = p.Validate()
+			}
+		default:
+			err = fmt.Errorf("no policy: use --policy or set policy in the config file")
+		}
+		if err != nil {
+			panic(err)
+		}
+		if failOnReview, _ := cmd.Flags().GetBool("fail-on-review"); failOnReview {
+			p.FailOnReview = true
+		}
+
+		doc, err := sbom.Load(input)
+		if err != nil {
+			panic(err)
+		}
+		report := p.Evaluate(doc)
+
+		format, _ := cmd.Flags().GetString("output-format")
+		out := os.Stdout
+		if file, _ := cmd.Flags().GetString("output-file"); file != "" {
+			out, err = os.Create(file)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if err := report.Write(out, format); err != nil {
+			panic(err)
+		}
+		// a failing policy exits below, skipping any deferred close
+		if out != os.Stdout {
+			if err := out.Close(); err != nil {
+				panic(err)
+			}
+		}
+		if !report.Pass {
+			fmt.Fprintf(os.Stderr, "%v packages violate the license policy\n", len(report.Violations()))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+
+	policyCmd.Flags().String("input", "", "SBOM to check")
+	policyCmd.Flags().String("policy", "", "license policy YAML file, defaults to the policy key of the config file")
+	policyCmd.Flags().String("output-format", "text", "output format, text or json")
+	policyCmd.Flags().String("output-file", "", "file to write the report to, stdout if empty")
+	policyCmd.Flags().Bool("fail-on-review", false, "fail on licenses that need a review, not just denied ones")
+}
@@ -0,0 +1,107 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/spf13/cobra"
+)
+
+// pushCmd represents the push command
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Stores an SBOM or attestation next to a chart in an OCI registry",
+	Long: `Uploads an SBOM, or an attestation made by attest, as an OCI artifact whose subject
+is the chart pushed with helm push.  The artifact type is the SBOM's media type,
+e.g. application/spdx+json, or application/vnd.dsse.envelope.v1+json for an
+attestation.  Registries without the referrers API find it under the
+sha256-<digest> tag.  Credentials are the ones docker and helm registry login keep.
+
+  sbom-cli push --input chart.spdx --chart oci://registry.example.com/charts/demo:1.0.0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		chart, _ := cmd.Flags().GetString("chart")
+		content, err := ioutil.ReadFile(input)
+		if err != nil {
+			panic(err)
+		}
+		artifactType, _ := cmd.Flags().GetString("artifact-type")
+		if artifactType == "" {
+			if artifactType, err = oci.MediaTypeOf(content); err != nil {
+				panic(fmt.Errorf("%v: %w", input, err))
+			}
+		}
+		client := registryClientFromFlags(cmd)
+		ref, err := client.Push(context.Background(), chart, artifactType, content, map[string]string{oci.AnnotationTitle: filepath.Base(input)})
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(ref)
+	},
+}
+
+// pullCmd represents the pull command
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Fetches the SBOM or attestation stored next to a chart",
+	Long: `Finds the newest SBOM, or attestation with --attestation, among the referrers of a
+chart in an OCI registry, as stored by push, and writes it out.
+
+  sbom-cli pull --chart oci://registry.example.com/charts/demo:1.0.0 --output-file chart.spdx`,
+	Run: func(cmd *cobra.Command, args []string) {
+		chart, _ := cmd.Flags().GetString("chart")
+		types := make([]string, 0)
+		if attestation, _ := cmd.Flags().GetBool("attestation"); attestation {
+			types = append(types, oci.MediaTypeDSSE)
+		}
+		if artifactType, _ := cmd.Flags().GetString("artifact-type"); artifactType != "" {
+			types = append(types, artifactType)
+		}
+		artifact, err := registryClientFromFlags(cmd).Pull(context.Background(), chart, types...)
+		if err != nil {
+			panic(err)
+		}
+		if artifact == nil {
+			panic(fmt.Errorf("nothing of the asked type is attached to %v", chart))
+		}
+		writeOutput(cmd, artifact.Content)
+	},
+}
+
+func registryClientFromFlags(cmd *cobra.Command) *oci.Client {
+	insecure, _ := cmd.Flags().GetBool("insecure-registry")
+	return &oci.Client{Insecure: insecure}
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(pullCmd)
+
+	pushCmd.Flags().String("input", "", "SBOM or attestation to push")
+	pushCmd.Flags().String("chart", "", "chart in the registry, e.g. oci://registry.example.com/charts/demo:1.0.0")
+	pushCmd.Flags().String("artifact-type", "", "media type to store the file as, worked out from its content if empty")
+	pushCmd.Flags().Bool("insecure-registry", false, "talk to registries over plain http")
+
+	pullCmd.Flags().String("chart", "", "chart in the registry, e.g. oci://registry.example.com/charts/demo:1.0.0")
+	pullCmd.Flags().Bool("attestation", false, "fetch the attestation instead of the SBOM")
+	pullCmd.Flags().String("artifact-type", "", "fetch an artifact of this media type instead")
+	pullCmd.Flags().String("output-file", "", "file to write it to, stdout if empty")
+	pullCmd.Flags().Bool("insecure-registry", false, "talk to registries over plain http")
+}
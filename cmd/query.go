@@ -0,0 +1,84 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/query"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Searches for components across SBOMs",
+	Long: `Loads one or more SBOMs (SPDX or CycloneDX, in any encoding) and lists the
+components matching all of the given filters, with the path chart → image → package
+for each, e.g. which charts ship openssl 1.1.1k:
+  sbom-cli query --input-files istio.xml,monitoring.spdx --name 'libssl*' --version '>=1.1.1a,<1.1.1l'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFiles, _ := cmd.Flags().GetStringSlice("input-files")
+		filter := query.Filter{}
+		filter.Name, _ = cmd.Flags().GetString("name")
+		filter.PURLType, _ = cmd.Flags().GetString("purl-type")
+		filter.Versions, _ = cmd.Flags().GetString("version")
+		filter.License, _ = cmd.Flags().GetString("license")
+		filter.CPE, _ = cmd.Flags().GetString("cpe")
+		filter.Image, _ = cmd.Flags().GetString("image")
+
+		hits := make([]query.Hit, 0)
+		for _, file := range inputFiles {
+			doc, err := sbom.Load(file)
+			if err != nil {
+				panic(err)
+			}
+			found, err := query.Search(file, doc, filter)
+			if err != nil {
+				panic(err)
+			}
+			hits = append(hits, found...)
+		}
+
+		format, _ := cmd.Flags().GetString("output-format")
+		out := os.Stdout
+		if file, _ := cmd.Flags().GetString("output-file"); file != "" {
+			var err error
+			out, err = os.Create(file)
+			if err != nil {
+				panic(err)
+			}
+			defer out.Close()
+		}
+		if err := query.Write(out, hits, format); err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringSlice("input-files", []string{}, "SBOMs to search")
+	queryCmd.Flags().String("name", "", "glob on the component name")
+	queryCmd.Flags().String("purl-type", "", "PURL type, e.g. deb, apk, rpm, npm, maven")
+	queryCmd.Flags().String("version", "", "version constraints that must all hold, e.g. '>=1.1.1a,<1.1.1l' or '=1.1.1k'")
+	queryCmd.Flags().String("license", "", "glob on the license IDs")
+	queryCmd.Flags().String("cpe", "", "glob on the CPEs")
+	queryCmd.Flags().String("image", "", "glob on the name of the image the component is in")
+	queryCmd.Flags().String("output-format", "table", "output format, table, json or csv")
+	queryCmd.Flags().String("output-file", "", "file to write the results to, stdout if empty")
+}
@@ -0,0 +1,115 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spf13/cobra"
+)
+
+// removeCmd represents the remove command
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes a component and everything under it from a BOM",
+	Long: `Removes the components matching --selector from a BOM along with the subtree
+below them.  Components that are only reachable through the removed component are
+pruned, the dependency graph (CycloneDX) or relationships (SPDX) are kept consistent.
+
+The selector is one of:
+  BOMRef / SPDX identifier   image-registry1.dso.mil/ironbank/opensource/istio/pilot:1.11.2
+  PURL                       pkg:deb/debian/openssl@1.1.1k
+  name@version               openssl@1.1.1k`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("remove called")
+
+		inputFilename, _ := cmd.Flags().GetString("input")
+		selector, _ := cmd.Flags().GetString("selector")
+		if selector == "" {
+			panic(fmt.Errorf("--selector is required"))
+		}
+		sel := sbom.ParseSelector(selector)
+		format, _ := cmd.Flags().GetString("format")
+		outFilename, _ := cmd.Flags().GetString("output")
+
+		switch format {
+		case "spdx":
+			doc, err := sbom.ReadSPDX(inputFilename)
+			if err != nil {
+				panic(err)
+			}
+			removed, err := sbom.RemoveSPDX(doc, sel)
+			if err != nil {
+				panic(err)
+			}
+			printRemoved(removed)
+			writeSPDXOrPrint(outFilename, doc)
+		case "cyclonedx":
+			bom, err := sbom.ReadCycloneDX(inputFilename)
+			if err != nil {
+				panic(err)
+			}
+			removed, err := sbom.RemoveCycloneDX(bom, sel)
+			if err != nil {
+				panic(err)
+			}
+			printRemoved(removed)
+			writeCycloneDXOrPrint(outFilename, bom)
+		default:
+			panic(fmt.Errorf("unknown format %v", format))
+		}
+	},
+}
+
+func printRemoved(removed []string) {
+	for _, ref := range removed {
+		fmt.Printf("Removed %v\n", ref)
+	}
+}
+
+func writeSPDXOrPrint(filename string, doc *spdx.Document2_2) {
+	if filename != "" {
+		if err := sbom.WriteSPDX(filename, doc); err != nil {
+			panic(err)
+		}
+	} else {
+		b, _ := json.MarshalIndent(doc, "", "\t")
+		fmt.Printf("%v\n", string(b))
+	}
+}
+
+func writeCycloneDXOrPrint(filename string, bom *cyclonedx.BOM) {
+	if filename != "" {
+		if err := sbom.WriteCycloneDX(filename, bom); err != nil {
+			panic(err)
+		}
+	} else {
+		b, _ := json.MarshalIndent(bom, "", "\t")
+		fmt.Printf("%v\n", string(b))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+
+	removeCmd.Flags().String("input", "", "input file to load BOM from")
+	removeCmd.Flags().String("output", "", "output file to write new BOM")
+	removeCmd.Flags().String("format", "spdx", "BOM Format, spdx or cyclonedx")
+	removeCmd.Flags().String("selector", "", "component to remove: BOMRef, PURL or name@version")
+}
@@ -0,0 +1,89 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// replaceCmd represents the replace command
+var replaceCmd = &cobra.Command{
+	Use:   "replace",
+	Short: "Swaps a component in a BOM for another BOM",
+	Long: `Removes the components matching --selector (see remove) and nests the BOM
+given with --bom in their place, under the same parents.  Useful when an image in a
+chart is swapped or a subchart is upgraded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("replace called")
+
+		inputFilename, _ := cmd.Flags().GetString("input")
+		bomFilename, _ := cmd.Flags().GetString("bom")
+		selector, _ := cmd.Flags().GetString("selector")
+		if selector == "" {
+			panic(fmt.Errorf("--selector is required"))
+		}
+		sel := sbom.ParseSelector(selector)
+		format, _ := cmd.Flags().GetString("format")
+		outFilename, _ := cmd.Flags().GetString("output")
+
+		switch format {
+		case "spdx":
+			doc, err := sbom.ReadSPDX(inputFilename)
+			if err != nil {
+				panic(err)
+			}
+			leaf, err := sbom.LoadSPDX(bomFilename)
+			if err != nil {
+				panic(err)
+			}
+			removed, err := sbom.ReplaceSPDX(doc, sel, leaf)
+			if err != nil {
+				panic(err)
+			}
+			printRemoved(removed)
+			writeSPDXOrPrint(outFilename, doc)
+		case "cyclonedx":
+			bom, err := sbom.ReadCycloneDX(inputFilename)
+			if err != nil {
+				panic(err)
+			}
+			leaf, err := sbom.ReadCycloneDX(bomFilename)
+			if err != nil {
+				panic(err)
+			}
+			removed, err := sbom.ReplaceCycloneDX(bom, sel, leaf)
+			if err != nil {
+				panic(err)
+			}
+			printRemoved(removed)
+			writeCycloneDXOrPrint(outFilename, bom)
+		default:
+			panic(fmt.Errorf("unknown format %v", format))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replaceCmd)
+
+	replaceCmd.Flags().String("input", "", "input file to load BOM from")
+	replaceCmd.Flags().String("bom", "", "Bill of Materials to put in place of the removed component")
+	replaceCmd.Flags().String("output", "", "output file to write new BOM")
+	replaceCmd.Flags().String("format", "spdx", "BOM Format, spdx or cyclonedx")
+	replaceCmd.Flags().String("selector", "", "component to replace: BOMRef, PURL or name@version")
+}
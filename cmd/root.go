@@ -25,6 +25,10 @@ import (
 
 var cfgFile string
 
+// Version is the version of sbom-cli, set when building with
+// -ldflags "-X github.com/defenseunicorns/spdx-cli/cmd.Version=v1.2.3"
+var Version = "dev"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "cli",
@@ -51,6 +55,7 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.Version = Version
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -0,0 +1,117 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/rules"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// rulesCmd represents the check rules command
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Evaluates CEL rules over the chart, images and packages of an SBOM",
+	Long: `Evaluates rules written in CEL (https://github.com/google/cel-spec) on every
+chart, image and package node of an SPDX or CycloneDX SBOM and reports the failures,
+as SARIF for code scanning dashboards by default.  Rules come from YAML files (or
+directories of them), e.g.
+
+rules:
+  - id: registry1-only
+    description: Images must come from registry1.dso.mil
+    on: image
+    condition: image.registry == "registry1.dso.mil"
+  - id: pinned-by-digest
+    on: image
+    level: warning
+    condition: image.digest != ""
+  - id: log4shell
+    on: package
+    when: pkg.name == "log4j-core"
+    condition: compareVersions(pkg.version, "2.17.1", "maven") >= 0
+
+Every rule sees the chart (name, version, annotations, images, cpes); image rules see
+the image (ref, name, registry, repository, tag, digest, packages) and package rules
+the package as pkg (ref, name, version, type, purl, purlType, cpes, licenses, supplier,
+images).  Pass --path to make the chart's annotations available.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		ruleFiles, _ := cmd.Flags().GetStringSlice("rules")
+		loaded, err := rules.Load(ruleFiles)
+		if err != nil {
+			panic(err)
+		}
+		engine, err := rules.NewEngine(loaded)
+		if err != nil {
+			panic(err)
+		}
+		doc, err := sbom.Load(input)
+		if err != nil {
+			panic(err)
+		}
+
+		var chartInfo *rules.ChartInfo
+		if path, _ := cmd.Flags().GetString("path"); path != "" {
+			chart, err := helm.Read(path)
+			if err != nil {
+				panic(err)
+			}
+			chartInfo = &rules.ChartInfo{
+				Name:        chart.Metadata.Name,
+				Version:     chart.Metadata.Version,
+				Annotations: chart.Metadata.Annotations,
+				CPEs:        helm.CPEs(chart),
+			}
+		}
+
+		report := &rules.Report{Input: input, Rules: loaded, Results: engine.Evaluate(doc, chartInfo)}
+		format, _ := cmd.Flags().GetString("output-format")
+		out := os.Stdout
+		if file, _ := cmd.Flags().GetString("output-file"); file != "" {
+			out, err = os.Create(file)
+			if err != nil {
+				panic(err)
+			}
+		}
+		if err := report.Write(out, format); err != nil {
+			panic(err)
+		}
+		// os.Exit skips deferred calls, so close the report before failing
+		if out != os.Stdout {
+			if err := out.Close(); err != nil {
+				panic(err)
+			}
+		}
+		if errors := report.Errors(); len(errors) > 0 {
+			fmt.Fprintf(os.Stderr, "%v rule violations\n", len(errors))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	checkCmd.AddCommand(rulesCmd)
+
+	rulesCmd.Flags().String("input", "", "SBOM to check")
+	rulesCmd.Flags().StringSlice("rules", []string{}, "rule files or directories of them")
+	rulesCmd.Flags().String("path", "", "for the chart, to make its annotations available to the rules")
+	rulesCmd.Flags().String("output-format", "sarif", "output format, sarif, json or text")
+	rulesCmd.Flags().String("output-file", "", "file to write the report to, stdout if empty")
+}
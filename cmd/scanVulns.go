@@ -0,0 +1,132 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/vex"
+	"github.com/defenseunicorns/spdx-cli/pkg/vulndb"
+	"github.com/spf13/cobra"
+)
+
+// scanVulnsCmd represents the scan-vulns command
+var scanVulnsCmd = &cobra.Command{
+	Use:   "scan-vulns",
+	Short: "Finds known vulnerabilities in an SBOM using a local database",
+	Long: `Matches the components of an SPDX or CycloneDX SBOM against a database built
+with vulndb import, without any network access.  Components are matched by PURL
+(ecosystem, name, and distro release for OS packages) and by CPE, and versions are
+compared with the rules of their ecosystem: deb, rpm, apk, semver or maven.
+
+VEX documents (OpenVEX or CycloneDX) given with --vex set the status of the findings
+they cover; findings that are not_affected or fixed are reported but do not count
+towards --fail-on.
+
+The output is a text or json report, or the input SBOM as CycloneDX with the
+findings added as vulnerabilities, e.g.
+  sbom-cli scan-vulns --input istio-1.11.2-bb.0.xml --db vulndb.json --output-format cyclonedx --output-file istio-vulns.xml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		dbFile, _ := cmd.Flags().GetString("db")
+		format, _ := cmd.Flags().GetString("output-format")
+		outputFile, _ := cmd.Flags().GetString("output-file")
+
+		db, err := vulndb.Load(dbFile)
+		if err != nil {
+			panic(err)
+		}
+		doc, err := sbom.Load(input)
+		if err != nil {
+			panic(err)
+		}
+		findings := vulndb.NewMatcher(db).Match(doc)
+		vexFiles, _ := cmd.Flags().GetStringSlice("vex")
+		if len(vexFiles) > 0 {
+			vexDocs := make([]*vex.Document, 0)
+			for _, f := range vexFiles {
+				fmt.Fprintf(os.Stderr, "Applying VEX statements from %v\n", f)
+				vexDoc, err := vex.Load(f)
+				if err != nil {
+					panic(err)
+				}
+				vexDocs = append(vexDocs, vexDoc)
+			}
+			findings = vulndb.ApplyVEX(findings, doc, vexDocs)
+		}
+		report := vulndb.NewReport(doc.Name, db, findings)
+
+		out := os.Stdout
+		if outputFile != "" {
+			out, err = os.Create(outputFile)
+			if err != nil {
+				panic(err)
+			}
+		}
+		switch format {
+		case "cyclonedx", "cyclonedx-json":
+			bom, err := sbom.LoadCycloneDX(input)
+			if err != nil {
+				panic(err)
+			}
+			vulns := vulndb.ToCycloneDX(db, findings)
+			// vulnerabilities need CycloneDX 1.4
+			bom.XMLNS = "http://cyclonedx.org/schema/bom/1.4"
+			bom.BOMFormat = cyclonedx.BOMFormat
+			bom.SpecVersion = "1.4"
+			bom.Vulnerabilities = &vulns
+			fileFormat := cyclonedx.BOMFileFormatXML
+			if format == "cyclonedx-json" {
+				fileFormat = cyclonedx.BOMFileFormatJSON
+			}
+			encoder := cyclonedx.NewBOMEncoder(out, fileFormat)
+			encoder.SetPretty(true)
+			if err := encoder.Encode(bom); err != nil {
+				panic(err)
+			}
+		default:
+			if err := report.Write(out, format); err != nil {
+				panic(err)
+			}
+		}
+		// flush the output before --fail-on can exit
+		if out != os.Stdout {
+			if err := out.Close(); err != nil {
+				panic(err)
+			}
+		}
+
+		if failOn, _ := cmd.Flags().GetString("fail-on"); failOn != "" {
+			if failing := report.AtLeast(failOn); len(failing) > 0 {
+				fmt.Fprintf(os.Stderr, "%v vulnerabilities of %v severity or higher\n", len(failing), failOn)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanVulnsCmd)
+
+	scanVulnsCmd.Flags().String("input", "", "SBOM to scan")
+	scanVulnsCmd.Flags().String("db", "vulndb.json", "vulnerability database built with vulndb import")
+	scanVulnsCmd.Flags().String("output-format", "text", "output format, text, json, cyclonedx or cyclonedx-json")
+	scanVulnsCmd.Flags().String("output-file", "", "file to write the output to, stdout if empty")
+	scanVulnsCmd.Flags().StringSlice("vex", []string{}, "OpenVEX or CycloneDX VEX documents to apply, later ones override earlier ones")
+	scanVulnsCmd.Flags().String("fail-on", "", "exit non-zero if there are vulnerabilities of this severity or higher: low, medium, high or critical")
+}
@@ -0,0 +1,94 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/inventory"
+	"github.com/defenseunicorns/spdx-cli/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves SBOM generation, diff and merge over a REST API",
+	Long: `Starts an HTTP server so other tools can request SBOMs without shelling out.
+POST a chart archive to /v1/sboms to start a generation job, poll /v1/jobs/{id}
+until it has succeeded and get the SBOM from /v1/jobs/{id}/sbom.  /v1/diff and
+/v1/merge take SBOMs as multipart forms.  With --inventory, generated SBOMs are
+added to the inventory and served from /v1/sboms/{chart}/{version}.  The OpenAPI
+spec is at /openapi.yaml, e.g.
+  sbom-cli serve --listen :8080 --max-scans 2 --inventory inventory.db
+  curl -si --data-binary @istio-1.11.2.tgz 'localhost:8080/v1/sboms?format=cyclonedx-json'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+		maxScans, _ := cmd.Flags().GetInt("max-scans")
+		maxUpload, _ := cmd.Flags().GetInt64("max-upload-mb")
+		jobTTL, _ := cmd.Flags().GetDuration("job-ttl")
+
+		opts := append([]generator.Option{generator.WithScanner(scannerFromFlags(cmd)), timestampFromFlags(cmd)}, creatorsFromFlags(cmd)...)
+		opts = append(opts, resolverFromFlags(cmd)...)
+		gen := generator.New(append(opts, namespaceFromFlags(cmd)...)...)
+		serverOpts := server.Options{Generate: server.GenerateWith(gen), MaxScans: maxScans, MaxUpload: maxUpload << 20, JobTTL: jobTTL}
+		if db, _ := cmd.Flags().GetString("inventory"); db != "" {
+			store, err := inventory.Open(db)
+			if err != nil {
+				panic(err)
+			}
+			defer store.Close()
+			serverOpts.Inventory = store
+		}
+		s := server.New(serverOpts)
+		defer s.Close()
+		srv := &http.Server{Addr: listen, Handler: s.Handler()}
+
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-stop
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			srv.Shutdown(ctx)
+		}()
+
+		fmt.Printf("Serving on %v\n", listen)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("listen", ":8080", "address to listen on")
+	serveCmd.Flags().Int("max-scans", 2, "how many generation jobs run at once")
+	serveCmd.Flags().Int64("max-upload-mb", 64, "largest request body accepted, in MiB")
+	serveCmd.Flags().Duration("job-ttl", time.Hour, "how long finished jobs and their SBOMs are kept")
+	serveCmd.Flags().String("inventory", "", "inventory database to keep generated SBOMs in and serve them from")
+	addScannerFlags(serveCmd)
+	addTimestampFlag(serveCmd)
+	addNamespaceFlags(serveCmd)
+	addCreatorFlags(serveCmd)
+}
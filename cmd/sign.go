@@ -0,0 +1,304 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/signing"
+	"github.com/spf13/cobra"
+)
+
+// signCmd represents the sign command
+var signCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Signs an SBOM with a local key",
+	Long: `Writes a detached, base64 encoded signature over an SBOM file, as cosign sign-blob
+does.  The key is an ECDSA or ed25519 private key, PEM encoded or made by cosign
+generate-key-pair; the password of a cosign key is read from COSIGN_PASSWORD.
+
+  sbom-cli sign --input chart.spdx --key cosign.key --output-file chart.spdx.sig`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		content, err := ioutil.ReadFile(input)
+		if err != nil {
+			panic(err)
+		}
+		key := privateKeyFromFlags(cmd)
+		sig, err := signing.Sign(key, content)
+		if err != nil {
+			panic(err)
+		}
+		writeOutput(cmd, append(signing.EncodeSignature(sig), '\n'))
+	},
+}
+
+// attestCmd represents the attest command
+var attestCmd = &cobra.Command{
+	Use:   "attest",
+	Short: "Wraps an SBOM in a signed in-toto attestation",
+	Long: `Makes an in-toto statement with the SBOM as its predicate, about the chart archive
+and the images of the chart, and signs it into a DSSE envelope.  The predicate
+type is https://spdx.dev/Document or https://cyclonedx.org/bom, following the SBOM.
+
+Images are subjects by digest: the digests the SBOM records, those given with
+--image-digest, or, with --resolve-images, the ones their registries report.
+
+  sbom-cli attest --input chart.spdx --chart chart-1.0.0.tgz --key cosign.key --resolve-images --output-file chart.intoto.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		content, err := ioutil.ReadFile(input)
+		if err != nil {
+			panic(err)
+		}
+		doc, err := sbom.Parse(content)
+		if err != nil {
+			panic(err)
+		}
+		subjects := make([]signing.Subject, 0)
+		if archive, _ := cmd.Flags().GetString("chart"); archive != "" {
+			s, err := signing.FileSubject(archive)
+			if err != nil {
+				panic(err)
+			}
+			subjects = append(subjects, s)
+		}
+
+		digests, _ := cmd.Flags().GetStringToString("image-digest")
+		resolve, _ := cmd.Flags().GetBool("resolve-images")
+		client := registryClientFromFlags(cmd)
+		images := make([]string, 0)
+		for _, c := range doc.Components {
+			if !c.IsImage() {
+				continue
+			}
+			image := c.Name
+			if c.Version != "" {
+				image = c.Name + ":" + c.Version
+			}
+			images = append(images, image)
+			if _, ok := digests[image]; !ok && c.Digest != "" {
+				digests[image] = c.Digest
+			}
+		}
+		sort.Strings(images)
+		for _, image := range images {
+			digest, ok := digests[image]
+			if !ok && resolve {
+				ref, err := client.ParseReference(image)
+				if err != nil {
+					panic(err)
+				}
+				d, err := client.Digest(context.Background(), ref)
+				if err != nil {
+					panic(fmt.Errorf("resolving %v: %w", image, err))
+				}
+				digest, ok = d.String(), true
+			}
+			if !ok {
+				panic(fmt.Errorf("no digest for image %v, use --image-digest or --resolve-images", image))
+			}
+			s, err := signing.ImageSubject(image, digest)
+			if err != nil {
+				panic(err)
+			}
+			subjects = append(subjects, s)
+		}
+
+		statement, err := signing.NewStatement(content, subjects)
+		if err != nil {
+			panic(err)
+		}
+		envelope, err := signing.Attest(privateKeyFromFlags(cmd), statement)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(envelope, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		writeOutput(cmd, append(b, '\n'))
+	},
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies the signature or attestation of an SBOM",
+	Long: `Checks a detached signature made by sign, or an attestation made by attest, with
+the public key only, so it works offline.  For an attestation, --input checks
+that the attested SBOM is the file and --chart that the chart archive is one of
+the subjects.
+
+  sbom-cli verify --input chart.spdx --key cosign.pub --signature chart.spdx.sig
+  sbom-cli verify --key cosign.pub --attestation chart.intoto.json --chart chart-1.0.0.tgz`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyFile, _ := cmd.Flags().GetString("key")
+		pub, err := signing.LoadPublicKey(keyFile)
+		if err != nil {
+			panic(err)
+		}
+		input, _ := cmd.Flags().GetString("input")
+		sigFile, _ := cmd.Flags().GetString("signature")
+		attestation, _ := cmd.Flags().GetString("attestation")
+		switch {
+		case sigFile != "":
+			content, err := ioutil.ReadFile(input)
+			if err != nil {
+				panic(err)
+			}
+			b, err := ioutil.ReadFile(sigFile)
+			if err != nil {
+				panic(err)
+			}
+			if err := signing.Verify(pub, content, signing.DecodeSignature(b)); err != nil {
+				verifyFailed(err)
+			}
+		case attestation != "":
+			b, err := ioutil.ReadFile(attestation)
+			if err != nil {
+				panic(err)
+			}
+			envelope := &signing.Envelope{}
+			if err := json.Unmarshal(b, envelope); err != nil {
+				panic(fmt.Errorf("reading %v: %w", attestation, err))
+			}
+			statement, err := signing.VerifyAttestation(pub, envelope)
+			if err != nil {
+				verifyFailed(err)
+			}
+			if input != "" {
+				content, err := ioutil.ReadFile(input)
+				if err != nil {
+					panic(err)
+				}
+				attested, err := statement.SBOM()
+				if err != nil {
+					panic(err)
+				}
+				if !sameJSON(content, attested) {
+					verifyFailed(fmt.Errorf("the attested SBOM is not %v", input))
+				}
+			}
+			if archive, _ := cmd.Flags().GetString("chart"); archive != "" {
+				s, err := signing.FileSubject(archive)
+				if err != nil {
+					panic(err)
+				}
+				if !statement.HasSubject(s) {
+					verifyFailed(fmt.Errorf("%v is not a subject of the attestation", archive))
+				}
+			}
+			fmt.Printf("Predicate type: %v\n", statement.PredicateType)
+			for _, s := range statement.Subject {
+				fmt.Printf("Subject: %v %v\n", s.Name, digestString(s.Digest))
+			}
+		default:
+			panic(fmt.Errorf("one of --signature or --attestation is required"))
+		}
+		fmt.Println("Verified OK")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+	rootCmd.AddCommand(attestCmd)
+	rootCmd.AddCommand(verifyCmd)
+
+	signCmd.Flags().String("input", "", "SBOM to sign")
+	signCmd.Flags().String("key", "", "ECDSA or ed25519 private key, PEM or cosign")
+	signCmd.Flags().String("output-file", "", "file to write the signature to, stdout if empty")
+
+	attestCmd.Flags().String("input", "", "SBOM to attest")
+	attestCmd.Flags().String("key", "", "ECDSA or ed25519 private key, PEM or cosign")
+	attestCmd.Flags().String("chart", "", "chart archive the SBOM is about, a subject of the attestation")
+	attestCmd.Flags().StringToString("image-digest", map[string]string{}, "digest of an image, as image=sha256:<hex>")
+	attestCmd.Flags().Bool("resolve-images", false, "ask the registries for the digests of images the SBOM has none for")
+	attestCmd.Flags().Bool("insecure-registry", false, "talk to registries over plain http")
+	attestCmd.Flags().String("output-file", "", "file to write the DSSE envelope to, stdout if empty")
+
+	verifyCmd.Flags().String("input", "", "the signed SBOM")
+	verifyCmd.Flags().String("key", "", "ECDSA or ed25519 public key, PEM")
+	verifyCmd.Flags().String("signature", "", "detached signature made by sign")
+	verifyCmd.Flags().String("attestation", "", "DSSE envelope made by attest")
+	verifyCmd.Flags().String("chart", "", "chart archive that must be a subject of the attestation")
+}
+
+// privateKeyFromFlags loads --key, with the password of a cosign key from
+// COSIGN_PASSWORD
+func privateKeyFromFlags(cmd *cobra.Command) crypto.Signer {
+	keyFile, _ := cmd.Flags().GetString("key")
+	if keyFile == "" {
+		panic(fmt.Errorf("--key is required"))
+	}
+	key, err := signing.LoadPrivateKey(keyFile, []byte(os.Getenv("COSIGN_PASSWORD")))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// writeOutput writes to --output-file, or stdout when it is empty
+func writeOutput(cmd *cobra.Command, b []byte) {
+	file, _ := cmd.Flags().GetString("output-file")
+	if file == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err := ioutil.WriteFile(file, b, 0644); err != nil {
+		panic(err)
+	}
+}
+
+func verifyFailed(err error) {
+	fmt.Fprintf(os.Stderr, "Verification failed: %v\n", err)
+	os.Exit(1)
+}
+
+// sameJSON reports whether two documents are the same, comparing JSON ones
+// by value since embedding them in the statement re-encodes them
+func sameJSON(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func digestString(digest map[string]string) string {
+	algs := make([]string, 0, len(digest))
+	for alg := range digest {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+	parts := make([]string, 0, len(algs))
+	for _, alg := range algs {
+		parts = append(parts, alg+":"+digest[alg])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,74 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Checks SBOMs against the SPDX and CycloneDX schemas and rules",
+	Long: `Validates each SBOM against the schema for its spec version and against the
+semantic rules of the spec: dangling references, duplicate IDs, missing mandatory
+fields, license expression, PURL and CPE syntax.  Exits non-zero if any SBOM has errors.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFiles, _ := cmd.Flags().GetStringSlice("input-files")
+		format, _ := cmd.Flags().GetString("output-format")
+		failed := false
+		for _, i := range inputFiles {
+			fmt.Printf("Input File: %v\n", i)
+			b, err := ioutil.ReadFile(i)
+			if err != nil {
+				panic(err)
+			}
+			report, err := sbom.Validate(b)
+			if err != nil {
+				panic(err)
+			}
+			if err := report.Write(os.Stdout, format); err != nil {
+				panic(err)
+			}
+			failed = failed || !report.Valid()
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// checkBeforeWrite prints the report and stops before anything is written if it has errors
+func checkBeforeWrite(report *sbom.ValidationReport) {
+	if err := report.Write(os.Stdout, "text"); err != nil {
+		panic(err)
+	}
+	if !report.Valid() {
+		fmt.Fprintln(os.Stderr, "BOM failed validation, not writing it")
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringSlice("input-files", []string{}, "SBOMs to validate")
+	validateCmd.Flags().String("output-format", "text", "output format, text or json")
+}
@@ -0,0 +1,180 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/vex"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// vexCmd represents the vex command
+var vexCmd = &cobra.Command{
+	Use:   "vex",
+	Short: "Works with VEX (Vulnerability Exploitability eXchange) documents",
+	Long:  `Groups the VEX commands, e.g. vex create.  Use scan-vulns --vex to apply VEX documents to findings.`,
+}
+
+// vexCreateCmd represents the vex create command
+var vexCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Generates VEX statements for the images of a chart",
+	Long: `Generates a VEX statement for each vulnerability and image of a chart, with the
+given status, justification and impact.  Products are the image BOMRefs that create
+produces, so the statements stay attached to the chart SBOM.  Images come from the
+chart (--path) or from an SBOM written by create (--bom), and can be narrowed down
+with --image.  Use --subcomponent to say which packages in the images the statement
+is about.
+
+  sbom-cli vex create --path chart/ --vulnerability CVE-2021-3711 --status not_affected \
+    --justification vulnerable_code_not_in_execute_path --impact "SM2 decryption is not used" --output-file istio.vex.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("path")
+		bomFile, _ := cmd.Flags().GetString("bom")
+		vulns, _ := cmd.Flags().GetStringSlice("vulnerability")
+		only, _ := cmd.Flags().GetStringSlice("image")
+
+		products := make(map[string]string)
+		switch {
+		case bomFile != "":
+			doc, err := sbom.Load(bomFile)
+			if err != nil {
+				panic(err)
+			}
+			for _, c := range doc.Components {
+				if c.IsImage() {
+					products[c.Name] = c.Ref
+				}
+			}
+		case path != "":
+			chart, err := helm.Read(path)
+			if err != nil {
+				panic(err)
+			}
+			for _, image := range helm.Images(chart) {
+				ref, err := imageref.Parse(image)
+				if err != nil {
+					panic(err)
+				}
+				products[image] = ref.ID() //matches the ElementID create uses
+			}
+		default:
+			panic(fmt.Errorf("one of --path or --bom is required"))
+		}
+		refs := make([]string, 0)
+		for image, ref := range products {
+			if len(only) == 0 || containsString(only, image) || containsString(only, ref) {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) == 0 {
+			panic(fmt.Errorf("Did not find any matching images"))
+		}
+		sort.Strings(refs)
+
+		status, _ := cmd.Flags().GetString("status")
+		justification, _ := cmd.Flags().GetString("justification")
+		impact, _ := cmd.Flags().GetString("impact")
+		action, _ := cmd.Flags().GetString("action")
+		subcomponents, _ := cmd.Flags().GetStringSlice("subcomponent")
+		author, _ := cmd.Flags().GetString("author")
+		now := time.Now().UTC().Format(time.RFC3339)
+		doc := &vex.Document{
+			ID:         fmt.Sprintf("urn:uuid:%v", uuid.New()),
+			Author:     author,
+			Timestamp:  now,
+			Statements: make([]vex.Statement, 0),
+		}
+		for _, v := range vulns {
+			s := vex.Statement{
+				Vulnerability:   v,
+				Products:        refs,
+				Subcomponents:   subcomponents,
+				Status:          status,
+				Justification:   justification,
+				Impact:          impact,
+				ActionStatement: action,
+				Timestamp:       now,
+			}
+			if err := s.Validate(); err != nil {
+				panic(err)
+			}
+			doc.Statements = append(doc.Statements, s)
+		}
+		if len(doc.Statements) == 0 {
+			panic(fmt.Errorf("at least one --vulnerability is required"))
+		}
+
+		out := os.Stdout
+		if file, _ := cmd.Flags().GetString("output-file"); file != "" {
+			var err error
+			out, err = os.Create(file)
+			if err != nil {
+				panic(err)
+			}
+			defer out.Close()
+		}
+		var err error
+		switch format, _ := cmd.Flags().GetString("output-format"); format {
+		case "openvex":
+			err = doc.WriteOpenVEX(out)
+		case "cyclonedx":
+			err = doc.WriteCycloneDX(out, cyclonedx.BOMFileFormatXML)
+		case "cyclonedx-json":
+			err = doc.WriteCycloneDX(out, cyclonedx.BOMFileFormatJSON)
+		default:
+			err = fmt.Errorf("unknown output format %v", format)
+		}
+		if err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vexCmd)
+	vexCmd.AddCommand(vexCreateCmd)
+
+	vexCreateCmd.Flags().String("path", "", "for the chart")
+	vexCreateCmd.Flags().String("bom", "", "SBOM written by create to take the image BOMRefs from, instead of --path")
+	vexCreateCmd.Flags().StringSlice("image", []string{}, "only make statements for these images (name or BOMRef), all images if empty")
+	vexCreateCmd.Flags().StringSlice("vulnerability", []string{}, "vulnerability IDs to make statements for")
+	vexCreateCmd.Flags().StringSlice("subcomponent", []string{}, "packages in the images the statements are about, by BOMRef or PURL")
+	vexCreateCmd.Flags().String("status", vex.StatusNotAffected, "not_affected, affected, fixed or under_investigation")
+	vexCreateCmd.Flags().String("justification", "", "why the images are not affected: component_not_present, vulnerable_code_not_present, vulnerable_code_not_in_execute_path, vulnerable_code_cannot_be_controlled_by_adversary or inline_mitigations_already_exist")
+	vexCreateCmd.Flags().String("impact", "", "impact statement explaining the status")
+	vexCreateCmd.Flags().String("action", "", "action statement for affected vulnerabilities")
+	vexCreateCmd.Flags().String("author", "Defense Unicorns", "author of the statements")
+	vexCreateCmd.Flags().String("output-format", "openvex", "output format, openvex, cyclonedx or cyclonedx-json")
+	vexCreateCmd.Flags().String("output-file", "", "file to write the VEX document to, stdout if empty")
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
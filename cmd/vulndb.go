@@ -0,0 +1,84 @@
+// Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/vulndb"
+	"github.com/spf13/cobra"
+)
+
+// vulndbCmd represents the vulndb command
+var vulndbCmd = &cobra.Command{
+	Use:   "vulndb",
+	Short: "Manages the local vulnerability database used by scan-vulns",
+	Long:  `Groups the commands that build the offline vulnerability database, e.g. vulndb import`,
+}
+
+// vulndbImportCmd represents the vulndb import command
+var vulndbImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Builds a vulnerability database from OSV and NVD dumps",
+	Long: `Imports OSV advisories (a JSON file, a directory of them or a zip as downloaded
+from the OSV bucket) and NVD 1.1 JSON feeds (optionally gzipped) into a single
+database file.  Run this on the connected side and carry the file across to use with
+scan-vulns, e.g.
+  sbom-cli vulndb import --osv Debian.zip --osv Alpine.zip --nvd nvdcve-1.1-2021.json.gz --output vulndb.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		osvPaths, _ := cmd.Flags().GetStringSlice("osv")
+		nvdPaths, _ := cmd.Flags().GetStringSlice("nvd")
+		output, _ := cmd.Flags().GetString("output")
+
+		db := vulndb.New()
+		if existing, _ := cmd.Flags().GetString("db"); existing != "" {
+			var err error
+			db, err = vulndb.Load(existing)
+			if err != nil {
+				panic(err)
+			}
+		}
+		for _, path := range osvPaths {
+			fmt.Printf("Importing OSV advisories from %v\n", path)
+			vulns, err := vulndb.ImportOSV(path)
+			if err != nil {
+				panic(err)
+			}
+			db.Add(path, vulns)
+		}
+		for _, path := range nvdPaths {
+			fmt.Printf("Importing NVD feed %v\n", path)
+			vulns, err := vulndb.ImportNVD(path)
+			if err != nil {
+				panic(err)
+			}
+			db.Add(path, vulns)
+		}
+		if err := db.Save(output); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Wrote %v vulnerabilities to %v\n", len(db.Vulnerabilities), output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vulndbCmd)
+	vulndbCmd.AddCommand(vulndbImportCmd)
+
+	vulndbImportCmd.Flags().StringSlice("osv", []string{}, "OSV JSON file, directory or zip to import")
+	vulndbImportCmd.Flags().StringSlice("nvd", []string{}, "NVD 1.1 JSON feed to import, .json or .json.gz")
+	vulndbImportCmd.Flags().String("db", "", "existing database to add to")
+	vulndbImportCmd.Flags().String("output", "vulndb.json", "file to write the database to")
+}
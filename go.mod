@@ -3,12 +3,22 @@ module github.com/defenseunicorns/spdx-cli
 go 1.16
 
 require (
-	github.com/CycloneDX/cyclonedx-go v0.4.0
+	github.com/CycloneDX/cyclonedx-go v0.5.2
+	github.com/Masterminds/semver/v3 v3.1.1
+	github.com/anchore/packageurl-go v0.0.0-20210922164639-b3fa992ebd29
 	github.com/anchore/stereoscope v0.0.0-20210817160504-0f4abc2a5a5a
 	github.com/anchore/syft v0.24.1
+	github.com/google/cel-go v0.9.0
+	github.com/google/go-containerregistry v0.6.0
+	github.com/google/uuid v1.2.0
 	github.com/mitchellh/go-homedir v1.1.0
-	github.com/spdx/tools-golang v0.2.0
+	github.com/spdx/tools-golang v0.3.0
 	github.com/spf13/cobra v1.2.1
 	github.com/spf13/viper v1.9.0
+	github.com/xeipuuv/gojsonschema v1.2.0
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
+	google.golang.org/genproto v0.0.0-20210831024726-fe130286e0e2
+	gopkg.in/yaml.v2 v2.4.0
 	helm.sh/helm/v3 v3.7.0
 )
@@ -0,0 +1,263 @@
+package generator
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// Properties of the Chart.yaml fields neither SPDX nor CycloneDX have a field
+// for, in CycloneDX properties of the chart component and SPDX annotations on
+// the chart package. There is a keyword property for each keyword.
+const (
+	ChartAppVersionProperty  = "helm:appVersion"
+	ChartKubeVersionProperty = "helm:kubeVersion"
+	ChartTypeProperty        = "helm:type"
+	ChartDeprecatedProperty  = "helm:deprecated"
+	ChartKeywordProperty     = "helm:keyword"
+)
+
+// External reference types of the chart package's sources and icon, in the
+// OTHER category
+const (
+	chartSourceRefType = "vcs"
+	chartIconRefType   = "icon"
+)
+
+// chartPackageID is the SPDX identifier of the package of a chart
+func chartPackageID(chart *chart.Chart) spdx.ElementID {
+	return sbom.ElementID("chart-" + chart.Metadata.Name)
+}
+
+// chartPackage is the package the chart's document describes, from
+// Chart.yaml, with who supplies and made it
+func (g *Generator) chartPackage(chart *chart.Chart) *spdx.Package2_2 {
+	md := chart.Metadata
+	p := &spdx.Package2_2{
+		PackageName:             md.Name,
+		PackageSPDXIdentifier:   chartPackageID(chart),
+		PackageVersion:          md.Version,
+		PackageDownloadLocation: "NOASSERTION",
+		PackageLicenseConcluded: "NOASSERTION",
+		PackageLicenseDeclared:  "NOASSERTION",
+		PackageCopyrightText:    "NOASSERTION",
+		PackageHomePage:         md.Home,
+		PackageDescription:      md.Description,
+	}
+	for _, source := range md.Sources {
+		p.PackageExternalReferences = append(p.PackageExternalReferences, &spdx.PackageExternalReference2_2{
+			Category: "OTHER",
+			RefType:  chartSourceRefType,
+			Locator:  source,
+		})
+	}
+	if md.Icon != "" {
+		p.PackageExternalReferences = append(p.PackageExternalReferences, &spdx.PackageExternalReference2_2{
+			Category: "OTHER",
+			RefType:  chartIconRefType,
+			Locator:  md.Icon,
+		})
+	}
+	g.chartSupplier(chart).SetSupplier(p)
+	g.chartOriginator(chart).SetOriginator(p)
+	if g.chartFiles {
+		p.Files = chartFiles(chart)
+		p.FilesAnalyzed = true
+		p.IsFilesAnalyzedTagPresent = true
+		p.PackageVerificationCode = sbom.VerificationCode(p.Files)
+		p.PackageLicenseInfoFromFiles = []string{"NOASSERTION"}
+	}
+	return p
+}
+
+// cpePackage is the package of a CPE 2.3 name the chart lists in its
+// helm.sh/cpe annotation, or nil if it is not one. The package is named after
+// the CPE's product and has its version, unless that is a wildcard.
+func cpePackage(cpe string) *spdx.Package2_2 {
+	parts := strings.Split(cpe, ":")
+	if len(parts) < 6 || parts[0] != "cpe" || parts[1] != "2.3" {
+		return nil
+	}
+	p := &spdx.Package2_2{
+		PackageName:             parts[4],
+		PackageSPDXIdentifier:   sbom.ElementID("cpe-" + strings.TrimRight(strings.Join(parts[2:], "-"), "*-")),
+		PackageDownloadLocation: "NOASSERTION",
+		PackageLicenseConcluded: "NOASSERTION",
+		PackageLicenseDeclared:  "NOASSERTION",
+		PackageCopyrightText:    "NOASSERTION",
+		PackageExternalReferences: []*spdx.PackageExternalReference2_2{{
+			Category: "SECURITY",
+			RefType:  string(syft.Cpe23ExternalRefType),
+			Locator:  cpe,
+		}},
+	}
+	if parts[5] != "*" && parts[5] != "-" {
+		p.PackageVersion = parts[5]
+	}
+	return p
+}
+
+// chartFiles are the SPDX files of every file in a chart, with their SHA1
+// and SHA256
+func chartFiles(chart *chart.Chart) map[spdx.ElementID]*spdx.File2_2 {
+	files := make(map[spdx.ElementID]*spdx.File2_2)
+	for _, f := range helm.Files(chart) {
+		id := fileID(f.Name)
+		files[id] = &spdx.File2_2{
+			FileName:           "./" + f.Name,
+			FileSPDXIdentifier: id,
+			FileChecksums: map[spdx.ChecksumAlgorithm]spdx.Checksum{
+				spdx.SHA1:   {Algorithm: spdx.SHA1, Value: fmt.Sprintf("%x", sha1.Sum(f.Data))},
+				spdx.SHA256: {Algorithm: spdx.SHA256, Value: fmt.Sprintf("%x", sha256.Sum256(f.Data))},
+			},
+			LicenseConcluded:  "NOASSERTION",
+			LicenseInfoInFile: []string{"NOASSERTION"},
+			FileCopyrightText: "NOASSERTION",
+		}
+	}
+	return files
+}
+
+// fileID is the SPDX identifier of a chart file. Sanitizing the path makes
+// e.g. templates/a_b.yaml and templates/a-b.yaml the same, so it ends in a
+// short hash of the path as well.
+func fileID(name string) spdx.ElementID {
+	sum := sha256.Sum256([]byte(name))
+	return sbom.ElementID(fmt.Sprintf("file-%s-%x", name, sum[:4]))
+}
+
+// fileComponents are the CycloneDX file components of the files of a
+// package, with the package's version
+func fileComponents(p *spdx.Package2_2) []cyclonedx.Component {
+	components := make([]cyclonedx.Component, 0, len(p.Files))
+	for _, f := range p.Files {
+		components = append(components, cyclonedx.Component{
+			Type:    cyclonedx.ComponentTypeFile,
+			Name:    strings.TrimPrefix(f.FileName, "./"),
+			Version: p.PackageVersion,
+			BOMRef:  string(f.FileSPDXIdentifier),
+			Hashes: &[]cyclonedx.Hash{
+				{Algorithm: cyclonedx.HashAlgoSHA1, Value: f.FileChecksums[spdx.SHA1].Value},
+				{Algorithm: cyclonedx.HashAlgoSHA256, Value: f.FileChecksums[spdx.SHA256].Value},
+			},
+		})
+	}
+	return components
+}
+
+// chartComponent is the CycloneDX metadata component of a chart, from
+// Chart.yaml, with who supplies it
+func (g *Generator) chartComponent(chart *chart.Chart) *cyclonedx.Component {
+	md := chart.Metadata
+	c := &cyclonedx.Component{
+		Type:        cyclonedx.ComponentTypeApplication,
+		Name:        md.Name,
+		Version:     md.Version,
+		Description: md.Description,
+		Supplier:    g.chartSupplier(chart).CycloneDX(),
+		BOMRef:      md.Name,
+	}
+	if md.Type == "library" {
+		c.Type = cyclonedx.ComponentTypeLibrary
+	}
+	authors := make([]string, 0, len(md.Maintainers))
+	for _, m := range md.Maintainers {
+		if m != nil && m.Name != "" {
+			authors = append(authors, sbom.Entity{Name: m.Name, Email: m.Email}.Value())
+		}
+	}
+	c.Author = strings.Join(authors, ", ")
+	refs := make([]cyclonedx.ExternalReference, 0)
+	if md.Home != "" {
+		refs = append(refs, cyclonedx.ExternalReference{Type: cyclonedx.ERTypeWebsite, URL: md.Home})
+	}
+	for _, source := range md.Sources {
+		refs = append(refs, cyclonedx.ExternalReference{Type: cyclonedx.ERTypeVCS, URL: source})
+	}
+	if md.Icon != "" {
+		refs = append(refs, cyclonedx.ExternalReference{Type: cyclonedx.ERTypeOther, URL: md.Icon, Comment: chartIconRefType})
+	}
+	if len(refs) > 0 {
+		c.ExternalReferences = &refs
+	}
+	if props := append(chartProperties(chart), provenanceProperties(g.provenance)...); len(props) > 0 {
+		c.Properties = &props
+	}
+	return c
+}
+
+// chartProperties are the properties of the Chart.yaml fields without an
+// SPDX or CycloneDX field
+func chartProperties(chart *chart.Chart) []cyclonedx.Property {
+	md := chart.Metadata
+	props := make([]cyclonedx.Property, 0)
+	if md.AppVersion != "" {
+		props = append(props, cyclonedx.Property{Name: ChartAppVersionProperty, Value: md.AppVersion})
+	}
+	if md.KubeVersion != "" {
+		props = append(props, cyclonedx.Property{Name: ChartKubeVersionProperty, Value: md.KubeVersion})
+	}
+	if md.Type != "" {
+		props = append(props, cyclonedx.Property{Name: ChartTypeProperty, Value: md.Type})
+	}
+	if md.Deprecated {
+		props = append(props, cyclonedx.Property{Name: ChartDeprecatedProperty, Value: strconv.FormatBool(md.Deprecated)})
+	}
+	for _, keyword := range md.Keywords {
+		props = append(props, cyclonedx.Property{Name: ChartKeywordProperty, Value: keyword})
+	}
+	return props
+}
+
+// annotate records properties of an element as SPDX annotations made by
+// sbom-cli
+func annotate(doc *spdx.Document2_2, id spdx.ElementID, props []cyclonedx.Property) {
+	for _, p := range props {
+		doc.Annotations = append(doc.Annotations, &spdx.Annotation2_2{
+			Annotator:                ToolName,
+			AnnotatorType:            "Tool",
+			AnnotationDate:           doc.CreationInfo.Created,
+			AnnotationType:           "OTHER",
+			AnnotationSPDXIdentifier: spdx.DocElementID{ElementRefID: id},
+			AnnotationComment:        p.Name + "=" + p.Value,
+		})
+	}
+}
+
+// chartSupplier is the supplier set with WithSupplier, else the chart's
+// first maintainer
+func (g *Generator) chartSupplier(chart *chart.Chart) sbom.Entity {
+	if !g.supplier.IsZero() {
+		return g.supplier
+	}
+	return maintainer(chart)
+}
+
+// chartOriginator is the originator set with WithOriginator, else the
+// chart's first maintainer
+func (g *Generator) chartOriginator(chart *chart.Chart) sbom.Entity {
+	if !g.originator.IsZero() {
+		return g.originator
+	}
+	return maintainer(chart)
+}
+
+// maintainer is the first maintainer Chart.yaml lists, as a person
+func maintainer(chart *chart.Chart) sbom.Entity {
+	for _, m := range chart.Metadata.Maintainers {
+		if m != nil && m.Name != "" {
+			return sbom.Entity{Kind: sbom.EntityPerson, Name: m.Name, Email: m.Email}
+		}
+	}
+	return sbom.Entity{}
+}
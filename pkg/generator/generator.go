@@ -0,0 +1,824 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tvsaver"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// Formats Generate can encode the chart SBOM in
+const (
+	FormatSPDX          = sbom.FormatSPDX
+	FormatCycloneDX     = sbom.FormatCycloneDX
+	FormatCycloneDXJSON = sbom.FormatCycloneDXJSON
+)
+
+// SourceProperty names where the packages of an image came from, in a
+// CycloneDX property of the image component and an SPDX annotation on the
+// image package
+const SourceProperty = "sbom-cli:source"
+
+// ProvenanceProperty is the status of the chart's provenance, verified or
+// unsigned, in a CycloneDX property of the chart component and an SPDX
+// annotation on the chart package. Properties named after it with :signer,
+// :fingerprint and :file:<name> say what was verified.
+const ProvenanceProperty = "sbom-cli:provenance"
+
+// CycloneDXSpecVersion is the CycloneDX version chart BOMs are written in;
+// component and metadata properties are new in 1.3
+const CycloneDXSpecVersion = "1.3"
+
+// ToolName is the tool named as the SBOM's creator, with the version set by
+// WithToolVersion
+const ToolName = "sbom-cli"
+
+// DefaultCreator is the organization named as the SBOM's creator when none
+// are set
+var DefaultCreator = sbom.Entity{Kind: sbom.EntityOrganization, Name: "Defense Unicorns"}
+
+// Generator makes the SBOM of a Helm chart from the images it lists
+type Generator struct {
+	scanner           scanner.Scanner
+	formats           []string
+	namespaceBase     string
+	namespaceStrategy string
+	imageDocuments    bool
+	creators          []sbom.Entity
+	toolVersion       string
+	supplier          sbom.Entity
+	originator        sbom.Entity
+	chartFiles        bool
+	resolver          *imageref.Resolver
+	platforms         []oci.Platform
+	progress          io.Writer
+	keyring           string
+	requireProvenance bool
+	provenance        *helm.Provenance
+	timestamp         time.Time
+}
+
+// Option configures a Generator
+type Option func(*Generator)
+
+// WithScanner sets how images are scanned, syft by default
+func WithScanner(s scanner.Scanner) Option {
+	return func(g *Generator) {
+		g.scanner = s
+	}
+}
+
+// WithFormats sets the formats the SBOM is encoded in, spdx by default
+func WithFormats(formats ...string) Option {
+	return func(g *Generator) {
+		g.formats = formats
+	}
+}
+
+// WithNamespaceBase sets the URI SPDX document namespaces are made from,
+// https://bigbang.dev by default. Chart documents get namespaces under
+// <base>/chart/ and image documents under <base>/image/.
+func WithNamespaceBase(base string) Option {
+	return func(g *Generator) {
+		g.namespaceBase = strings.TrimSuffix(base, "/")
+	}
+}
+
+// WithNamespaceStrategy sets how namespaces are made unique, NamespaceHash
+// by default
+func WithNamespaceStrategy(strategy string) Option {
+	return func(g *Generator) {
+		g.namespaceStrategy = strategy
+	}
+}
+
+// WithImageDocuments makes a separate SPDX document for each image, which
+// the chart document refers to instead of listing the image's packages
+func WithImageDocuments(separate bool) Option {
+	return func(g *Generator) {
+		g.imageDocuments = separate
+	}
+}
+
+// WithCreators sets the persons and organizations named as the SBOM's
+// creators, DefaultCreator by default
+func WithCreators(creators ...sbom.Entity) Option {
+	return func(g *Generator) {
+		if len(creators) > 0 {
+			g.creators = creators
+		}
+	}
+}
+
+// WithToolVersion sets the version of the tool named as the SBOM's creator
+func WithToolVersion(version string) Option {
+	return func(g *Generator) {
+		g.toolVersion = version
+	}
+}
+
+// WithSupplier sets who distributes the chart, its first maintainer by
+// default
+func WithSupplier(supplier sbom.Entity) Option {
+	return func(g *Generator) {
+		g.supplier = supplier
+	}
+}
+
+// WithOriginator sets who originally made the chart, its first maintainer by
+// default
+func WithOriginator(originator sbom.Entity) Option {
+	return func(g *Generator) {
+		g.originator = originator
+	}
+}
+
+// WithChartFiles catalogs every file of the chart, e.g. its templates,
+// values.yaml and bundled subcharts, with their checksums, as SPDX files of
+// the chart package and CycloneDX file components
+func WithChartFiles(catalog bool) Option {
+	return func(g *Generator) {
+		g.chartFiles = catalog
+	}
+}
+
+// WithProgress writes what the generator is doing to w; it is quiet by default
+func WithProgress(w io.Writer) Option {
+	return func(g *Generator) {
+		g.progress = w
+	}
+}
+
+// WithKeyring verifies chart archives against their .prov files with the
+// keys of the keyring, and records the result in the SBOM
+func WithKeyring(keyring string) Option {
+	return func(g *Generator) {
+		g.keyring = keyring
+	}
+}
+
+// WithRequireProvenance fails charts whose provenance was not verified
+func WithRequireProvenance(require bool) Option {
+	return func(g *Generator) {
+		g.requireProvenance = require
+	}
+}
+
+// WithProvenance records the provenance of a chart verified beforehand, for
+// GenerateChart
+func WithProvenance(p *helm.Provenance) Option {
+	return func(g *Generator) {
+		g.provenance = p
+	}
+}
+
+// WithTimestamp sets the creation time recorded in the SBOM, instead of the
+// time it is generated, so rebuilds produce the same bytes
+func WithTimestamp(t time.Time) Option {
+	return func(g *Generator) {
+		g.timestamp = t
+	}
+}
+
+// ParseTimestamp reads a timestamp given as RFC 3339 or, like
+// SOURCE_DATE_EPOCH, as seconds since the epoch
+func ParseTimestamp(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp %q is neither RFC 3339 nor seconds since the epoch", s)
+	}
+	return t.UTC(), nil
+}
+
+// New creates a Generator
+func New(opts ...Option) *Generator {
+	g := &Generator{
+		scanner:           scanner.SyftScanner{},
+		formats:           []string{FormatSPDX},
+		namespaceBase:     syft.DefaultNamespaceBase,
+		namespaceStrategy: NamespaceHash,
+		creators:          []sbom.Entity{DefaultCreator},
+		toolVersion:       "dev",
+		progress:          ioutil.Discard,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Result is the SBOM of a chart
+type Result struct {
+	Chart *chart.Chart
+	// SPDX is the chart's document, with a package for each image, the
+	// packages found in them and the chart's CPEs
+	SPDX *spdx.Document2_2
+	// CycloneDX is the chart's BOM, set when a CycloneDX format was asked for
+	CycloneDX *cyclonedx.BOM
+	// Images are what the scanner found in each image, keyed by the
+	// normalized reference, docker.io/library/nginx:1.21, with the platform
+	// for the images of an index, docker.io/library/nginx:1.21#linux/arm64
+	Images map[string]*scanner.Image
+	// Encoded is the SBOM in each of the formats asked for
+	Encoded map[string][]byte
+	// Provenance is what verifying the chart found, if it was verified
+	Provenance *helm.Provenance
+	// ImageSPDX are the documents of the images, with WithImageDocuments
+	ImageSPDX map[string]*spdx.Document2_2
+	// EncodedImageSPDX are those documents as tag-value, as the chart
+	// document's checksums of them were taken
+	EncodedImageSPDX map[string][]byte
+	// CRDs are the custom resource definitions the chart installs
+	CRDs []helm.CRD
+	// References are the images, normalized and with their digests when
+	// known, keyed like Images. Image indexes scanned for platforms are
+	// here too, though not in Images.
+	References map[string]imageref.Reference
+}
+
+// with returns a copy of the generator with more options, so one Generator
+// can serve calls that differ e.g. in format
+func (g *Generator) with(opts []Option) *Generator {
+	if len(opts) == 0 {
+		return g
+	}
+	c := *g
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// Generate makes the SBOM of the chart in a directory or archive. Options
+// given here apply to this call only.
+func (g *Generator) Generate(ctx context.Context, chartSource string, opts ...Option) (*Result, error) {
+	g = g.with(opts)
+	chart, err := helm.Load(chartSource)
+	if err != nil {
+		return nil, err
+	}
+	if g.keyring != "" || g.requireProvenance {
+		keyring := g.keyring
+		if keyring == "" {
+			keyring = helm.DefaultKeyring()
+		}
+		p, err := helm.VerifyProvenance(chartSource, keyring)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(g.progress, "The provenance of %v is %v\n", chartSource, p.Status)
+		return g.GenerateChart(ctx, chart, WithProvenance(p))
+	}
+	return g.GenerateChart(ctx, chart)
+}
+
+// GenerateChart makes the SBOM of a chart already loaded
+func (g *Generator) GenerateChart(ctx context.Context, chart *chart.Chart, opts ...Option) (*Result, error) {
+	g = g.with(opts)
+	for _, f := range g.formats {
+		switch f {
+		case FormatSPDX, FormatCycloneDX, FormatCycloneDXJSON:
+		default:
+			return nil, fmt.Errorf("unknown format %v, must be spdx, cyclonedx or cyclonedx-json", f)
+		}
+	}
+	if g.namespaceStrategy != NamespaceHash && g.namespaceStrategy != NamespaceUUID {
+		return nil, fmt.Errorf("unknown namespace strategy %v, must be one of %v", g.namespaceStrategy, strings.Join(NamespaceStrategies, ", "))
+	}
+	if len(g.platforms) > 0 && g.resolver == nil {
+		return nil, fmt.Errorf("scanning platforms needs a resolver to read image indexes with")
+	}
+	if g.requireProvenance && (g.provenance == nil || g.provenance.Status != helm.ProvenanceVerified) {
+		return nil, fmt.Errorf("the provenance of chart %v is not verified", chart.Metadata.Name)
+	}
+	imageList := helm.Images(chart)
+	if len(imageList) == 0 {
+		return nil, fmt.Errorf("Did not find any images in helm chart")
+	}
+	res := &Result{Chart: chart, Images: make(map[string]*scanner.Image), References: make(map[string]imageref.Reference), Encoded: make(map[string][]byte), Provenance: g.provenance}
+	chartBom := &spdx.Document2_2{
+		CreationInfo: &spdx.CreationInfo2_2{
+			// 2.1: SPDX Version; should be in the format "SPDX-2.2"
+			// Cardinality: mandatory, one
+			SPDXVersion: "SPDX-2.2",
+
+			// 2.2: Data License; should be "CC0-1.0"
+			// Cardinality: mandatory, one
+			DataLicense: "CC0-1.0",
+
+			// 2.3: SPDX Identifier; should be "DOCUMENT" to represent mandatory identifier of SPDXRef-DOCUMENT
+			// Cardinality: mandatory, one
+			SPDXIdentifier: spdx.ElementID("DOCUMENT"),
+
+			// 2.4: Document Name
+			// Cardinality: mandatory, one
+			DocumentName: chart.Metadata.Name,
+
+			// 2.5: Document Namespace
+			// Cardinality: mandatory, one
+			// Purpose: Provide an SPDX document specific namespace as a unique absolute Uniform Resource
+			// Identifier (URI) as specified in RFC-3986, with the exception of the ‘#’ delimiter. The SPDX
+			// Document URI cannot contain a URI "part" (e.g. the "#" character), since the ‘#’ is used in SPDX
+			// element URIs (packages, files, snippets, etc) to separate the document namespace from the
+			// element’s SPDX identifier. Additionally, a scheme (e.g. “https:”) is required.
+
+			// The URI must be unique for the SPDX document including the specific version of the SPDX document.
+			// If the SPDX document is updated, thereby creating a new version, a new URI for the updated
+			// document must be used. There can only be one URI for an SPDX document and only one SPDX document
+			// for a given URI.
+
+			// Note that the URI does not have to be accessible. It is only intended to provide a unique ID.
+			// In many cases, the URI will point to a web accessible document, but this should not be assumed
+			// to be the case.
+
+			// Set once the rest of the document is, from a hash of its content
+			DocumentNamespace: "",
+
+			// 2.6: External Document References
+			// Cardinality: optional, one or many
+			ExternalDocumentReferences: nil,
+
+			// 2.7: License List Version
+			// Cardinality: optional, one
+			// LicenseListVersion: spdxlicense.Version,
+
+			// 2.8: Creators: may have multiple keys for Person, Organization
+			//      and/or Tool
+			// Cardinality: mandatory, one or many
+			CreatorPersons:       g.creatorValues(sbom.EntityPerson),
+			CreatorOrganizations: g.creatorValues(sbom.EntityOrganization),
+			CreatorTools:         []string{g.tool()},
+
+			// 2.9: Created: data format YYYY-MM-DDThh:mm:ssZ
+			// Cardinality: mandatory, one
+			Created: g.created().Format(time.RFC3339),
+
+			// 2.10: Creator Comment
+			// Cardinality: optional, one
+			CreatorComment: "",
+
+			// 2.11: Document Comment
+			// Cardinality: optional, one
+			DocumentComment: "",
+		},
+		Packages: make(map[spdx.ElementID]*spdx.Package2_2),
+	}
+	chartPackage := g.chartPackage(chart)
+	chartBom.Packages[chartPackage.PackageSPDXIdentifier] = chartPackage
+	chartBom.Relationships = append(chartBom.Relationships, &spdx.Relationship2_2{
+		RefA:         spdx.DocElementID{ElementRefID: chartBom.CreationInfo.SPDXIdentifier},
+		RefB:         spdx.DocElementID{ElementRefID: chartPackage.PackageSPDXIdentifier},
+		Relationship: "DESCRIBES",
+	})
+	refs, err := g.imageReferences(ctx, imageList)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range refs {
+		targets := []imageref.Reference{index}
+		if len(g.platforms) > 0 {
+			platforms, err := g.resolver.Platforms(ctx, index, g.platforms)
+			if err != nil {
+				return nil, err
+			}
+			if len(platforms) > 0 {
+				// the index is the parent of the image of each platform
+				res.References[index.Key()] = index
+				chartBom.Packages[imageID(index)] = imagePackage(index)
+				relate(chartBom, chartPackage.PackageSPDXIdentifier, "CONTAINS", imageID(index))
+				targets = platforms
+			}
+		}
+		for _, ref := range targets {
+			image := ref.Key()
+			fmt.Fprintf(g.progress, "Found an image: %v\n", image)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			name := ref.Original
+			if ref.Platform != "" {
+				name = ref.Pinned()
+			}
+			scanned, err := g.scanner.Scan(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("scanning %v: %w", name, err)
+			}
+			if ref.Digest == "" {
+				ref.Digest = scanned.Digest
+			}
+			res.Images[image] = scanned
+			res.References[image] = ref
+			//add entry for the image
+			id := imageID(ref)
+			chartBom.Packages[id] = imagePackage(ref)
+			annotate(chartBom, id, []cyclonedx.Property{{Name: SourceProperty, Value: scanned.Source}})
+			if ref.Platform != "" {
+				relate(chartBom, imageID(index), "CONTAINS", id)
+				relate(chartBom, id, "VARIANT_OF", imageID(index))
+				annotate(chartBom, id, platformProperties(ref))
+			} else {
+				relate(chartBom, chartPackage.PackageSPDXIdentifier, "CONTAINS", id)
+			}
+			// Add all the packages from the image too
+			fmt.Fprintf(g.progress, "The image %v has %v packages inside of it, from %v\n", image, len(scanned.Packages), scanned.Source)
+			contained := make([]string, 0, len(scanned.Packages))
+			for _, c := range scanned.Packages {
+				p := componentPackage(c)
+				chartBom.Packages[p.PackageSPDXIdentifier] = p
+				contained = append(contained, string(p.PackageSPDXIdentifier))
+			}
+			for _, packageRef := range sortedRefs(contained) {
+				relate(chartBom, id, "CONTAINS", spdx.ElementID(packageRef))
+			}
+		}
+	}
+	// Add CPEs:
+
+	for _, cpe := range helm.CPEs(chart) {
+		p := cpePackage(cpe)
+		if p == nil {
+			fmt.Fprintf(g.progress, "Skipping %q, which is not a CPE 2.3 name\n", cpe)
+			continue
+		}
+		chartBom.Packages[p.PackageSPDXIdentifier] = p
+		relate(chartBom, chartPackage.PackageSPDXIdentifier, "CONTAINS", p.PackageSPDXIdentifier)
+	}
+	annotate(chartBom, chartPackage.PackageSPDXIdentifier, append(chartProperties(chart), provenanceProperties(g.provenance)...))
+	res.CRDs = g.addKubernetes(chartBom, chartPackage, chart)
+	doc := chartBom
+	if g.imageDocuments {
+		var err error
+		if doc, err = g.referenceImageDocuments(chartBom, res); err != nil {
+			return nil, err
+		}
+	}
+	sortDocument(doc)
+	if err := g.setNamespace(doc, namespaceChart, chart.Metadata.Name+"-"+chart.Metadata.Version); err != nil {
+		return nil, err
+	}
+	res.SPDX = doc
+
+	for _, f := range g.formats {
+		buf := &bytes.Buffer{}
+		switch f {
+		case FormatSPDX:
+			err = tvsaver.Save2_2(res.SPDX, buf)
+		default:
+			if res.CycloneDX == nil {
+				res.CycloneDX = g.cycloneDX(chart, chartBom, res)
+			}
+			fileFormat := cyclonedx.BOMFileFormatXML
+			if f == FormatCycloneDXJSON {
+				fileFormat = cyclonedx.BOMFileFormatJSON
+			}
+			err = cyclonedx.NewBOMEncoder(buf, fileFormat).Encode(res.CycloneDX)
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Encoded[f] = buf.Bytes()
+	}
+	return res, nil
+}
+
+// cycloneDX converts the chart's SPDX document to CycloneDX, with the chart as
+// the metadata component and the chart → image → package dependencies
+func (g *Generator) cycloneDX(chart *chart.Chart, chartBom *spdx.Document2_2, res *Result) *cyclonedx.BOM {
+	images := res.Images
+	cycloneBom := sbom.ToCycloneDX(chartBom)
+	cycloneBom.SpecVersion = CycloneDXSpecVersion
+	cycloneBom.XMLNS = "http://cyclonedx.org/schema/bom/" + CycloneDXSpecVersion
+	cycloneBom.Metadata.Component = g.chartComponent(chart)
+	cycloneBom.Metadata.Supplier = g.chartSupplier(chart).CycloneDX()
+	cycloneBom.Metadata.Manufacture = g.chartOriginator(chart).CycloneDX()
+	cycloneBom.Metadata.Tools = &[]cyclonedx.Tool{{Vendor: DefaultCreator.Name, Name: ToolName, Version: g.toolVersion}}
+	authors := make([]cyclonedx.OrganizationalContact, 0, len(g.creators))
+	for _, c := range g.creators {
+		authors = append(authors, cyclonedx.OrganizationalContact{Name: c.Name, Email: c.Email})
+	}
+	cycloneBom.Metadata.Authors = &authors
+	// the chart package is the metadata component, and its files are
+	// components of their own
+	components := make([]cyclonedx.Component, 0, len(*cycloneBom.Components))
+	for _, c := range *cycloneBom.Components {
+		if c.BOMRef != string(chartPackageID(chart)) {
+			components = append(components, c)
+		}
+	}
+	if p, ok := chartBom.Packages[chartPackageID(chart)]; ok {
+		components = append(components, fileComponents(p)...)
+	}
+	cycloneBom.Components = &components
+
+	// chartDependency.Dependencies = &tmpDep
+	// imageRefs := make([]cyclonedx.Dependency, len(imageList))
+
+	// For each image in the list, make an entry that the image is a dependency of the chart
+	chartDependency := cyclonedx.Dependency{
+		Ref: chart.Metadata.Name,
+	}
+	imagesInChart := make([]cyclonedx.Dependency, 0)
+	imageRefs := make([]string, 0, len(res.References))
+	for _, ref := range res.References {
+		// the images of an index's platforms are in the chart through it
+		if ref.Platform == "" {
+			imageRefs = append(imageRefs, ref.ID())
+		}
+	}
+	for _, ref := range sortedRefs(imageRefs) {
+		imagesInChart = append(imagesInChart, cyclonedx.Dependency{
+			Ref: ref, //matches the ElementID for spdx
+		})
+	}
+	if _, ok := chartBom.Packages[kubernetesID]; ok {
+		imagesInChart = append(imagesInChart, cyclonedx.Dependency{Ref: kubernetesID})
+	}
+	// the chart also depends on the custom resource definitions it installs
+	for _, crd := range res.CRDs {
+		imagesInChart = append(imagesInChart, cyclonedx.Dependency{Ref: string(crdID(crd))})
+	}
+	chartDependency.Dependencies = &imagesInChart
+
+	//Add the images as a dependency of the chart
+	cycloneBom.Dependencies = &[]cyclonedx.Dependency{chartDependency}
+
+	// For each image in the list, make a list of dependencies of the image,
+	// in order so the output is the same every time
+	scannedImages := make([]string, 0, len(images))
+	for image := range images {
+		scannedImages = append(scannedImages, image)
+	}
+	sort.Strings(scannedImages)
+	for _, image := range scannedImages {
+		imageDep := cyclonedx.Dependency{
+			Ref: res.References[image].ID(), //matches the ElementID for spdx
+		}
+		packageRefs := make([]cyclonedx.Dependency, 0)
+		refs := make([]string, 0, len(images[image].Packages))
+		for _, c := range images[image].Packages {
+			refs = append(refs, string(packageID(c)))
+		}
+		for _, ref := range sortedRefs(refs) {
+			packageRefs = append(packageRefs, cyclonedx.Dependency{Ref: ref})
+		}
+		imageDep.Dependencies = &packageRefs
+		tmp := append(*cycloneBom.Dependencies, imageDep)
+		cycloneBom.Dependencies = &tmp
+	}
+	sort.SliceStable(*cycloneBom.Components, func(i, j int) bool {
+		return (*cycloneBom.Components)[i].BOMRef < (*cycloneBom.Components)[j].BOMRef
+	})
+	cycloneBom.SerialNumber = uuid.NewSHA1(uuid.NameSpaceURL, []byte(res.SPDX.CreationInfo.DocumentNamespace)).URN()
+	imagesByID := make(map[string]string)
+	for image, ref := range res.References {
+		imagesByID[ref.ID()] = image
+	}
+	for i, c := range *cycloneBom.Components {
+		image, ok := imagesByID[c.BOMRef]
+		if !ok {
+			continue
+		}
+		ref := res.References[image]
+		if scanned, ok := images[image]; ok {
+			props := append([]cyclonedx.Property{{Name: SourceProperty, Value: scanned.Source}}, platformProperties(ref)...)
+			(*cycloneBom.Components)[i].Properties = &props
+		}
+		if hashes := imageHashes(ref); len(hashes) > 0 {
+			(*cycloneBom.Components)[i].Hashes = &hashes
+		}
+	}
+	setKubernetesComponents(*cycloneBom.Components, chart, res.CRDs)
+	nestPlatforms(cycloneBom, res)
+	assertVersions(*cycloneBom.Components)
+	return cycloneBom
+}
+
+// assertVersions gives the components without a version NOASSERTION, as
+// their SPDX packages have it, since CycloneDX requires a version before 1.4
+func assertVersions(components []cyclonedx.Component) {
+	for i, c := range components {
+		if c.Version == "" {
+			components[i].Version = "NOASSERTION"
+		}
+		if c.Components != nil {
+			assertVersions(*c.Components)
+		}
+	}
+}
+
+// referenceImageDocuments makes a document for each image, and a copy of the
+// chart document that refers to them through external document references
+// instead of listing their packages. The copy shares the creation info of
+// the chart document, so both end up with the same namespace.
+func (g *Generator) referenceImageDocuments(chartBom *spdx.Document2_2, res *Result) (*spdx.Document2_2, error) {
+	// a copy of the chart's document, which the CycloneDX BOM is still made
+	// from, that leaves the chart's document as it is
+	doc := *chartBom
+	creationInfo := *chartBom.CreationInfo
+	doc.CreationInfo = &creationInfo
+	doc.Annotations = append([]*spdx.Annotation2_2(nil), chartBom.Annotations...)
+	doc.Packages = make(map[spdx.ElementID]*spdx.Package2_2)
+	for id, p := range chartBom.Packages {
+		doc.Packages[id] = p
+	}
+	doc.Relationships = append([]*spdx.Relationship2_2(nil), chartBom.Relationships...)
+	doc.CreationInfo.ExternalDocumentReferences = make(map[string]spdx.ExternalDocumentRef2_2)
+	res.ImageSPDX = make(map[string]*spdx.Document2_2)
+	res.EncodedImageSPDX = make(map[string][]byte)
+
+	images := make([]string, 0, len(res.Images))
+	for image := range res.Images {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	for _, image := range images {
+		id := imageID(res.References[image])
+		imageDoc := &spdx.Document2_2{
+			CreationInfo: &spdx.CreationInfo2_2{
+				SPDXVersion:          "SPDX-2.2",
+				DataLicense:          "CC0-1.0",
+				SPDXIdentifier:       spdx.ElementID("DOCUMENT"),
+				DocumentName:         image,
+				CreatorPersons:       chartBom.CreationInfo.CreatorPersons,
+				CreatorOrganizations: chartBom.CreationInfo.CreatorOrganizations,
+				CreatorTools:         chartBom.CreationInfo.CreatorTools,
+				Created:              chartBom.CreationInfo.Created,
+			},
+			Packages: map[spdx.ElementID]*spdx.Package2_2{id: chartBom.Packages[id]},
+			Relationships: []*spdx.Relationship2_2{{
+				RefA:         spdx.DocElementID{ElementRefID: "DOCUMENT"},
+				RefB:         spdx.DocElementID{ElementRefID: id},
+				Relationship: "DESCRIBES",
+			}},
+		}
+		refs := make([]string, 0)
+		for _, c := range res.Images[image].Packages {
+			p := componentPackage(c)
+			imageDoc.Packages[p.PackageSPDXIdentifier] = p
+			refs = append(refs, string(p.PackageSPDXIdentifier))
+			delete(doc.Packages, p.PackageSPDXIdentifier)
+		}
+		for _, ref := range sortedRefs(refs) {
+			imageDoc.Relationships = append(imageDoc.Relationships, &spdx.Relationship2_2{
+				RefA:         spdx.DocElementID{ElementRefID: id},
+				RefB:         spdx.DocElementID{ElementRefID: spdx.ElementID(ref)},
+				Relationship: "CONTAINS",
+			})
+		}
+		sortDocument(imageDoc)
+		if err := g.setNamespace(imageDoc, namespaceImage, image); err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		if err := tvsaver.Save2_2(imageDoc, buf); err != nil {
+			return nil, err
+		}
+		res.ImageSPDX[image] = imageDoc
+		res.EncodedImageSPDX[image] = buf.Bytes()
+
+		refID := string(id)
+		doc.CreationInfo.ExternalDocumentReferences[refID] = spdx.ExternalDocumentRef2_2{
+			DocumentRefID: refID,
+			URI:           imageDoc.CreationInfo.DocumentNamespace,
+			Alg:           "SHA1",
+			Checksum:      fmt.Sprintf("%x", sha1.Sum(buf.Bytes())),
+		}
+		doc.Relationships = append(doc.Relationships, &spdx.Relationship2_2{
+			RefA:         spdx.DocElementID{ElementRefID: id},
+			RefB:         spdx.DocElementID{DocumentRefID: refID, ElementRefID: "DOCUMENT"},
+			Relationship: "DESCRIBED_BY",
+		})
+	}
+	// the images' documents have the relationships of the packages moved
+	// to them
+	rels := make([]*spdx.Relationship2_2, 0, len(doc.Relationships))
+	for _, r := range doc.Relationships {
+		if inDocument(&doc, r.RefA) && inDocument(&doc, r.RefB) {
+			rels = append(rels, r)
+		}
+	}
+	doc.Relationships = rels
+	return &doc, nil
+}
+
+// inDocument reports whether an element a relationship refers to is the
+// document, one of its packages or in another document
+func inDocument(doc *spdx.Document2_2, id spdx.DocElementID) bool {
+	if id.DocumentRefID != "" || id.ElementRefID == doc.CreationInfo.SPDXIdentifier {
+		return true
+	}
+	_, ok := doc.Packages[id.ElementRefID]
+	return ok
+}
+
+// relate records a relationship between two elements of a document
+func relate(doc *spdx.Document2_2, a spdx.ElementID, relationship string, b spdx.ElementID) {
+	doc.Relationships = append(doc.Relationships, &spdx.Relationship2_2{
+		RefA:         spdx.DocElementID{ElementRefID: a},
+		RefB:         spdx.DocElementID{ElementRefID: b},
+		Relationship: relationship,
+	})
+}
+
+// sortDocument puts the relationships and annotations of a document in
+// a stable order, whatever order the images were scanned in: what the
+// document describes first, then by their elements
+func sortDocument(doc *spdx.Document2_2) {
+	key := func(r *spdx.Relationship2_2) string {
+		describes := "1"
+		if r.Relationship == "DESCRIBES" {
+			describes = "0"
+		}
+		return describes + " " + r.RefA.DocumentRefID + ":" + string(r.RefA.ElementRefID) + " " + r.Relationship + " " + r.RefB.DocumentRefID + ":" + string(r.RefB.ElementRefID)
+	}
+	sort.SliceStable(doc.Relationships, func(i, j int) bool {
+		return key(doc.Relationships[i]) < key(doc.Relationships[j])
+	})
+	sort.SliceStable(doc.Annotations, func(i, j int) bool {
+		return doc.Annotations[i].AnnotationSPDXIdentifier.ElementRefID < doc.Annotations[j].AnnotationSPDXIdentifier.ElementRefID
+	})
+}
+
+// sortedRefs are the distinct refs, sorted
+func sortedRefs(refs []string) []string {
+	seen := make(map[string]bool)
+	sorted := make([]string, 0, len(refs))
+	for _, r := range refs {
+		if !seen[r] {
+			seen[r] = true
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// creatorValues are the creators of a kind, as SPDX writes them after the
+// prefix
+func (g *Generator) creatorValues(kind string) []string {
+	var values []string
+	for _, c := range g.creators {
+		if c.Kind == kind {
+			values = append(values, c.Value())
+		}
+	}
+	return values
+}
+
+// created is when the SBOM says it was made
+func (g *Generator) created() time.Time {
+	if g.timestamp.IsZero() {
+		return time.Now().UTC()
+	}
+	return g.timestamp.UTC()
+}
+
+// tool is the tool named as the SBOM's creator, with its version
+func (g *Generator) tool() string {
+	return ToolName + "-" + g.toolVersion
+}
+
+// provenanceProperties are the properties that record a chart's provenance
+func provenanceProperties(p *helm.Provenance) []cyclonedx.Property {
+	if p == nil {
+		return nil
+	}
+	props := []cyclonedx.Property{{Name: ProvenanceProperty, Value: p.Status}}
+	if p.Signer != "" {
+		props = append(props, cyclonedx.Property{Name: ProvenanceProperty + ":signer", Value: p.Signer})
+	}
+	if p.Fingerprint != "" {
+		props = append(props, cyclonedx.Property{Name: ProvenanceProperty + ":fingerprint", Value: p.Fingerprint})
+	}
+	files := make([]string, 0, len(p.Files))
+	for f := range p.Files {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		props = append(props, cyclonedx.Property{Name: ProvenanceProperty + ":file:" + f, Value: p.Files[f]})
+	}
+	return props
+}
@@ -0,0 +1,853 @@
+package generator_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/jsonsaver"
+	"github.com/spdx/tools-golang/spdx"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
+)
+
+// validate fails the test for every error validating an encoded SBOM finds
+func validate(t *testing.T, name string, b []byte) {
+	t.Helper()
+	r, err := sbom.Validate(b)
+	if err != nil {
+		t.Fatalf("%v: %v", name, err)
+	}
+	for _, f := range r.Findings {
+		if f.Severity == sbom.SeverityError {
+			t.Errorf("%v: %v %v: %v", name, f.Rule, f.Element, f.Message)
+		}
+	}
+}
+
+// spdxJSON encodes a document as SPDX JSON. The saver writes a null list for
+// a document without annotations of its own, which its loader can't read, so
+// that is left out
+func spdxJSON(t *testing.T, doc *spdx.Document2_2) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := jsonsaver.Save2_2(doc, buf); err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["annotations"] == nil {
+		delete(fields, "annotations")
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestGenerateValidates(t *testing.T) {
+	res := generatortest.Generate(t, generatortest.Chart())
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+	// and as SPDX JSON, which the generator doesn't write but validate reads
+	validate(t, "spdx-json", spdxJSON(t, res.SPDX))
+	bom := res.CycloneDX
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != generator.CycloneDXSpecVersion || bom.Version != 1 {
+		t.Errorf("the BOM is %v %v version %v, want CycloneDX %v version 1", bom.BOMFormat, bom.SpecVersion, bom.Version, generator.CycloneDXSpecVersion)
+	}
+}
+
+func TestGeneratePackages(t *testing.T) {
+	res := generatortest.Generate(t, generatortest.Chart())
+	var ids []string
+	for id := range res.SPDX.Packages {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	want := []string{"chart-demo", "deb-nginx-1.21.3", "deb-openssl-1.1.1k", "deb-redis-6.2.6", "image-docker.io-library-nginx-1.21", "image-docker.io-library-redis-6.2"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("packages %v, want %v", ids, want)
+	}
+	if p := res.SPDX.Packages["deb-openssl-1.1.1k"]; p.PackageVersion != "1.1.1k" || p.PackageLicenseDeclared != "OpenSSL" {
+		t.Errorf("openssl is version %q licensed %q, want 1.1.1k licensed OpenSSL", p.PackageVersion, p.PackageLicenseDeclared)
+	}
+
+	var relationships []string
+	for _, r := range res.SPDX.Relationships {
+		relationships = append(relationships, string(r.RefA.ElementRefID)+" "+r.Relationship+" "+string(r.RefB.ElementRefID))
+	}
+	wantRelationships := []string{
+		"DOCUMENT DESCRIBES chart-demo",
+		"chart-demo CONTAINS image-docker.io-library-nginx-1.21",
+		"chart-demo CONTAINS image-docker.io-library-redis-6.2",
+		"image-docker.io-library-nginx-1.21 CONTAINS deb-nginx-1.21.3",
+		"image-docker.io-library-nginx-1.21 CONTAINS deb-openssl-1.1.1k",
+		"image-docker.io-library-redis-6.2 CONTAINS deb-openssl-1.1.1k",
+		"image-docker.io-library-redis-6.2 CONTAINS deb-redis-6.2.6",
+	}
+	if !reflect.DeepEqual(relationships, wantRelationships) {
+		t.Errorf("relationships %q, want %q", relationships, wantRelationships)
+	}
+}
+
+func TestGeneratePackagesAtDifferentVersions(t *testing.T) {
+	s := generatortest.Scanner()
+	openssl := &s.Packages["redis:6.2"][0]
+	openssl.Version, openssl.PURL = "1.1.1n", "pkg:deb/debian/openssl@1.1.1n"
+	res := generatortest.Generate(t, generatortest.Chart(), generator.WithScanner(s))
+
+	for id, version := range map[spdx.ElementID]string{"deb-openssl-1.1.1k": "1.1.1k", "deb-openssl-1.1.1n": "1.1.1n"} {
+		if p, ok := res.SPDX.Packages[id]; !ok || p.PackageVersion != version {
+			t.Errorf("package %v is %+v, want openssl %v", id, p, version)
+		}
+	}
+	contains := make(map[string][]string)
+	for _, r := range res.SPDX.Relationships {
+		if r.Relationship == "CONTAINS" {
+			contains[string(r.RefA.ElementRefID)] = append(contains[string(r.RefA.ElementRefID)], string(r.RefB.ElementRefID))
+		}
+	}
+	want := map[string][]string{
+		"chart-demo":                         {"image-docker.io-library-nginx-1.21", "image-docker.io-library-redis-6.2"},
+		"image-docker.io-library-nginx-1.21": {"deb-nginx-1.21.3", "deb-openssl-1.1.1k"},
+		"image-docker.io-library-redis-6.2":  {"deb-openssl-1.1.1n", "deb-redis-6.2.6"},
+	}
+	if !reflect.DeepEqual(contains, want) {
+		t.Errorf("contains %v, want %v", contains, want)
+	}
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	first := generatortest.Generate(t, generatortest.Chart())
+	second := generatortest.Generate(t, generatortest.Chart())
+	for format, b := range first.Encoded {
+		if !bytes.Equal(b, second.Encoded[format]) {
+			t.Errorf("the %v of two runs at the same time differ:\n%s\n%s", format, b, second.Encoded[format])
+		}
+	}
+	if first.SPDX.CreationInfo.Created != "2021-10-01T00:00:00Z" {
+		t.Errorf("created %v, want the timestamp given", first.SPDX.CreationInfo.Created)
+	}
+}
+
+func TestGenerateNamespaceStrategies(t *testing.T) {
+	hash := regexp.MustCompile(`^https://bigbang.dev/chart/demo-chart-0.1.0-[0-9a-f]{64}$`)
+	uuid := regexp.MustCompile(`^https://bigbang.dev/chart/demo-chart-0.1.0-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	generate := func(strategy string, edit func(c *chart.Chart)) *generator.Result {
+		c := generatortest.Chart()
+		// a name the namespace can't hold as it is
+		c.Metadata.Name = "demo_chart"
+		edit(c)
+		return generatortest.Generate(t, c, generator.WithNamespaceStrategy(strategy))
+	}
+	same := func(c *chart.Chart) {}
+	namespace := func(res *generator.Result) string { return res.SPDX.CreationInfo.DocumentNamespace }
+
+	// the hash changes with the content, not with the run
+	first := generate(generator.NamespaceHash, same)
+	if !hash.MatchString(namespace(first)) {
+		t.Errorf("hash namespace %v, want the chart's name and version and a SHA-256", namespace(first))
+	}
+	if ns := namespace(generate(generator.NamespaceHash, same)); ns != namespace(first) {
+		t.Errorf("hash namespaces %v and %v of the same chart, want them the same", ns, namespace(first))
+	}
+	changed := generate(generator.NamespaceHash, func(c *chart.Chart) {
+		c.Metadata.Annotations["helm.sh/images"] = "- image: nginx:1.21\n"
+	})
+	if ns := namespace(changed); ns == namespace(first) || !hash.MatchString(ns) {
+		t.Errorf("hash namespace %v of a chart with other images, want another hash than %v", ns, namespace(first))
+	}
+	if changed.CycloneDX.SerialNumber == first.CycloneDX.SerialNumber {
+		t.Errorf("BOMs of charts with other images have the same serial number %v", first.CycloneDX.SerialNumber)
+	}
+
+	// a UUID changes with every run
+	first = generate(generator.NamespaceUUID, same)
+	second := generate(generator.NamespaceUUID, same)
+	for _, res := range []*generator.Result{first, second} {
+		if !uuid.MatchString(namespace(res)) {
+			t.Errorf("uuid namespace %v, want the chart's name and version and a random UUID", namespace(res))
+		}
+	}
+	if namespace(first) == namespace(second) || first.CycloneDX.SerialNumber == second.CycloneDX.SerialNumber {
+		t.Errorf("two runs got namespace %v and serial number %v, want them unique", namespace(first), first.CycloneDX.SerialNumber)
+	}
+
+	if _, err := generatortest.Generator(generator.WithNamespaceStrategy("sequential")).GenerateChart(context.Background(), generatortest.Chart()); err == nil {
+		t.Error("generated with an unknown namespace strategy")
+	}
+}
+
+func TestGenerateElementIDs(t *testing.T) {
+	c := generatortest.Chart()
+	c.Metadata.Name = "demo_chart"
+	c.Metadata.Annotations["helm.sh/cpe"] = "- cpe: cpe:2.3:a:f5:nginx:1.21.0:*:*:*:*:*:*:*\n"
+	res := generatortest.Generate(t, c)
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	valid := regexp.MustCompile(`^[A-Za-z0-9.\-]+$`)
+	for id := range res.SPDX.Packages {
+		if !valid.MatchString(string(id)) {
+			t.Errorf("%q is not a valid SPDX element ID", id)
+		}
+	}
+	if _, ok := res.SPDX.Packages["chart-demo-chart"]; !ok {
+		t.Errorf("no chart-demo-chart package")
+	}
+	p, ok := res.SPDX.Packages["cpe-a-f5-nginx-1.21.0"]
+	if !ok {
+		t.Fatalf("no package for the CPE")
+	}
+	if p.PackageName != "nginx" || p.PackageVersion != "1.21.0" || p.PackageDownloadLocation != "NOASSERTION" {
+		t.Errorf("the CPE package is %v %v from %v, want nginx 1.21.0 from NOASSERTION", p.PackageName, p.PackageVersion, p.PackageDownloadLocation)
+	}
+	if len(p.PackageExternalReferences) != 1 || p.PackageExternalReferences[0].Category != "SECURITY" {
+		t.Errorf("the CPE package has references %+v, want a SECURITY reference to the CPE", p.PackageExternalReferences)
+	}
+}
+
+// importScanner writes the SBOMs of the test images to a new directory and
+// imports them from there
+func importScanner(t *testing.T) *scanner.ImportScanner {
+	t.Helper()
+	dir := t.TempDir()
+	for image, packages := range generatortest.Scanner().Packages {
+		components := make([]cyclonedx.Component, 0, len(packages))
+		for _, p := range packages {
+			components = append(components, cyclonedx.Component{Type: cyclonedx.ComponentTypeLibrary, BOMRef: p.Ref, Name: p.Name, Version: p.Version, PackageURL: p.PURL})
+		}
+		bom := cyclonedx.NewBOM()
+		bom.Components = &components
+		buf := &bytes.Buffer{}
+		if err := cyclonedx.NewBOMEncoder(buf, cyclonedx.BOMFileFormatJSON).Encode(bom); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, scanner.FileName(image)+".cdx.json"), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &scanner.ImportScanner{Dir: dir}
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	run := func(images string) *generator.Result {
+		c := generatortest.Chart()
+		c.Metadata.Annotations["helm.sh/images"] = images
+		return generatortest.Generate(t, c, generator.WithScanner(importScanner(t)), generator.WithImageDocuments(true))
+	}
+	// from SBOMs in another directory, with the images listed the other way round
+	first := run("- image: nginx:1.21\n- image: redis:6.2\n")
+	second := run("- image: redis:6.2\n- image: nginx:1.21\n")
+
+	for format, b := range first.Encoded {
+		if !bytes.Equal(b, second.Encoded[format]) {
+			t.Errorf("the %v of two runs differ:\n%s\n%s", format, b, second.Encoded[format])
+		}
+	}
+	for image, b := range first.EncodedImageSPDX {
+		if !bytes.Equal(b, second.EncodedImageSPDX[image]) {
+			t.Errorf("the SPDX of %v of two runs differ:\n%s\n%s", image, b, second.EncodedImageSPDX[image])
+		}
+	}
+	if len(first.EncodedImageSPDX) != 2 {
+		t.Errorf("%v image documents, want 2", len(first.EncodedImageSPDX))
+	}
+}
+
+func TestGenerateImageDocuments(t *testing.T) {
+	res := generatortest.Generate(t, generatortest.Chart(), generator.WithImageDocuments(true), generator.WithNamespaceBase("https://example.com/sboms/"))
+	if ns := res.SPDX.CreationInfo.DocumentNamespace; !strings.HasPrefix(ns, "https://example.com/sboms/chart/demo-0.1.0-") {
+		t.Errorf("chart namespace %v, want it under the namespace base", ns)
+	}
+	refs := res.SPDX.CreationInfo.ExternalDocumentReferences
+	if len(refs) != 2 {
+		t.Fatalf("%v external document references, want one for each image", len(refs))
+	}
+	for image, doc := range res.ImageSPDX {
+		ns := doc.CreationInfo.DocumentNamespace
+		if !strings.HasPrefix(ns, "https://example.com/sboms/image/") {
+			t.Errorf("namespace of %v is %v, want it under the namespace base", image, ns)
+		}
+		validate(t, image, res.EncodedImageSPDX[image])
+	}
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref.URI, "https://example.com/sboms/image/") {
+			t.Errorf("%v refers to %v, want an image document", ref.DocumentRefID, ref.URI)
+		}
+	}
+	if _, ok := res.SPDX.Packages["deb-nginx-1.21.3"]; ok {
+		t.Errorf("deb-nginx is in the chart document, want it only in the image's")
+	}
+	// the CycloneDX BOM is made from the whole chart document
+	found := false
+	for _, c := range *res.CycloneDX.Components {
+		found = found || c.BOMRef == "deb-nginx-1.21.3"
+	}
+	if !found {
+		t.Errorf("deb-nginx is not a component of the CycloneDX BOM")
+	}
+	if res.CycloneDX.SerialNumber == generatortest.Generate(t, generatortest.Chart(), generator.WithImageDocuments(true)).CycloneDX.SerialNumber {
+		t.Errorf("BOMs of documents with different namespaces have the same serial number")
+	}
+}
+
+func TestGenerateChartFiles(t *testing.T) {
+	c := generatortest.Chart()
+	c.Templates = []*chart.File{{Name: "templates/deployment.yaml", Data: []byte("kind: Deployment\n")}}
+	c.Raw = []*chart.File{{Name: "Chart.yaml", Data: []byte("name: demo\n")}}
+	res := generatortest.Generate(t, c, generator.WithChartFiles(true))
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	p := res.SPDX.Packages["chart-demo"]
+	if len(p.Files) != 2 || p.PackageVerificationCode == "" {
+		t.Errorf("the chart package has %v files and verification code %q, want 2 and a code", len(p.Files), p.PackageVerificationCode)
+	}
+	files := 0
+	for _, component := range *res.CycloneDX.Components {
+		if component.Type != cyclonedx.ComponentTypeFile {
+			continue
+		}
+		files++
+		if component.Version != "0.1.0" {
+			t.Errorf("%v has version %q, want the chart's 0.1.0", component.Name, component.Version)
+		}
+		if component.Hashes == nil || len(*component.Hashes) != 2 {
+			t.Errorf("%v has hashes %v, want its SHA-1 and SHA-256", component.Name, component.Hashes)
+		}
+	}
+	if files != 2 {
+		t.Errorf("%v file components, want 2", files)
+	}
+}
+
+func TestGenerateChartFileIDs(t *testing.T) {
+	c := generatortest.Chart()
+	// both paths sanitize to templates-a-b.yaml
+	c.Templates = []*chart.File{
+		{Name: "templates/a_b.yaml", Data: []byte("kind: Service\n")},
+		{Name: "templates/a-b.yaml", Data: []byte("kind: Deployment\n")},
+	}
+	res := generatortest.Generate(t, c, generator.WithChartFiles(true))
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	names := make(map[spdx.ElementID]string)
+	for id, f := range res.SPDX.Packages["chart-demo"].Files {
+		names[id] = f.FileName
+	}
+	if len(names) != 2 {
+		t.Fatalf("files %v, want one for each template", names)
+	}
+	valid := regexp.MustCompile(`^file-templates-a-b.yaml-[0-9a-f]{8}$`)
+	for id := range names {
+		if !valid.MatchString(string(id)) {
+			t.Errorf("file ID %q, want the sanitized path and a short hash", id)
+		}
+	}
+	refs := make(map[string]string)
+	for _, component := range *res.CycloneDX.Components {
+		if component.Type == cyclonedx.ComponentTypeFile {
+			refs[component.BOMRef] = "./" + component.Name
+		}
+	}
+	if len(refs) != 2 {
+		t.Errorf("file components %v, want one for each template", refs)
+	}
+	for id, name := range names {
+		if refs[string(id)] != name {
+			t.Errorf("bom-ref %v is %q, want %q", id, refs[string(id)], name)
+		}
+	}
+}
+
+func TestGenerateCRDs(t *testing.T) {
+	c := generatortest.Chart()
+	c.Metadata.KubeVersion = ">=1.20.0"
+	c.Files = []*chart.File{{Name: "crds/widgets.yaml", Data: []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Widget
+  versions:
+    - name: v1
+      served: true
+      storage: true
+`)}}
+	res := generatortest.Generate(t, c)
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	if p, ok := res.SPDX.Packages["crd-widgets.example.com"]; !ok || p.PackageVersion != "v1" {
+		t.Errorf("the CRD package is %+v, want widgets.example.com at its storage version v1", p)
+	}
+	var dependsOn []string
+	for _, dep := range *res.CycloneDX.Dependencies {
+		if dep.Ref != "demo" {
+			continue
+		}
+		for _, d := range *dep.Dependencies {
+			dependsOn = append(dependsOn, d.Ref)
+		}
+	}
+	want := []string{"image-docker.io-library-nginx-1.21", "image-docker.io-library-redis-6.2", "platform-kubernetes", "crd-widgets.example.com"}
+	if !reflect.DeepEqual(dependsOn, want) {
+		t.Errorf("the chart depends on %v, want %v", dependsOn, want)
+	}
+
+	// a chart of Kubernetes itself is another component than the Kubernetes
+	// it runs on
+	c.Metadata.Name = "kubernetes"
+	res = generatortest.Generate(t, c)
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+	bom := res.CycloneDX
+	refs := map[string]cyclonedx.ComponentType{bom.Metadata.Component.BOMRef: bom.Metadata.Component.Type}
+	for _, component := range *bom.Components {
+		if _, ok := refs[component.BOMRef]; ok {
+			t.Errorf("bom-ref %v is not unique", component.BOMRef)
+		}
+		refs[component.BOMRef] = component.Type
+	}
+	if refs["kubernetes"] != cyclonedx.ComponentTypeApplication || refs["platform-kubernetes"] != cyclonedx.ComponentTypeFramework {
+		t.Errorf("components %v, want the kubernetes chart and the platform-kubernetes framework", refs)
+	}
+}
+
+// annotations are the comments of the SPDX annotations of an element
+func annotations(doc *spdx.Document2_2, id spdx.ElementID) []string {
+	out := make([]string, 0)
+	for _, a := range doc.Annotations {
+		if a.AnnotationSPDXIdentifier.ElementRefID == id {
+			out = append(out, a.AnnotationComment)
+		}
+	}
+	return out
+}
+
+// properties are the properties of a CycloneDX component, as name=value
+func properties(c *cyclonedx.Component) []string {
+	out := make([]string, 0)
+	if c.Properties != nil {
+		for _, p := range *c.Properties {
+			out = append(out, p.Name+"="+p.Value)
+		}
+	}
+	return out
+}
+
+func TestGenerateProvenance(t *testing.T) {
+	p := &helm.Provenance{
+		Status:      helm.ProvenanceVerified,
+		Signer:      "Jane Doe <jane@example.com>",
+		Fingerprint: "5E615389B53CA37F0EE60BD3843BBF981FC18762",
+		Files:       map[string]string{"demo-0.1.0.tgz": "sha256:d04d6f97"},
+	}
+	res := generatortest.Generate(t, generatortest.Chart(), generator.WithProvenance(p), generator.WithRequireProvenance(true))
+	want := []string{
+		"sbom-cli:provenance=verified",
+		"sbom-cli:provenance:signer=Jane Doe <jane@example.com>",
+		"sbom-cli:provenance:fingerprint=5E615389B53CA37F0EE60BD3843BBF981FC18762",
+		"sbom-cli:provenance:file:demo-0.1.0.tgz=sha256:d04d6f97",
+	}
+	if got := annotations(res.SPDX, "chart-demo"); !reflect.DeepEqual(got, want) {
+		t.Errorf("SPDX annotations %q, want %q", got, want)
+	}
+	if got := properties(res.CycloneDX.Metadata.Component); !reflect.DeepEqual(got, want) {
+		t.Errorf("CycloneDX properties %q, want %q", got, want)
+	}
+	if res.Provenance != p {
+		t.Errorf("result provenance %+v, want %+v", res.Provenance, p)
+	}
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	// without verifying, the chart has no provenance to record
+	res = generatortest.Generate(t, generatortest.Chart())
+	if got := annotations(res.SPDX, "chart-demo"); len(got) != 0 {
+		t.Errorf("SPDX annotations %q of a chart not verified, want none", got)
+	}
+	if got := properties(res.CycloneDX.Metadata.Component); len(got) != 0 {
+		t.Errorf("CycloneDX properties %q of a chart not verified, want none", got)
+	}
+}
+
+func TestGenerateRequireProvenance(t *testing.T) {
+	archive, err := chartutil.Save(generatortest.Chart(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := generatortest.Generator()
+	// the archive has no .prov file, so its keyring isn't read
+	res, err := g.Generate(context.Background(), archive, generator.WithKeyring("missing.gpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Provenance == nil || res.Provenance.Status != helm.ProvenanceUnsigned {
+		t.Errorf("provenance %+v, want unsigned", res.Provenance)
+	}
+	if got := annotations(res.SPDX, "chart-demo"); !reflect.DeepEqual(got, []string{"sbom-cli:provenance=unsigned"}) {
+		t.Errorf("SPDX annotations %q, want the chart unsigned", got)
+	}
+
+	if _, err := g.Generate(context.Background(), archive, generator.WithRequireProvenance(true)); err == nil {
+		t.Error("generated the SBOM of an unsigned chart with its provenance required")
+	}
+	if _, err := g.GenerateChart(context.Background(), generatortest.Chart(), generator.WithRequireProvenance(true)); err == nil {
+		t.Error("generated the SBOM of a chart not verified with its provenance required")
+	}
+}
+
+// tagValues are the values of a tag in a tag-value SPDX document, in order
+func tagValues(b []byte, tag string) []string {
+	out := make([]string, 0)
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(line, tag+": ") {
+			out = append(out, strings.TrimPrefix(line, tag+": "))
+		}
+	}
+	return out
+}
+
+// decodeCycloneDX reads the CycloneDX JSON a result encoded
+func decodeCycloneDX(t *testing.T, res *generator.Result) *cyclonedx.BOM {
+	t.Helper()
+	bom := &cyclonedx.BOM{}
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(res.Encoded[generator.FormatCycloneDXJSON]), cyclonedx.BOMFileFormatJSON).Decode(bom); err != nil {
+		t.Fatal(err)
+	}
+	return bom
+}
+
+func TestGenerateCreators(t *testing.T) {
+	jane := &cyclonedx.OrganizationalEntity{Name: "Jane Doe", Contact: &[]cyclonedx.OrganizationalContact{{Name: "Jane Doe", Email: "jane@example.com"}}}
+	acme := &cyclonedx.OrganizationalEntity{Name: "Acme"}
+	tests := []struct {
+		name           string
+		maintainers    []*chart.Maintainer
+		opts           []generator.Option
+		creators       []string
+		supplier       string
+		originator     string
+		authors        []cyclonedx.OrganizationalContact
+		cdxSupplier    *cyclonedx.OrganizationalEntity
+		cdxManufacture *cyclonedx.OrganizationalEntity
+	}{
+		{
+			name:           "default",
+			creators:       []string{"Organization: Defense Unicorns", "Tool: sbom-cli-dev"},
+			supplier:       "Person: Jane Doe (jane@example.com)",
+			originator:     "Person: Jane Doe (jane@example.com)",
+			authors:        []cyclonedx.OrganizationalContact{{Name: "Defense Unicorns"}},
+			cdxSupplier:    jane,
+			cdxManufacture: jane,
+		},
+		{
+			name: "configured",
+			opts: []generator.Option{
+				generator.WithCreators(sbom.ParseEntity("Person: John Roe (john@example.com)"), sbom.ParseEntity("Acme")),
+				generator.WithToolVersion("1.2.3"),
+				generator.WithSupplier(sbom.ParseEntity("Organization: Acme")),
+				generator.WithOriginator(sbom.ParseEntity("Person: John Roe (john@example.com)")),
+			},
+			creators:       []string{"Person: John Roe (john@example.com)", "Organization: Acme", "Tool: sbom-cli-1.2.3"},
+			supplier:       "Organization: Acme",
+			originator:     "Person: John Roe (john@example.com)",
+			authors:        []cyclonedx.OrganizationalContact{{Name: "John Roe", Email: "john@example.com"}, {Name: "Acme"}},
+			cdxSupplier:    acme,
+			cdxManufacture: &cyclonedx.OrganizationalEntity{Name: "John Roe", Contact: &[]cyclonedx.OrganizationalContact{{Name: "John Roe", Email: "john@example.com"}}},
+		},
+		{
+			name:        "no maintainers",
+			maintainers: []*chart.Maintainer{},
+			creators:    []string{"Organization: Defense Unicorns", "Tool: sbom-cli-dev"},
+			supplier:    "NOASSERTION",
+			originator:  "NOASSERTION",
+			authors:     []cyclonedx.OrganizationalContact{{Name: "Defense Unicorns"}},
+		},
+		{
+			name:           "no maintainers with a supplier",
+			maintainers:    []*chart.Maintainer{{Email: "nameless@example.com"}},
+			opts:           []generator.Option{generator.WithSupplier(sbom.ParseEntity("Acme"))},
+			creators:       []string{"Organization: Defense Unicorns", "Tool: sbom-cli-dev"},
+			supplier:       "Organization: Acme",
+			originator:     "NOASSERTION",
+			authors:        []cyclonedx.OrganizationalContact{{Name: "Defense Unicorns"}},
+			cdxSupplier:    acme,
+			cdxManufacture: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := generatortest.Chart()
+			if test.maintainers != nil {
+				c.Metadata.Maintainers = test.maintainers
+			}
+			res := generatortest.Generate(t, c, test.opts...)
+			for format, b := range res.Encoded {
+				validate(t, format, b)
+			}
+
+			tv := res.Encoded[generator.FormatSPDX]
+			if got := tagValues(tv, "Creator"); !reflect.DeepEqual(got, test.creators) {
+				t.Errorf("SPDX creators %q, want %q", got, test.creators)
+			}
+			// only the chart package has a supplier and originator
+			if got := tagValues(tv, "PackageSupplier"); !reflect.DeepEqual(got, []string{test.supplier}) {
+				t.Errorf("SPDX suppliers %q, want %q", got, test.supplier)
+			}
+			if got := tagValues(tv, "PackageOriginator"); !reflect.DeepEqual(got, []string{test.originator}) {
+				t.Errorf("SPDX originators %q, want %q", got, test.originator)
+			}
+
+			md := decodeCycloneDX(t, res).Metadata
+			if md.Authors == nil || !reflect.DeepEqual(*md.Authors, test.authors) {
+				t.Errorf("CycloneDX authors %+v, want %+v", md.Authors, test.authors)
+			}
+			if !reflect.DeepEqual(md.Supplier, test.cdxSupplier) || !reflect.DeepEqual(md.Component.Supplier, test.cdxSupplier) {
+				t.Errorf("CycloneDX suppliers %+v and %+v of the chart, want %+v", md.Supplier, md.Component.Supplier, test.cdxSupplier)
+			}
+			if !reflect.DeepEqual(md.Manufacture, test.cdxManufacture) {
+				t.Errorf("CycloneDX manufacturer %+v, want %+v", md.Manufacture, test.cdxManufacture)
+			}
+			if tools := *md.Tools; len(tools) != 1 || tools[0].Name != generator.ToolName || "Tool: "+tools[0].Name+"-"+tools[0].Version != test.creators[len(test.creators)-1] {
+				t.Errorf("CycloneDX tools %+v, want the tool SPDX names", tools)
+			}
+		})
+	}
+}
+
+func TestGenerateChartMetadata(t *testing.T) {
+	c := generatortest.Chart()
+	md := c.Metadata
+	md.Home = "https://example.com/demo"
+	md.Sources = []string{"https://github.com/example/demo", "https://github.com/example/images"}
+	md.Icon = "https://example.com/demo.png"
+	md.Keywords = []string{"web", "cache"}
+	md.Deprecated = true
+	md.Type = "library"
+	md.AppVersion = "1.21"
+	md.KubeVersion = ">=1.20.0"
+	md.Maintainers = append(md.Maintainers, &chart.Maintainer{Name: "John Roe"}, nil)
+	res := generatortest.Generate(t, c)
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	tv := res.Encoded[generator.FormatSPDX]
+	for tag, want := range map[string][]string{
+		"PackageHomePage": {"https://example.com/demo"},
+		// the first maintainer made the chart
+		"PackageOriginator": {"Person: Jane Doe (jane@example.com)"},
+	} {
+		if got := tagValues(tv, tag); !reflect.DeepEqual(got, want) {
+			t.Errorf("SPDX %v %q, want %q", tag, got, want)
+		}
+	}
+	// the images' packages have purls, the chart's sources and icon
+	chartRefs := make([]string, 0)
+	for _, ref := range tagValues(tv, "ExternalRef") {
+		if strings.HasPrefix(ref, "OTHER ") {
+			chartRefs = append(chartRefs, ref)
+		}
+	}
+	wantChartRefs := []string{
+		"OTHER vcs https://github.com/example/demo",
+		"OTHER vcs https://github.com/example/images",
+		"OTHER icon https://example.com/demo.png",
+	}
+	if !reflect.DeepEqual(chartRefs, wantChartRefs) {
+		t.Errorf("SPDX external references %q, want %q", chartRefs, wantChartRefs)
+	}
+	props := []string{
+		"helm:appVersion=1.21",
+		"helm:kubeVersion=>=1.20.0",
+		"helm:type=library",
+		"helm:deprecated=true",
+		"helm:keyword=web",
+		"helm:keyword=cache",
+	}
+	if got := annotations(res.SPDX, "chart-demo"); !reflect.DeepEqual(got, props) {
+		t.Errorf("SPDX annotations %q, want %q", got, props)
+	}
+
+	component := decodeCycloneDX(t, res).Metadata.Component
+	if got := properties(component); !reflect.DeepEqual(got, props) {
+		t.Errorf("CycloneDX properties %q, want %q", got, props)
+	}
+	if component.Type != cyclonedx.ComponentTypeLibrary {
+		t.Errorf("a library chart is a CycloneDX %v, want a library", component.Type)
+	}
+	// every maintainer wrote the chart
+	if want := "Jane Doe (jane@example.com), John Roe"; component.Author != want {
+		t.Errorf("CycloneDX author %q, want %q", component.Author, want)
+	}
+	wantRefs := []cyclonedx.ExternalReference{
+		{Type: cyclonedx.ERTypeWebsite, URL: "https://example.com/demo"},
+		{Type: cyclonedx.ERTypeVCS, URL: "https://github.com/example/demo"},
+		{Type: cyclonedx.ERTypeVCS, URL: "https://github.com/example/images"},
+		{Type: cyclonedx.ERTypeOther, URL: "https://example.com/demo.png", Comment: "icon"},
+	}
+	if component.ExternalReferences == nil || !reflect.DeepEqual(*component.ExternalReferences, wantRefs) {
+		t.Errorf("CycloneDX external references %+v, want %+v", component.ExternalReferences, wantRefs)
+	}
+
+	// an application chart that isn't deprecated has neither property
+	res = generatortest.Generate(t, generatortest.Chart())
+	component = decodeCycloneDX(t, res).Metadata.Component
+	if component.Type != cyclonedx.ComponentTypeApplication || component.ExternalReferences != nil || component.Properties != nil {
+		t.Errorf("the CycloneDX component of a chart without metadata is %+v", component)
+	}
+	if got := tagValues(res.Encoded[generator.FormatSPDX], "PackageHomePage"); len(got) != 0 {
+		t.Errorf("SPDX home pages %q of a chart without one", got)
+	}
+}
+
+// testdata/layout is an OCI image layout of nginx:1.21, a single image, and
+// redis:6.2, an image index of linux/amd64 and linux/arm64/v8
+const (
+	amd64Digest = "sha256:673de5d3943b722922c8dd729d12cc89c9d95b0ef3fd5540052f613dbae84a5f"
+	arm64Digest = "sha256:3678d452af231b1d97ffdbf74f6a29d5c4a403f6617b042c7c8dc61477ce8e53"
+)
+
+func TestGeneratePlatforms(t *testing.T) {
+	s := generatortest.Scanner()
+	redis := s.Packages["redis:6.2"]
+	armOpenSSL := redis[0]
+	armOpenSSL.Version, armOpenSSL.PURL = "1.1.1n", "pkg:deb/debian/openssl@1.1.1n"
+	// the images of the platforms are scanned by digest
+	s.Packages["docker.io/library/redis@"+amd64Digest] = redis
+	s.Packages["docker.io/library/redis@"+arm64Digest] = []sbom.Component{armOpenSSL, redis[1]}
+	res := generatortest.Generate(t, generatortest.Chart(), generator.WithScanner(s),
+		generator.WithResolver(&imageref.Resolver{Layout: "testdata/layout"}),
+		generator.WithPlatforms(oci.Platform{OS: "linux", Architecture: "amd64"}, oci.Platform{OS: "linux", Architecture: "arm64"}))
+	for format, b := range res.Encoded {
+		validate(t, format, b)
+	}
+
+	const (
+		index = "image-docker.io-library-redis-6.2"
+		amd64 = "image-docker.io-library-redis-6.2-linux-amd64"
+		arm64 = "image-docker.io-library-redis-6.2-linux-arm64-v8"
+		nginx = "image-docker.io-library-nginx-1.21"
+	)
+	for id, digest := range map[spdx.ElementID]string{amd64: amd64Digest, arm64: arm64Digest} {
+		p, ok := res.SPDX.Packages[id]
+		if !ok {
+			t.Errorf("no package %v", id)
+			continue
+		}
+		if sum := p.PackageChecksums[spdx.SHA256].Value; "sha256:"+sum != digest {
+			t.Errorf("%v has checksum %v, want the digest of its manifest %v", id, sum, digest)
+		}
+	}
+	var relationships []string
+	for _, r := range res.SPDX.Relationships {
+		relationships = append(relationships, string(r.RefA.ElementRefID)+" "+r.Relationship+" "+string(r.RefB.ElementRefID))
+	}
+	sort.Strings(relationships)
+	wantRelationships := []string{
+		"DOCUMENT DESCRIBES chart-demo",
+		"chart-demo CONTAINS " + nginx,
+		"chart-demo CONTAINS " + index,
+		nginx + " CONTAINS deb-nginx-1.21.3",
+		nginx + " CONTAINS deb-openssl-1.1.1k",
+		index + " CONTAINS " + amd64,
+		index + " CONTAINS " + arm64,
+		amd64 + " CONTAINS deb-openssl-1.1.1k",
+		amd64 + " CONTAINS deb-redis-6.2.6",
+		amd64 + " VARIANT_OF " + index,
+		arm64 + " CONTAINS deb-openssl-1.1.1n",
+		arm64 + " CONTAINS deb-redis-6.2.6",
+		arm64 + " VARIANT_OF " + index,
+	}
+	sort.Strings(wantRelationships)
+	if !reflect.DeepEqual(relationships, wantRelationships) {
+		t.Errorf("relationships\n%q\nwant\n%q", relationships, wantRelationships)
+	}
+	platformProps := []string{"oci:platform:os=linux", "oci:platform:architecture=arm64", "oci:platform:variant=v8"}
+	if got := annotations(res.SPDX, arm64); !reflect.DeepEqual(got, append([]string{"sbom-cli:source=fake"}, platformProps...)) {
+		t.Errorf("SPDX annotations of %v %q, want its source and platform", arm64, got)
+	}
+
+	// in CycloneDX the images of the platforms are in the index's component
+	bom := decodeCycloneDX(t, res)
+	nested := make(map[string][]string)
+	var top []string
+	for _, c := range *bom.Components {
+		if c.Type != cyclonedx.ComponentTypeContainer {
+			continue
+		}
+		top = append(top, c.BOMRef)
+		if c.Components != nil {
+			for _, child := range *c.Components {
+				nested[c.BOMRef] = append(nested[c.BOMRef], child.BOMRef)
+				if child.BOMRef == arm64 {
+					if got := properties(&child); !reflect.DeepEqual(got, append([]string{"sbom-cli:source=fake"}, platformProps...)) {
+						t.Errorf("CycloneDX properties of %v %q, want its source and platform", arm64, got)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(top)
+	if want := []string{nginx, index}; !reflect.DeepEqual(top, want) {
+		t.Errorf("image components %v, want %v", top, want)
+	}
+	if want := map[string][]string{index: {amd64, arm64}}; !reflect.DeepEqual(nested, want) {
+		t.Errorf("nested components %v, want %v", nested, want)
+	}
+	for _, dep := range *bom.Dependencies {
+		if dep.Ref != "demo" {
+			continue
+		}
+		var dependsOn []string
+		for _, d := range *dep.Dependencies {
+			dependsOn = append(dependsOn, d.Ref)
+		}
+		if want := []string{nginx, index}; !reflect.DeepEqual(dependsOn, want) {
+			t.Errorf("the chart depends on %v, want the images it lists", dependsOn)
+		}
+	}
+}
+
+func TestGeneratePlatformsNeedResolver(t *testing.T) {
+	g := generatortest.Generator(generator.WithPlatforms(oci.Platform{OS: "linux", Architecture: "amd64"}))
+	if _, err := g.GenerateChart(context.Background(), generatortest.Chart()); err == nil {
+		t.Error("generated the SBOM of platforms without a resolver")
+	}
+}
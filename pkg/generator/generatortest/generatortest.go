@@ -0,0 +1,61 @@
+// Package generatortest provides a chart and a fake scanner for testing code
+// that generates or reads chart SBOMs
+package generatortest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
+)
+
+// Time is when Generate makes SBOMs
+var Time = time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// Chart lists an nginx and a redis image, which share openssl
+func Chart() *chart.Chart {
+	return &chart.Chart{Metadata: &chart.Metadata{
+		APIVersion:  "v2",
+		Name:        "demo",
+		Version:     "0.1.0",
+		Description: "A chart for tests",
+		Maintainers: []*chart.Maintainer{{Name: "Jane Doe", Email: "jane@example.com"}},
+		Annotations: map[string]string{
+			"helm.sh/images": "- image: nginx:1.21\n- image: redis:6.2\n",
+		},
+	}}
+}
+
+// Scanner knows the packages of the images of Chart
+func Scanner() *scanner.Fake {
+	openssl := sbom.Component{Ref: "deb-openssl", Name: "openssl", Version: "1.1.1k", PURL: "pkg:deb/debian/openssl@1.1.1k", Licenses: []string{"OpenSSL"}}
+	return &scanner.Fake{Packages: map[string][]sbom.Component{
+		"nginx:1.21": {openssl, {Ref: "deb-nginx", Name: "nginx", Version: "1.21.3", PURL: "pkg:deb/debian/nginx@1.21.3"}},
+		"redis:6.2":  {openssl, {Ref: "deb-redis", Name: "redis", Version: "6.2.6", PURL: "pkg:deb/debian/redis@6.2.6"}},
+	}}
+}
+
+// Generator makes SBOMs with Scanner at Time in every format, then applies
+// opts
+func Generator(opts ...generator.Option) *generator.Generator {
+	return generator.New(append([]generator.Option{
+		generator.WithScanner(Scanner()),
+		generator.WithTimestamp(Time),
+		generator.WithFormats(generator.FormatSPDX, generator.FormatCycloneDX, generator.FormatCycloneDXJSON),
+	}, opts...)...)
+}
+
+// Generate makes the SBOM of c with Generator, failing the test if it can't
+func Generate(t testing.TB, c *chart.Chart, opts ...generator.Option) *generator.Result {
+	t.Helper()
+	res, err := Generator(opts...).GenerateChart(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
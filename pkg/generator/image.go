@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"context"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// WithResolver resolves image tags to the digests of their manifests, so
+// the SBOM records what the tag pointed at when it was made. Without one,
+// digests are only recorded when the chart or the scanner gives them.
+func WithResolver(r *imageref.Resolver) Option {
+	return func(g *Generator) {
+		g.resolver = r
+	}
+}
+
+// Properties of the image of one platform of an image index, in CycloneDX
+// properties of its component and SPDX annotations on its package
+const (
+	PlatformOSProperty           = "oci:platform:os"
+	PlatformArchitectureProperty = "oci:platform:architecture"
+	PlatformVariantProperty      = "oci:platform:variant"
+)
+
+// WithPlatforms scans the image of each of the platforms in the image index
+// an image tag points at, instead of the one the scanner would pick. It
+// needs a resolver.
+func WithPlatforms(platforms ...oci.Platform) Option {
+	return func(g *Generator) {
+		g.platforms = platforms
+	}
+}
+
+// platformProperties are the properties of the platform of an image
+func platformProperties(ref imageref.Reference) []cyclonedx.Property {
+	p, err := imageref.ParsePlatform(ref.Platform)
+	if err != nil {
+		return nil
+	}
+	props := []cyclonedx.Property{
+		{Name: PlatformOSProperty, Value: p.OS},
+		{Name: PlatformArchitectureProperty, Value: p.Architecture},
+	}
+	if p.Variant != "" {
+		props = append(props, cyclonedx.Property{Name: PlatformVariantProperty, Value: p.Variant})
+	}
+	return props
+}
+
+// nestPlatforms moves the components of the images of an index's platforms
+// into the component of the index
+func nestPlatforms(bom *cyclonedx.BOM, res *Result) {
+	parents := make(map[string]string)
+	for _, ref := range res.References {
+		if ref.Platform != "" {
+			index := ref
+			index.Platform = ""
+			parents[ref.ID()] = index.ID()
+		}
+	}
+	if len(parents) == 0 {
+		return
+	}
+	children := make(map[string][]cyclonedx.Component)
+	components := make([]cyclonedx.Component, 0, len(*bom.Components))
+	for _, c := range *bom.Components {
+		if parent, ok := parents[c.BOMRef]; ok {
+			children[parent] = append(children[parent], c)
+			continue
+		}
+		components = append(components, c)
+	}
+	for i, c := range components {
+		if nested, ok := children[c.BOMRef]; ok {
+			components[i].Components = &nested
+		}
+	}
+	bom.Components = &components
+}
+
+// imageReferences normalizes the images a chart lists, leaving out those that
+// are the same image written differently, and resolves their digests with
+// the resolver
+func (g *Generator) imageReferences(ctx context.Context, images []string) ([]imageref.Reference, error) {
+	refs := make([]imageref.Reference, 0, len(images))
+	seen := make(map[string]bool)
+	for _, image := range images {
+		ref, err := imageref.Parse(image)
+		if err != nil {
+			return nil, err
+		}
+		if seen[ref.Tagged()] {
+			continue
+		}
+		seen[ref.Tagged()] = true
+		if g.resolver != nil {
+			if ref, err = g.resolver.Resolve(ctx, ref); err != nil {
+				return nil, err
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// imageID is the SPDX identifier and bom-ref of the package of an image
+func imageID(ref imageref.Reference) spdx.ElementID {
+	return sbom.ElementID(ref.ID())
+}
+
+// imagePackage is the package of an image, with its OCI package URL and,
+// when it is known, its digest as a checksum
+func imagePackage(ref imageref.Reference) *spdx.Package2_2 {
+	p := sbom.ImageToPackage(ref.Tagged())
+	p.PackageSPDXIdentifier = imageID(ref)
+	if hex := sha256Hex(ref.Digest); hex != "" {
+		p.PackageChecksums = map[spdx.ChecksumAlgorithm]spdx.Checksum{
+			spdx.SHA256: {Algorithm: spdx.SHA256, Value: hex},
+		}
+	}
+	p.PackageExternalReferences = append(p.PackageExternalReferences, &spdx.PackageExternalReference2_2{
+		Category: string(syft.PackageManagerReferenceCategory),
+		RefType:  string(syft.PurlExternalRefType),
+		Locator:  ref.PURL(),
+	})
+	return p
+}
+
+// packageID is the element ID of a package found in an image. Scanners keep
+// refs unique only within an image and syft makes them from a package's type
+// and name, so the version tells the same package at two versions apart.
+func packageID(c sbom.Component) spdx.ElementID {
+	if c.Version == "" {
+		return sbom.ElementID(c.Ref)
+	}
+	return sbom.ElementID(c.Ref + "-" + c.Version)
+}
+
+// componentPackage is the package of a component found in an image
+func componentPackage(c sbom.Component) *spdx.Package2_2 {
+	p := sbom.ComponentToPackage(c)
+	p.PackageSPDXIdentifier = packageID(c)
+	return p
+}
+
+// imageHashes are the CycloneDX hashes of an image, its digest
+func imageHashes(ref imageref.Reference) []cyclonedx.Hash {
+	hex := sha256Hex(ref.Digest)
+	if hex == "" {
+		return nil
+	}
+	return []cyclonedx.Hash{{Algorithm: cyclonedx.HashAlgoSHA256, Value: hex}}
+}
+
+// sha256Hex is the hex of a sha256:<hex> digest, empty for other digests
+func sha256Hex(digest string) string {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return ""
+	}
+	return strings.TrimPrefix(digest, "sha256:")
+}
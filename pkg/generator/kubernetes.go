@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Properties of the custom resource definitions a chart installs, in
+// CycloneDX properties of their components and SPDX annotations on their
+// packages. There is a version property for each version.
+const (
+	CRDGroupProperty   = "k8s:crd:group"
+	CRDKindProperty    = "k8s:crd:kind"
+	CRDVersionProperty = "k8s:crd:version"
+	CRDScopeProperty   = "k8s:crd:scope"
+	CRDSourceProperty  = "k8s:crd:source"
+)
+
+// KubernetesAPIVersionProperty is an API version the chart's templates check
+// the cluster for, on the kubernetes component. The component's version is
+// the chart's kubeVersion range.
+const KubernetesAPIVersionProperty = "k8s:apiVersion"
+
+// kubernetesID is the SPDX identifier and bom-ref of the Kubernetes the
+// chart runs on. It has a prefix, as a chart's bom-ref is its name, which
+// may well be kubernetes.
+const kubernetesID = "platform-kubernetes"
+
+// crdID is the SPDX identifier and bom-ref of a custom resource definition
+func crdID(crd helm.CRD) spdx.ElementID {
+	return sbom.ElementID("crd-" + crd.Name)
+}
+
+// addKubernetes adds a package for each custom resource definition the chart
+// installs, which the chart package contains, and one for the Kubernetes it
+// needs, which the chart package depends on, when Chart.yaml has a
+// kubeVersion or the templates check for API versions
+func (g *Generator) addKubernetes(doc *spdx.Document2_2, chartPackage *spdx.Package2_2, chart *chart.Chart) []helm.CRD {
+	crds, err := helm.CRDs(chart)
+	if err != nil {
+		fmt.Fprintf(g.progress, "Only found the CRDs outside the templates, which did not render: %v\n", err)
+	}
+	for _, crd := range crds {
+		p := &spdx.Package2_2{
+			PackageName:             crd.Name,
+			PackageSPDXIdentifier:   crdID(crd),
+			PackageVersion:          crd.StorageVersion,
+			PackageDownloadLocation: "NOASSERTION",
+			PackageLicenseConcluded: "NOASSERTION",
+			PackageLicenseDeclared:  "NOASSERTION",
+			PackageCopyrightText:    "NOASSERTION",
+		}
+		doc.Packages[p.PackageSPDXIdentifier] = p
+		doc.Relationships = append(doc.Relationships, &spdx.Relationship2_2{
+			RefA:         spdx.DocElementID{ElementRefID: chartPackage.PackageSPDXIdentifier},
+			RefB:         spdx.DocElementID{ElementRefID: p.PackageSPDXIdentifier},
+			Relationship: "CONTAINS",
+		})
+		annotate(doc, p.PackageSPDXIdentifier, crdProperties(crd))
+	}
+
+	apiVersions := helm.APIVersions(chart)
+	if chart.Metadata.KubeVersion == "" && len(apiVersions) == 0 {
+		return crds
+	}
+	p := &spdx.Package2_2{
+		PackageName:             "kubernetes",
+		PackageSPDXIdentifier:   kubernetesID,
+		PackageVersion:          chart.Metadata.KubeVersion,
+		PackageDownloadLocation: "NOASSERTION",
+		PackageLicenseConcluded: "NOASSERTION",
+		PackageLicenseDeclared:  "NOASSERTION",
+		PackageCopyrightText:    "NOASSERTION",
+	}
+	doc.Packages[p.PackageSPDXIdentifier] = p
+	doc.Relationships = append(doc.Relationships, &spdx.Relationship2_2{
+		RefA:         spdx.DocElementID{ElementRefID: chartPackage.PackageSPDXIdentifier},
+		RefB:         spdx.DocElementID{ElementRefID: p.PackageSPDXIdentifier},
+		Relationship: "DEPENDS_ON",
+	})
+	annotate(doc, p.PackageSPDXIdentifier, apiVersionProperties(apiVersions))
+	return crds
+}
+
+func crdProperties(crd helm.CRD) []cyclonedx.Property {
+	props := []cyclonedx.Property{
+		{Name: CRDGroupProperty, Value: crd.Group},
+		{Name: CRDKindProperty, Value: crd.Kind},
+	}
+	for _, v := range crd.Versions {
+		props = append(props, cyclonedx.Property{Name: CRDVersionProperty, Value: v})
+	}
+	if crd.Scope != "" {
+		props = append(props, cyclonedx.Property{Name: CRDScopeProperty, Value: crd.Scope})
+	}
+	return append(props, cyclonedx.Property{Name: CRDSourceProperty, Value: crd.Source})
+}
+
+func apiVersionProperties(apiVersions []string) []cyclonedx.Property {
+	props := make([]cyclonedx.Property, 0, len(apiVersions))
+	for _, v := range apiVersions {
+		props = append(props, cyclonedx.Property{Name: KubernetesAPIVersionProperty, Value: v})
+	}
+	return props
+}
+
+// setKubernetesComponents gives the components of the custom resource
+// definitions and of Kubernetes their types and properties
+func setKubernetesComponents(components []cyclonedx.Component, chart *chart.Chart, crds []helm.CRD) {
+	byID := make(map[string]helm.CRD)
+	for _, crd := range crds {
+		byID[string(crdID(crd))] = crd
+	}
+	for i, c := range components {
+		var props []cyclonedx.Property
+		switch {
+		case c.BOMRef == kubernetesID:
+			components[i].Type = cyclonedx.ComponentTypeFramework
+			props = apiVersionProperties(helm.APIVersions(chart))
+		case strings.HasPrefix(c.BOMRef, "crd-"):
+			crd, ok := byID[c.BOMRef]
+			if !ok {
+				continue
+			}
+			components[i].Type = cyclonedx.ComponentTypeLibrary
+			props = crdProperties(crd)
+		default:
+			continue
+		}
+		if len(props) > 0 {
+			components[i].Properties = &props
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tvsaver"
+)
+
+// Namespace strategies, how the part of a document namespace that makes it
+// unique is made
+const (
+	// NamespaceHash uses the SHA-256 of the document, so the same content
+	// always gets the same namespace and different content a different one
+	NamespaceHash = "hash"
+	// NamespaceUUID uses a random UUID, so every run gets its own namespace
+	NamespaceUUID = "uuid"
+)
+
+// NamespaceStrategies are the strategies WithNamespaceStrategy takes
+var NamespaceStrategies = []string{NamespaceHash, NamespaceUUID}
+
+// Kinds of documents, the path under the namespace base they live in
+const (
+	namespaceChart = "chart"
+	namespaceImage = "image"
+)
+
+var unsafeInNamespace = regexp.MustCompile(`[^A-Za-z0-9.\-]`)
+
+// setNamespace sets the namespace of a document to
+// <base>/<kind>/<name>-<hash or uuid>
+func (g *Generator) setNamespace(doc *spdx.Document2_2, kind, name string) error {
+	unique := uuid.New().String()
+	if g.namespaceStrategy != NamespaceUUID {
+		doc.CreationInfo.DocumentNamespace = ""
+		h := sha256.New()
+		if err := tvsaver.Save2_2(doc, h); err != nil {
+			return err
+		}
+		unique = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	name = unsafeInNamespace.ReplaceAllString(name, "-")
+	doc.CreationInfo.DocumentNamespace = fmt.Sprintf("%s/%s/%s-%s", g.namespaceBase, kind, name, unique)
+	return nil
+}
@@ -0,0 +1,165 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// CRD is a custom resource definition a chart installs
+type CRD struct {
+	// Name is the name of the definition, <plural>.<group>
+	Name     string
+	Group    string
+	Kind     string
+	Versions []string
+	// StorageVersion is the version objects are stored as
+	StorageVersion string
+	Scope          string
+	// Source is the file of the chart the definition is in, under crds/ or
+	// templates/
+	Source string
+}
+
+// crdObject is the part of a CustomResourceDefinition CRDs reads, of
+// apiextensions.k8s.io/v1 or v1beta1
+type crdObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind string `yaml:"kind"`
+		} `yaml:"names"`
+		Scope string `yaml:"scope"`
+		// Version is the only version of v1beta1 definitions without versions
+		Version  string `yaml:"version"`
+		Versions []struct {
+			Name    string `yaml:"name"`
+			Storage bool   `yaml:"storage"`
+		} `yaml:"versions"`
+	} `yaml:"spec"`
+}
+
+// CRDs finds the custom resource definitions of a chart and its subcharts,
+// in their crds/ directories and in the templates rendered with the default
+// values. When the templates do not render, e.g. because they need values,
+// it returns the definitions it found with the error.
+func CRDs(c *chart.Chart) ([]CRD, error) {
+	crds := make([]CRD, 0)
+	seen := make(map[string]bool)
+	add := func(source string, content []byte) {
+		for _, crd := range parseCRDs(source, content) {
+			if !seen[crd.Name] {
+				seen[crd.Name] = true
+				crds = append(crds, crd)
+			}
+		}
+	}
+	for _, obj := range c.CRDObjects() {
+		add(obj.Filename, obj.File.Data)
+	}
+
+	rendered, err := render(c)
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, []byte(rendered[name]))
+	}
+	sort.Slice(crds, func(i, j int) bool {
+		return crds[i].Name < crds[j].Name
+	})
+	return crds, err
+}
+
+// render renders the templates of a chart with its default values, as helm
+// template does without a cluster
+func render(c *chart.Chart) (map[string]string, error) {
+	options := chartutil.ReleaseOptions{Name: c.Name(), Namespace: "default", IsInstall: true}
+	values, err := chartutil.ToRenderValues(c, c.Values, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, fmt.Errorf("rendering %v: %w", c.Name(), err)
+	}
+	rendered, err := engine.Render(c, values)
+	if err != nil {
+		return nil, fmt.Errorf("rendering %v: %w", c.Name(), err)
+	}
+	return rendered, nil
+}
+
+// parseCRDs reads the custom resource definitions among the YAML documents
+// of a manifest; documents that are not definitions, or not YAML, are skipped
+func parseCRDs(source string, content []byte) []CRD {
+	crds := make([]CRD, 0)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		obj := crdObject{}
+		if err := dec.Decode(&obj); err != nil {
+			break
+		}
+		if obj.Kind != "CustomResourceDefinition" || obj.Spec.Group == "" {
+			continue
+		}
+		crd := CRD{
+			Name:   obj.Metadata.Name,
+			Group:  obj.Spec.Group,
+			Kind:   obj.Spec.Names.Kind,
+			Scope:  obj.Spec.Scope,
+			Source: source,
+		}
+		for _, v := range obj.Spec.Versions {
+			crd.Versions = append(crd.Versions, v.Name)
+			if v.Storage {
+				crd.StorageVersion = v.Name
+			}
+		}
+		if len(crd.Versions) == 0 && obj.Spec.Version != "" {
+			crd.Versions = []string{obj.Spec.Version}
+		}
+		if crd.StorageVersion == "" && len(crd.Versions) > 0 {
+			crd.StorageVersion = crd.Versions[0]
+		}
+		crds = append(crds, crd)
+	}
+	return crds
+}
+
+// capabilitiesAPIVersion matches the API versions templates check the
+// cluster for, e.g. .Capabilities.APIVersions.Has "policy/v1"
+var capabilitiesAPIVersion = regexp.MustCompile(`\.Capabilities\.APIVersions\.Has\s+"([^"]+)"`)
+
+// APIVersions are the API versions the templates of a chart and its
+// subcharts check the cluster for, sorted
+func APIVersions(c *chart.Chart) []string {
+	seen := make(map[string]bool)
+	var walk func(c *chart.Chart)
+	walk = func(c *chart.Chart) {
+		for _, t := range c.Templates {
+			for _, m := range capabilitiesAPIVersion.FindAllStringSubmatch(string(t.Data), -1) {
+				seen[strings.TrimSpace(m[1])] = true
+			}
+		}
+		for _, dep := range c.Dependencies() {
+			walk(dep)
+		}
+	}
+	walk(c)
+	versions := make([]string, 0, len(seen))
+	for v := range seen {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
@@ -0,0 +1,27 @@
+package helm
+
+import (
+	"sort"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// Files are the files of a loaded chart, sorted by name: every file the
+// chart was loaded from, including bundled subchart archives, and its
+// templates and other files when it was not loaded from files
+func Files(c *chart.Chart) []*chart.File {
+	byName := make(map[string]*chart.File)
+	for _, list := range [][]*chart.File{c.Templates, c.Files, c.Raw} {
+		for _, f := range list {
+			byName[f.Name] = f
+		}
+	}
+	files := make([]*chart.File, 0, len(byName))
+	for _, f := range byName {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
@@ -3,6 +3,7 @@ package helm
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"strings"
 
 	"helm.sh/helm/v3/pkg/chart"
@@ -26,6 +27,16 @@ func Read(path string) (*chart.Chart, error) {
 	return chart, nil
 }
 
+// Load reads a chart from a directory or an archive, without the logging of Read
+func Load(path string) (*chart.Chart, error) {
+	return loader.Load(path)
+}
+
+// LoadArchive reads a packaged chart, e.g. an uploaded .tgz
+func LoadArchive(r io.Reader) (*chart.Chart, error) {
+	return loader.LoadArchive(r)
+}
+
 func CPEs(chart *chart.Chart) []string {
 	list := chart.Metadata.Annotations[cpeKey]
 
@@ -36,8 +47,9 @@ func CPEs(chart *chart.Chart) []string {
 		if len(parts) < 3 {
 			continue
 		}
-		if strings.Contains(parts[0], "cpe") {
-			cpes = append(cpes, strings.Join(parts[1:], ":"))
+		cpe := strings.Trim(strings.Join(parts[1:], ":"), ` "'`)
+		if strings.Contains(parts[0], "cpe") && cpe != "" {
+			cpes = append(cpes, cpe)
 		}
 
 	}
@@ -50,12 +62,14 @@ func Images(chart *chart.Chart) []string {
 	images := make([]string, 0)
 	scanner := bufio.NewScanner(strings.NewReader(list))
 	for scanner.Scan() {
-		parts := strings.Split(scanner.Text(), ":")
-		if len(parts) < 3 {
+		// - image: registry:5000/app:1.0, where only the first : ends the key
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) < 2 {
 			continue
 		}
-		if strings.Contains(parts[0], "image") {
-			images = append(images, strings.Trim(parts[1], " ")+":"+parts[2])
+		image := strings.Trim(parts[1], ` "'`)
+		if strings.Contains(parts[0], "image") && image != "" {
+			images = append(images, image)
 		}
 
 	}
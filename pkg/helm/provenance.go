@@ -0,0 +1,70 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+// Provenance statuses
+const (
+	// ProvenanceVerified is a chart archive whose .prov file the keyring verifies
+	ProvenanceVerified = "verified"
+	// ProvenanceUnsigned is a chart without a .prov file, e.g. a directory
+	ProvenanceUnsigned = "unsigned"
+)
+
+// Provenance is what verifying a chart against its .prov file found
+type Provenance struct {
+	Status string
+	// Signer is the identity of the key that signed the .prov file
+	Signer string
+	// Fingerprint is the fingerprint of that key, in hex
+	Fingerprint string
+	// Files are the hashes the .prov file vouches for, e.g. of the archive
+	Files map[string]string
+}
+
+// DefaultKeyring is the keyring helm verifies charts with
+func DefaultKeyring() string {
+	if home := os.Getenv("GNUPGHOME"); home != "" {
+		return filepath.Join(home, "pubring.gpg")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gnupg", "pubring.gpg")
+}
+
+// VerifyProvenance checks a chart archive against the .prov file next to it
+// with the keys of the keyring, as helm verify does. A chart without a .prov
+// file is unsigned; one whose .prov file does not verify is an error.
+func VerifyProvenance(path, keyring string) (*Provenance, error) {
+	provFile := path + ".prov"
+	if _, err := os.Stat(provFile); os.IsNotExist(err) {
+		return &Provenance{Status: ProvenanceUnsigned}, nil
+	}
+	signatory, err := provenance.NewFromKeyring(keyring, "")
+	if err != nil {
+		return nil, fmt.Errorf("loading the keyring %v: %w", keyring, err)
+	}
+	v, err := signatory.Verify(path, provFile)
+	if err != nil {
+		return nil, fmt.Errorf("verifying %v: %w", provFile, err)
+	}
+	p := &Provenance{
+		Status:      ProvenanceVerified,
+		Fingerprint: fmt.Sprintf("%X", v.SignedBy.PrimaryKey.Fingerprint),
+		Files:       map[string]string{v.FileName: v.FileHash},
+	}
+	identities := make([]string, 0, len(v.SignedBy.Identities))
+	for name := range v.SignedBy.Identities {
+		identities = append(identities, name)
+	}
+	sort.Strings(identities)
+	if len(identities) > 0 {
+		p.Signer = identities[0]
+	}
+	return p, nil
+}
@@ -0,0 +1,80 @@
+package helm_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+)
+
+// testdata/demo-0.1.0.tgz is testdata/demo packaged, and its .prov file is
+// signed with helm's test key, whose public key is testdata/pubring.gpg
+const (
+	keyring     = "testdata/pubring.gpg"
+	testSigner  = "Helm Testing (This key should only be used for testing. DO NOT TRUST.) <helm-testing@helm.sh>"
+	fingerprint = "5E615389B53CA37F0EE60BD3843BBF981FC18762"
+	archiveHash = "sha256:d04d6f97558fb16c2d13d0e7ca528baf71b3bb4f82d7ba80a28dcc82ea72554d"
+)
+
+func TestVerifyProvenance(t *testing.T) {
+	p, err := helm.VerifyProvenance("testdata/demo-0.1.0.tgz", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &helm.Provenance{
+		Status:      helm.ProvenanceVerified,
+		Signer:      testSigner,
+		Fingerprint: fingerprint,
+		Files:       map[string]string{"demo-0.1.0.tgz": archiveHash},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("provenance %+v, want %+v", p, want)
+	}
+}
+
+func TestVerifyProvenanceUnsigned(t *testing.T) {
+	p, err := helm.VerifyProvenance("testdata/demo", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != helm.ProvenanceUnsigned || p.Signer != "" || len(p.Files) != 0 {
+		t.Errorf("provenance %+v, want unsigned", p)
+	}
+}
+
+func TestVerifyProvenanceErrors(t *testing.T) {
+	archive, err := ioutil.ReadFile("testdata/demo-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov, err := ioutil.ReadFile("testdata/demo-0.1.0.tgz.prov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	tampered := filepath.Join(dir, "demo-0.1.0.tgz")
+	archive[len(archive)-1] ^= 0xff
+	if err := ioutil.WriteFile(tampered, archive, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(tampered+".prov", prov, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := helm.VerifyProvenance(tampered, keyring); err == nil {
+		t.Error("verified a tampered archive")
+	}
+
+	// the .prov file of an archive signed by a key not in the keyring
+	emptyKeyring := filepath.Join(dir, "empty.gpg")
+	if err := ioutil.WriteFile(emptyKeyring, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := helm.VerifyProvenance("testdata/demo-0.1.0.tgz", emptyKeyring); err == nil {
+		t.Error("verified an archive without its key")
+	}
+	if _, err := helm.VerifyProvenance("testdata/demo-0.1.0.tgz", filepath.Join(dir, "missing.gpg")); err == nil {
+		t.Error("verified an archive without a keyring")
+	}
+}
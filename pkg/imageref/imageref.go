@@ -0,0 +1,151 @@
+package imageref
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Defaults of references that leave parts out, as docker fills them in
+const (
+	DefaultRegistry  = "docker.io"
+	DefaultNamespace = "library"
+	DefaultTag       = "latest"
+)
+
+// Reference is an image reference with the defaults filled in, so nginx:1.21
+// and docker.io/library/nginx:1.21 are the same
+type Reference struct {
+	// Original is the reference as it was written, e.g. in a chart
+	Original string
+	// Registry is the registry host, with its port if it has one
+	Registry string
+	// Repository is the repository in the registry, e.g. library/nginx
+	Repository string
+	// Tag is the tag, empty for references written with only a digest
+	Tag string
+	// Digest is the digest of the manifest, sha256:<hex>, once resolved or
+	// when written with one
+	Digest string
+	// Platform is the platform of an image picked from an image index,
+	// linux/arm64/v8; the digest is then of the platform's manifest
+	Platform string
+}
+
+// Parse normalizes an image reference: a first part without a . or : that
+// is not localhost is not a registry, so the image is on docker.io, and
+// docker.io images without a namespace are in library
+func Parse(s string) (Reference, error) {
+	ref := Reference{Original: s}
+	rest := strings.TrimSpace(s)
+	if i := strings.Index(rest, "@"); i >= 0 {
+		ref.Digest = rest[i+1:]
+		rest = rest[:i]
+	}
+	// a tag is after the last :, if no / follows it; a : before a / is a port
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.Contains(rest[i+1:], "/") {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+	}
+	ref.Registry = DefaultRegistry
+	if i := strings.Index(rest, "/"); i >= 0 {
+		first := rest[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry = first
+			rest = rest[i+1:]
+		}
+	}
+	if rest == "" {
+		return Reference{}, fmt.Errorf("image reference %q has no repository", s)
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = DefaultRegistry
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(rest, "/") {
+		rest = DefaultNamespace + "/" + rest
+	}
+	ref.Repository = rest
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+	if _, err := name.ParseReference(ref.String()); err != nil {
+		return Reference{}, fmt.Errorf("image reference %q: %w", s, err)
+	}
+	return ref, nil
+}
+
+// Name is the image without tag or digest, docker.io/library/nginx
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// Tagged is the image with its tag, or its digest when it has no tag,
+// docker.io/library/nginx:1.21
+func (r Reference) Tagged() string {
+	if r.Tag == "" {
+		return r.Name() + "@" + r.Digest
+	}
+	return r.Name() + ":" + r.Tag
+}
+
+// String is the image with its tag and digest, as far as they are known,
+// docker.io/library/nginx:1.21@sha256:<hex>
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Pinned is the image by digest, docker.io/library/nginx@sha256:<hex>, or
+// Tagged when the digest is not known
+func (r Reference) Pinned() string {
+	if r.Digest == "" {
+		return r.Tagged()
+	}
+	return r.Name() + "@" + r.Digest
+}
+
+var invalidIDChars = regexp.MustCompile(`[^A-Za-z0-9.\-]`)
+
+// Key tells images apart, as Tagged does, and the platforms of an image
+// index, docker.io/library/nginx:1.21#linux/arm64
+func (r Reference) Key() string {
+	if r.Platform == "" {
+		return r.Tagged()
+	}
+	return r.Tagged() + "#" + r.Platform
+}
+
+// ID is the SPDX element ID, and CycloneDX bom-ref, of the image,
+// image-docker.io-library-nginx-1.21, or image-docker.io-library-nginx-1.21-linux-arm64
+// for a platform
+func (r Reference) ID() string {
+	return "image-" + invalidIDChars.ReplaceAllString(r.Key(), "-")
+}
+
+// PURL is the OCI package URL of the image,
+// pkg:oci/nginx@sha256:<hex>?repository_url=docker.io/library/nginx&tag=1.21,
+// with an arch qualifier for a platform; without a digest it has no version
+func (r Reference) PURL() string {
+	parts := strings.Split(r.Repository, "/")
+	purl := "pkg:oci/" + strings.ToLower(parts[len(parts)-1])
+	if r.Digest != "" {
+		purl += "@" + r.Digest
+	}
+	purl += "?"
+	if platform := strings.Split(r.Platform, "/"); len(platform) > 1 {
+		purl += "arch=" + platform[1] + "&"
+	}
+	purl += "repository_url=" + r.Name()
+	if r.Tag != "" {
+		purl += "&tag=" + r.Tag
+	}
+	return purl
+}
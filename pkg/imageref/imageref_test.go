@@ -0,0 +1,247 @@
+package imageref_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+)
+
+// testdata/layout is an OCI image layout of nginx:1.21, a single image named
+// the way skopeo and crane name them, and redis:6.2, an image index of
+// linux/amd64 and linux/arm64/v8 named the way containerd does
+const (
+	layoutDir   = "testdata/layout"
+	nginxDigest = "sha256:58b18faeef0e1a1f4c37b906d417e427ecfc775c7a58bcd9bd42100362e7b9f1"
+	redisDigest = "sha256:cccd404742aad4d3247170712fbd4c903691d4facd675cc7dfbc6814e1ce176f"
+	amd64Digest = "sha256:673de5d3943b722922c8dd729d12cc89c9d95b0ef3fd5540052f613dbae84a5f"
+	arm64Digest = "sha256:3678d452af231b1d97ffdbf74f6a29d5c4a403f6617b042c7c8dc61477ce8e53"
+)
+
+func parse(t *testing.T, s string) imageref.Reference {
+	t.Helper()
+	ref, err := imageref.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		ref                            string
+		registry, repository, tag, dig string
+	}{
+		{"nginx", "docker.io", "library/nginx", "latest", ""},
+		{"nginx:1.21", "docker.io", "library/nginx", "1.21", ""},
+		{"library/nginx", "docker.io", "library/nginx", "latest", ""},
+		{"bitnami/redis:6.2", "docker.io", "bitnami/redis", "6.2", ""},
+		{"docker.io/nginx:1.21", "docker.io", "library/nginx", "1.21", ""},
+		{"index.docker.io/library/nginx:1.21", "docker.io", "library/nginx", "1.21", ""},
+		{"index.docker.io/nginx", "docker.io", "library/nginx", "latest", ""},
+		{"localhost/app", "localhost", "app", "latest", ""},
+		// a port is not a tag
+		{"localhost:5000/app", "localhost:5000", "app", "latest", ""},
+		{"localhost:5000/team/app:1.0", "localhost:5000", "team/app", "1.0", ""},
+		{"registry1.dso.mil/ironbank/opensource/nginx/nginx:1.21.3", "registry1.dso.mil", "ironbank/opensource/nginx/nginx", "1.21.3", ""},
+		// a digest without a tag gets no tag
+		{"nginx@" + nginxDigest, "docker.io", "library/nginx", "", nginxDigest},
+		{"nginx:1.21@" + nginxDigest, "docker.io", "library/nginx", "1.21", nginxDigest},
+		{" nginx:1.21\n", "docker.io", "library/nginx", "1.21", ""},
+	}
+	for _, test := range tests {
+		ref := parse(t, test.ref)
+		got := []string{ref.Original, ref.Registry, ref.Repository, ref.Tag, ref.Digest}
+		want := []string{test.ref, test.registry, test.repository, test.tag, test.dig}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q parsed to %q, want %q", test.ref, got, want)
+		}
+	}
+
+	for _, s := range []string{"Nginx:1.21", "nginx@sha256:abc", "nginx:1.21:latest", "", ":1.21", "localhost:5000/"} {
+		if ref, err := imageref.Parse(s); err == nil {
+			t.Errorf("parsed %q to %+v, want an error", s, ref)
+		}
+	}
+}
+
+func TestReferenceForms(t *testing.T) {
+	tests := []struct {
+		ref                                imageref.Reference
+		name, tagged, str, pinned, key, id string
+	}{
+		{
+			ref:    parse(t, "nginx:1.21"),
+			name:   "docker.io/library/nginx",
+			tagged: "docker.io/library/nginx:1.21",
+			str:    "docker.io/library/nginx:1.21",
+			pinned: "docker.io/library/nginx:1.21",
+			key:    "docker.io/library/nginx:1.21",
+			id:     "image-docker.io-library-nginx-1.21",
+		},
+		{
+			ref:    parse(t, "nginx:1.21@"+nginxDigest),
+			name:   "docker.io/library/nginx",
+			tagged: "docker.io/library/nginx:1.21",
+			str:    "docker.io/library/nginx:1.21@" + nginxDigest,
+			pinned: "docker.io/library/nginx@" + nginxDigest,
+			key:    "docker.io/library/nginx:1.21",
+			id:     "image-docker.io-library-nginx-1.21",
+		},
+		{
+			ref:    parse(t, "localhost:5000/app@"+nginxDigest),
+			name:   "localhost:5000/app",
+			tagged: "localhost:5000/app@" + nginxDigest,
+			str:    "localhost:5000/app@" + nginxDigest,
+			pinned: "localhost:5000/app@" + nginxDigest,
+			key:    "localhost:5000/app@" + nginxDigest,
+			id:     "image-localhost-5000-app-" + "sha256-58b18faeef0e1a1f4c37b906d417e427ecfc775c7a58bcd9bd42100362e7b9f1",
+		},
+		{
+			ref:    imageref.Reference{Registry: "docker.io", Repository: "library/redis", Tag: "6.2", Digest: arm64Digest, Platform: "linux/arm64/v8"},
+			name:   "docker.io/library/redis",
+			tagged: "docker.io/library/redis:6.2",
+			str:    "docker.io/library/redis:6.2@" + arm64Digest,
+			pinned: "docker.io/library/redis@" + arm64Digest,
+			key:    "docker.io/library/redis:6.2#linux/arm64/v8",
+			id:     "image-docker.io-library-redis-6.2-linux-arm64-v8",
+		},
+	}
+	for _, test := range tests {
+		r := test.ref
+		got := []string{r.Name(), r.Tagged(), r.String(), r.Pinned(), r.Key(), r.ID()}
+		want := []string{test.name, test.tagged, test.str, test.pinned, test.key, test.id}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v has forms\n%q\nwant\n%q", r, got, want)
+		}
+	}
+}
+
+func TestPURL(t *testing.T) {
+	platform := parse(t, "redis:6.2")
+	platform.Digest, platform.Platform = arm64Digest, "linux/arm64/v8"
+	tests := []struct {
+		ref  imageref.Reference
+		want string
+	}{
+		{parse(t, "nginx:1.21"), "pkg:oci/nginx?repository_url=docker.io/library/nginx&tag=1.21"},
+		{parse(t, "nginx:1.21@"+nginxDigest), "pkg:oci/nginx@" + nginxDigest + "?repository_url=docker.io/library/nginx&tag=1.21"},
+		{parse(t, "localhost:5000/team/app@"+nginxDigest), "pkg:oci/app@" + nginxDigest + "?repository_url=localhost:5000/team/app"},
+		{platform, "pkg:oci/redis@" + arm64Digest + "?arch=arm64&repository_url=docker.io/library/redis&tag=6.2"},
+	}
+	for _, test := range tests {
+		if got := test.ref.PURL(); got != test.want {
+			t.Errorf("%v has purl %v, want %v", test.ref, got, test.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := &imageref.Resolver{Layout: layoutDir}
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		// by the name the layout has for the image, with defaults filled in
+		{"nginx:1.21", nginxDigest},
+		{"docker.io/library/nginx:1.21", nginxDigest},
+		{"redis:6.2", redisDigest},
+		// a digest is kept as it is
+		{"nginx:1.21@" + arm64Digest, arm64Digest},
+	}
+	for _, test := range tests {
+		ref, err := r.Resolve(context.Background(), parse(t, test.ref))
+		if err != nil {
+			t.Errorf("%v: %v", test.ref, err)
+			continue
+		}
+		if ref.Digest != test.want || ref.Original != test.ref {
+			t.Errorf("%v resolved to %+v, want digest %v", test.ref, ref, test.want)
+		}
+	}
+
+	// without a client, an image the layout doesn't have can't be resolved
+	if ref, err := r.Resolve(context.Background(), parse(t, "nginx:1.22")); err == nil {
+		t.Errorf("resolved nginx:1.22 to %+v, want an error", ref)
+	}
+	missing := &imageref.Resolver{Layout: "testdata/missing"}
+	if _, err := missing.Resolve(context.Background(), parse(t, "nginx:1.21")); err == nil {
+		t.Error("resolved nginx:1.21 with a layout that doesn't exist")
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	r := &imageref.Resolver{Layout: layoutDir}
+	redis, err := r.Resolve(context.Background(), parse(t, "redis:6.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	platforms := func(wanted ...string) ([]string, error) {
+		ps := make([]oci.Platform, 0, len(wanted))
+		for _, w := range wanted {
+			p, err := imageref.ParsePlatform(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ps = append(ps, p)
+		}
+		refs, err := r.Platforms(context.Background(), redis, ps)
+		out := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			if ref.Tag != "6.2" || ref.Original != "redis:6.2" {
+				t.Errorf("platform %+v, want it of redis:6.2", ref)
+			}
+			out = append(out, ref.Platform+" "+ref.Digest)
+		}
+		return out, err
+	}
+
+	tests := []struct {
+		wanted []string
+		want   []string
+	}{
+		{[]string{"linux/amd64"}, []string{"linux/amd64 " + amd64Digest}},
+		// without a variant every variant matches
+		{[]string{"linux/arm64"}, []string{"linux/arm64/v8 " + arm64Digest}},
+		{[]string{"linux/arm64/v8", "linux/amd64"}, []string{"linux/amd64 " + amd64Digest, "linux/arm64/v8 " + arm64Digest}},
+		{[]string{"linux/arm64/v7", "linux/amd64"}, []string{"linux/amd64 " + amd64Digest}},
+	}
+	for _, test := range tests {
+		got, err := platforms(test.wanted...)
+		if err != nil {
+			t.Errorf("%v: %v", test.wanted, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("platforms %q of %v, want %q", got, test.wanted, test.want)
+		}
+	}
+	if got, err := platforms("linux/s390x"); err == nil {
+		t.Errorf("platforms %q of linux/s390x, which the index doesn't have, want an error", got)
+	}
+
+	// a single image has no platforms to pick
+	nginx, err := r.Resolve(context.Background(), parse(t, "nginx:1.21"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs, err := r.Platforms(context.Background(), nginx, []oci.Platform{{OS: "linux", Architecture: "amd64"}}); err != nil || refs != nil {
+		t.Errorf("platforms %+v (%v) of a single image, want none", refs, err)
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	for s, want := range map[string]oci.Platform{
+		"linux/amd64":    {OS: "linux", Architecture: "amd64"},
+		"linux/arm64/v8": {OS: "linux", Architecture: "arm64", Variant: "v8"},
+	} {
+		if p, err := imageref.ParsePlatform(s); err != nil || p != want || p.String() != s {
+			t.Errorf("%v parsed to %+v (%v), want %+v", s, p, err, want)
+		}
+	}
+	for _, s := range []string{"linux", "linux/", "/amd64", "linux/arm/v7/extra"} {
+		if p, err := imageref.ParsePlatform(s); err == nil {
+			t.Errorf("parsed %q to %+v, want an error", s, p)
+		}
+	}
+}
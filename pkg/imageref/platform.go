@@ -0,0 +1,119 @@
+package imageref
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+)
+
+// ParsePlatform reads a platform as docker writes it, os/architecture or
+// os/architecture/variant
+func ParsePlatform(s string) (oci.Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return oci.Platform{}, fmt.Errorf("platform %q is not os/architecture[/variant]", s)
+	}
+	p := oci.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// matches reports whether a platform is one of those wanted; a wanted
+// platform without a variant matches every variant
+func matches(p oci.Platform, wanted []oci.Platform) bool {
+	for _, w := range wanted {
+		if w.OS == p.OS && w.Architecture == p.Architecture && (w.Variant == "" || w.Variant == p.Variant) {
+			return true
+		}
+	}
+	return false
+}
+
+// Platforms are the images of the wanted platforms in the image index a
+// resolved reference points at, each with the digest of its manifest. They
+// are nil when the reference points at a single image.
+func (r *Resolver) Platforms(ctx context.Context, ref Reference, wanted []oci.Platform) ([]Reference, error) {
+	var entries []oci.Descriptor
+	var err error
+	if r.Layout != "" {
+		if entries, err = r.layoutIndex(ref); err != nil {
+			return nil, err
+		}
+	}
+	if entries == nil && r.Client != nil {
+		remote, err := r.Client.ParseReference(ref.Pinned())
+		if err != nil {
+			return nil, err
+		}
+		index, err := r.Client.Index(ctx, remote)
+		if err != nil {
+			return nil, fmt.Errorf("reading the index of %v: %w", ref.Original, err)
+		}
+		if index != nil {
+			entries = index.Manifests
+		}
+	}
+
+	var platforms []Reference
+	for _, d := range entries {
+		if d.Platform == nil || !matches(*d.Platform, wanted) {
+			continue
+		}
+		p := ref
+		p.Digest = d.Digest
+		p.Platform = d.Platform.String()
+		platforms = append(platforms, p)
+	}
+	if entries != nil && len(platforms) == 0 {
+		return nil, fmt.Errorf("%v has none of the platforms asked for", ref.Original)
+	}
+	return platforms, nil
+}
+
+// layoutIndex is the entries of the image index of the layout whose digest
+// is the reference's; it is nil when the layout does not have the reference
+// or it is a single image
+func (r *Resolver) layoutIndex(ref Reference) ([]oci.Descriptor, error) {
+	index, err := layout.ImageIndexFromPath(r.Layout)
+	if err != nil {
+		return nil, fmt.Errorf("reading the layout %v: %w", r.Layout, err)
+	}
+	m, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading the layout %v: %w", r.Layout, err)
+	}
+	for _, d := range m.Manifests {
+		if d.Digest.String() != ref.Digest || !d.MediaType.IsIndex() {
+			continue
+		}
+		child, err := index.ImageIndex(d.Digest)
+		if err != nil {
+			return nil, err
+		}
+		cm, err := child.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+		return descriptors(cm.Manifests), nil
+	}
+	return nil, nil
+}
+
+func descriptors(manifests []v1.Descriptor) []oci.Descriptor {
+	ds := make([]oci.Descriptor, 0, len(manifests))
+	for _, m := range manifests {
+		d := oci.Descriptor{MediaType: string(m.MediaType), Digest: m.Digest.String(), Size: m.Size}
+		if m.Platform != nil {
+			d.Platform = &oci.Platform{OS: m.Platform.OS, Architecture: m.Platform.Architecture, Variant: m.Platform.Variant}
+		}
+		ds = append(ds, d)
+	}
+	return ds
+}
@@ -0,0 +1,81 @@
+package imageref
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+)
+
+// Annotations naming the images of an OCI image layout, as written by
+// skopeo, crane and containerd
+const (
+	annotationRefName       = "org.opencontainers.image.ref.name"
+	annotationContainerdRef = "io.containerd.image.name"
+)
+
+// Resolver finds the digests of the manifests image tags point at
+type Resolver struct {
+	// Layout is the directory of an OCI image layout to look images up in
+	// before asking their registries
+	Layout string
+	// Client asks registries; without one only the layout is used
+	Client *oci.Client
+}
+
+// Resolve fills in the digest of a reference that has none. A reference
+// found in neither the layout nor its registry is an error.
+func (r *Resolver) Resolve(ctx context.Context, ref Reference) (Reference, error) {
+	if ref.Digest != "" {
+		return ref, nil
+	}
+	if r.Layout != "" {
+		digest, err := r.layoutDigest(ref)
+		if err != nil {
+			return ref, err
+		}
+		if digest != "" {
+			ref.Digest = digest
+			return ref, nil
+		}
+	}
+	if r.Client == nil {
+		return ref, fmt.Errorf("%v is not in the layout %v", ref.Original, r.Layout)
+	}
+	remote, err := r.Client.ParseReference(ref.Tagged())
+	if err != nil {
+		return ref, err
+	}
+	digest, err := r.Client.Digest(ctx, remote)
+	if err != nil {
+		return ref, fmt.Errorf("resolving %v: %w", ref.Original, err)
+	}
+	ref.Digest = digest.String()
+	return ref, nil
+}
+
+// layoutDigest is the digest of the manifest of the layout whose name is the
+// reference, with or without defaults, or its tag; it is empty when there is
+// none
+func (r *Resolver) layoutDigest(ref Reference) (string, error) {
+	index, err := layout.ImageIndexFromPath(r.Layout)
+	if err != nil {
+		return "", fmt.Errorf("reading the layout %v: %w", r.Layout, err)
+	}
+	m, err := index.IndexManifest()
+	if err != nil {
+		return "", fmt.Errorf("reading the layout %v: %w", r.Layout, err)
+	}
+	for _, d := range m.Manifests {
+		for _, key := range []string{annotationRefName, annotationContainerdRef} {
+			switch d.Annotations[key] {
+			case "":
+			case ref.Original, ref.Tagged(), ref.Tag:
+				return d.Digest.String(), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,242 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anchore/packageurl-go"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/license"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Match is a component found through an index
+type Match struct {
+	Chart        string   `json:"chart"`
+	ChartVersion string   `json:"chartVersion"`
+	Value        string   `json:"value"`
+	Ref          string   `json:"ref"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version,omitempty"`
+	PURL         string   `json:"purl,omitempty"`
+	Images       []string `json:"images,omitempty"`
+}
+
+// indexed returns the values of each field for a component
+func indexed(c sbom.Component) map[string][]string {
+	values := map[string][]string{
+		Name: {c.Name},
+		CPE:  c.CPEs,
+	}
+	if c.PURL != "" {
+		values[PURL] = []string{c.PURL}
+	}
+	if c.Digest != "" {
+		values[Digest] = []string{c.Digest}
+	}
+	for _, l := range c.Licenses {
+		e, err := license.Parse(l)
+		if err != nil {
+			values[License] = append(values[License], l)
+			continue
+		}
+		values[License] = append(values[License], e.IDs()...)
+	}
+	return values
+}
+
+func indexKey(value, entryKey, ref string) []byte {
+	return []byte(strings.ToLower(value) + sep + entryKey + sep + ref)
+}
+
+// identity is what a package is tracked by across chart versions: its PURL
+// without version or qualifiers, or its name when it has no PURL
+func identity(c sbom.Component) string {
+	p, err := packageurl.FromString(c.PURL)
+	if err != nil {
+		return c.Name
+	}
+	return packageurl.NewPackageURL(p.Type, p.Namespace, p.Name, "", nil, "").ToString()
+}
+
+type historyValue struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// historyKey orders a chart's history by package, then by when the chart
+// version was added. It ends in the component's ref, as images can hold the
+// same package at different versions.
+func historyKey(e *Entry, c sbom.Component) ([]byte, error) {
+	added, err := time.Parse(time.RFC3339Nano, e.Added)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(e.Chart + sep + identity(c) + sep + added.UTC().Format(timeFormat) + sep + e.Version + sep + c.Ref), nil
+}
+
+func index(tx *bolt.Tx, e *Entry) error {
+	history := tx.Bucket(bucketHistory)
+	for _, c := range e.Document.Components {
+		m := Match{
+			Chart:        e.Chart,
+			ChartVersion: e.Version,
+			Ref:          c.Ref,
+			Name:         c.Name,
+			Version:      c.Version,
+			PURL:         c.PURL,
+			Images:       c.Images,
+		}
+		for field, values := range indexed(c) {
+			b := tx.Bucket(indexBucket(field))
+			for _, v := range values {
+				m.Value = v
+				value, err := json.Marshal(m)
+				if err != nil {
+					return err
+				}
+				if err := b.Put(indexKey(v, e.Key, c.Ref), value); err != nil {
+					return err
+				}
+			}
+		}
+		if c.IsImage() {
+			continue
+		}
+		k, err := historyKey(e, c)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(historyValue{Name: c.Name, Version: c.Version})
+		if err != nil {
+			return err
+		}
+		if err := history.Put(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unindex(tx *bolt.Tx, e *Entry) error {
+	history := tx.Bucket(bucketHistory)
+	for _, c := range e.Document.Components {
+		for field, values := range indexed(c) {
+			b := tx.Bucket(indexBucket(field))
+			for _, v := range values {
+				if err := b.Delete(indexKey(v, e.Key, c.Ref)); err != nil {
+					return err
+				}
+			}
+		}
+		if c.IsImage() {
+			continue
+		}
+		k, err := historyKey(e, c)
+		if err != nil {
+			return err
+		}
+		if err := history.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search looks a value up in the index of a field. Values are matched case
+// insensitively and exactly, or as a prefix when they end in *, e.g.
+// pkg:deb/debian/openssl@* or sha256:4a5b*.
+func (s *Store) Search(field, value string) ([]Match, error) {
+	if !isField(field) {
+		return nil, fmt.Errorf("cannot search on %v, only on %v", field, strings.Join(Fields, ", "))
+	}
+	prefix := []byte(strings.ToLower(value) + sep)
+	if strings.HasSuffix(value, "*") {
+		prefix = []byte(strings.ToLower(strings.TrimSuffix(value, "*")))
+	}
+	out := make([]Match, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(indexBucket(field)).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			m := Match{}
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("%q: %w", k, err)
+			}
+			out = append(out, m)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func isField(field string) bool {
+	for _, f := range Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Appearance is a package in one version of a chart
+type Appearance struct {
+	ChartVersion string `json:"chartVersion"`
+	Added        string `json:"added"`
+	Version      string `json:"version,omitempty"`
+}
+
+// Timeline is the history of a package in a chart, oldest first
+type Timeline struct {
+	Package     string       `json:"package"`
+	Name        string       `json:"name"`
+	FirstSeen   string       `json:"firstSeen"`
+	FirstIn     string       `json:"firstIn"`
+	Appearances []Appearance `json:"appearances"`
+}
+
+// History returns when the packages of a chart were seen, in the order the
+// chart versions were added. pkg narrows it down to packages whose name or
+// PURL (without version) is pkg, or starts with it when it ends in *.
+func (s *Store) History(chart, pkg string) ([]Timeline, error) {
+	out := make([]Timeline, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(chart + sep)
+		c := tx.Bucket(bucketHistory).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			parts := strings.Split(string(k), sep)
+			if len(parts) != 5 {
+				return fmt.Errorf("malformed history key %q", k)
+			}
+			hv := historyValue{}
+			if err := json.Unmarshal(v, &hv); err != nil {
+				return fmt.Errorf("%q: %w", k, err)
+			}
+			if pkg != "" && !matches(pkg, parts[1]) && !matches(pkg, hv.Name) {
+				continue
+			}
+			added, err := time.Parse(timeFormat, parts[2])
+			if err != nil {
+				return err
+			}
+			a := Appearance{ChartVersion: parts[3], Added: added.Format(time.RFC3339Nano), Version: hv.Version}
+			if len(out) == 0 || out[len(out)-1].Package != parts[1] {
+				out = append(out, Timeline{Package: parts[1], Name: hv.Name, FirstSeen: a.Added, FirstIn: a.ChartVersion})
+			}
+			t := &out[len(out)-1]
+			t.Appearances = append(t.Appearances, a)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func matches(pattern, s string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(strings.ToLower(s), strings.ToLower(strings.TrimSuffix(pattern, "*")))
+	}
+	return strings.EqualFold(pattern, s)
+}
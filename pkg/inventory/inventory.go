@@ -0,0 +1,250 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Buckets of the store. sboms holds an Entry per chart version and raw the
+// SBOM it was made from; the idx- buckets map a lowercased value to the
+// components that have it; history records when each package was seen.
+var (
+	bucketSBOMs   = []byte("sboms")
+	bucketRaw     = []byte("raw")
+	bucketHistory = []byte("history")
+)
+
+// Fields that can be searched
+const (
+	PURL    = "purl"
+	CPE     = "cpe"
+	License = "license"
+	Digest  = "digest"
+	Name    = "name"
+)
+
+// Fields are the indexed fields, in the order they are listed
+var Fields = []string{PURL, CPE, License, Digest, Name}
+
+func indexBucket(field string) []byte {
+	return []byte("idx-" + field)
+}
+
+// timeFormat sorts lexically in time order, so history keys do too
+const timeFormat = "20060102T150405.000000000Z"
+
+// sep separates the parts of keys; it cannot appear in names, PURLs or CPEs
+const sep = "\x00"
+
+// Store is an inventory of SBOMs in a bbolt database
+type Store struct {
+	db *bolt.DB
+}
+
+// Entry is an SBOM in the store, keyed by chart name and version
+type Entry struct {
+	Key     string `json:"key"`
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	Added   string `json:"added"`
+	Format  string `json:"format"`
+	Source  string `json:"source,omitempty"`
+	// Digests are the image digests by image name, for the images the SBOM
+	// records a digest for
+	Digests  map[string]string `json:"digests,omitempty"`
+	Document *sbom.Document    `json:"document,omitempty"`
+}
+
+// Key is the key of a chart version in the store
+func Key(chart, version string) string {
+	return chart + "@" + version
+}
+
+// Open opens the store at path, creating it if needed. bbolt locks the file,
+// so Open waits a little for another process to close it before giving up.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening inventory %v: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		buckets := [][]byte{bucketSBOMs, bucketRaw, bucketHistory}
+		for _, f := range Fields {
+			buckets = append(buckets, indexBucket(f))
+		}
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores the SBOM for a chart version, replacing any SBOM already stored
+// for it. chart and version default to the name and version in the SBOM.
+func (s *Store) Add(raw []byte, chart, version, source string) (*Entry, error) {
+	doc, err := sbom.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if chart == "" {
+		chart = doc.Name
+	}
+	if version == "" {
+		version = doc.Version
+	}
+	switch {
+	case chart == "":
+		return nil, fmt.Errorf("the SBOM has no chart name, give one")
+	case version == "":
+		return nil, fmt.Errorf("the SBOM for %v has no chart version, give one", chart)
+	case strings.Contains(chart+version, sep):
+		return nil, fmt.Errorf("chart name and version cannot contain NUL")
+	}
+	e := &Entry{
+		Key:      Key(chart, version),
+		Chart:    chart,
+		Version:  version,
+		Added:    time.Now().UTC().Format(time.RFC3339Nano),
+		Format:   doc.Format,
+		Source:   source,
+		Digests:  make(map[string]string),
+		Document: doc,
+	}
+	for _, c := range doc.Components {
+		if c.IsImage() && c.Digest != "" {
+			e.Digests[c.Name] = c.Digest
+		}
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if err := remove(tx, e.Key); err != nil && err != errNotFound {
+			return err
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketSBOMs).Put([]byte(e.Key), b); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketRaw).Put([]byte(e.Key), raw); err != nil {
+			return err
+		}
+		return index(tx, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+var errNotFound = fmt.Errorf("not found")
+
+// NotFound is the error for a chart version that is not in the store
+func NotFound(chart, version string) error {
+	return fmt.Errorf("%v is not in the inventory: %w", Key(chart, version), errNotFound)
+}
+
+// List returns the stored SBOMs, without their documents, for one chart or
+// for all charts if chart is empty
+func (s *Store) List(chart string) ([]Entry, error) {
+	out := make([]Entry, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSBOMs).ForEach(func(k, v []byte) error {
+			e := Entry{}
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			if chart != "" && e.Chart != chart {
+				return nil
+			}
+			e.Document = nil
+			out = append(out, e)
+			return nil
+		})
+	})
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Chart != out[j].Chart {
+			return out[i].Chart < out[j].Chart
+		}
+		return out[i].Added < out[j].Added
+	})
+	return out, err
+}
+
+// Get returns the entry for a chart version
+func (s *Store) Get(chart, version string) (*Entry, error) {
+	e := &Entry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketSBOMs).Get([]byte(Key(chart, version)))
+		if v == nil {
+			return NotFound(chart, version)
+		}
+		return json.Unmarshal(v, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Raw returns the SBOM a chart version was added from, as it was given
+func (s *Store) Raw(chart, version string) ([]byte, error) {
+	var out []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketRaw).Get([]byte(Key(chart, version)))
+		if v == nil {
+			return NotFound(chart, version)
+		}
+		out = append(out, v...)
+		return nil
+	})
+	return out, err
+}
+
+// Remove deletes a chart version, its index entries and its history
+func (s *Store) Remove(chart, version string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := remove(tx, Key(chart, version))
+		if err == errNotFound {
+			return NotFound(chart, version)
+		}
+		return err
+	})
+}
+
+func remove(tx *bolt.Tx, key string) error {
+	v := tx.Bucket(bucketSBOMs).Get([]byte(key))
+	if v == nil {
+		return errNotFound
+	}
+	e := &Entry{}
+	if err := json.Unmarshal(v, e); err != nil {
+		return err
+	}
+	if err := unindex(tx, e); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketRaw).Delete([]byte(key)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketSBOMs).Delete([]byte(key))
+}
@@ -0,0 +1,280 @@
+package inventory_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/inventory"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+const busybox = "busybox@sha256:3b3128d9df6bbbcc92e2358e596c9fbd722a437a62bafbc51607970e9e3b8869"
+
+func openStore(t *testing.T) *inventory.Store {
+	t.Helper()
+	s, err := inventory.Open(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// chartSBOM is the SBOM of the test chart at a version in a format, with
+// openssl at the given version in each image, and a busybox image pinned by
+// digest
+func chartSBOM(t *testing.T, format, chartVersion, nginxOpenSSL, redisOpenSSL string) []byte {
+	t.Helper()
+	c := generatortest.Chart()
+	c.Metadata.Version = chartVersion
+	c.Metadata.Annotations["helm.sh/images"] += "- image: " + busybox + "\n"
+	s := generatortest.Scanner()
+	for image, version := range map[string]string{"nginx:1.21": nginxOpenSSL, "redis:6.2": redisOpenSSL} {
+		packages := append([]sbom.Component(nil), s.Packages[image]...)
+		packages[0].Version = version
+		packages[0].PURL = "pkg:deb/debian/openssl@" + version
+		s.Packages[image] = packages
+	}
+	s.Packages[busybox] = []sbom.Component{{Ref: "binary-busybox", Name: "busybox", Version: "1.34.1", Licenses: []string{"GPL-2.0-only"}}}
+	res := generatortest.Generate(t, c, generator.WithScanner(s))
+	return res.Encoded[format]
+}
+
+func add(t *testing.T, s *inventory.Store, raw []byte) *inventory.Entry {
+	t.Helper()
+	e, err := s.Add(raw, "", "", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// found are the chart version, ref and matched value of each match
+func found(t *testing.T, s *inventory.Store, field, value string) []string {
+	t.Helper()
+	matches, err := s.Search(field, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]string, 0)
+	for _, m := range matches {
+		out = append(out, m.ChartVersion+" "+m.Ref+" "+m.Value)
+	}
+	return out
+}
+
+func TestAdd(t *testing.T) {
+	s := openStore(t)
+	raw := chartSBOM(t, generator.FormatCycloneDXJSON, "0.1.0", "1.1.1k", "1.1.1k")
+	e := add(t, s, raw)
+	if e.Key != "demo@0.1.0" || e.Chart != "demo" || e.Version != "0.1.0" || e.Format != sbom.FormatCycloneDXJSON || e.Source != "test" {
+		t.Errorf("entry %+v, want demo 0.1.0 from the SBOM", e)
+	}
+	if want := map[string]string{"docker.io/library/busybox": "sha256:3b3128d9df6bbbcc92e2358e596c9fbd722a437a62bafbc51607970e9e3b8869"}; !reflect.DeepEqual(e.Digests, want) {
+		t.Errorf("digests %v, want %v", e.Digests, want)
+	}
+
+	got, err := s.Get("demo", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Added != e.Added || len(got.Document.Components) != len(e.Document.Components) {
+		t.Errorf("got %+v, want the entry added", got)
+	}
+	stored, err := s.Raw("demo", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != string(raw) {
+		t.Error("raw SBOM differs from the one added")
+	}
+
+	// the chart and version given override the SBOM's
+	if _, err := s.Add(raw, "other", "1.0.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := s.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "demo@0.1.0" || entries[1].Key != "other@1.0.0" || entries[0].Document != nil {
+		t.Errorf("listed %+v, want demo@0.1.0 and other@1.0.0 without documents", entries)
+	}
+	if entries, _ := s.List("other"); len(entries) != 1 {
+		t.Errorf("listed %+v for other, want other@1.0.0", entries)
+	}
+
+	if _, err := s.Add([]byte("not an sbom"), "", "", ""); err == nil {
+		t.Error("added garbage, want an error")
+	}
+	if _, err := s.Add(raw, "bad\x00chart", "", ""); err == nil {
+		t.Error("added a chart name with a NUL, want an error")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := openStore(t)
+	// SPDX has no chart version, but unlike CycloneDX has the licenses
+	if _, err := s.Add(chartSBOM(t, generator.FormatSPDX, "0.1.0", "1.1.1k", "1.1.1n"), "", "0.1.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		field string
+		value string
+		want  []string
+	}{
+		{inventory.PURL, "pkg:deb/debian/openssl@1.1.1k", []string{"0.1.0 deb-openssl-1.1.1k pkg:deb/debian/openssl@1.1.1k"}},
+		{inventory.PURL, "pkg:deb/debian/openssl@*", []string{
+			"0.1.0 deb-openssl-1.1.1k pkg:deb/debian/openssl@1.1.1k",
+			"0.1.0 deb-openssl-1.1.1n pkg:deb/debian/openssl@1.1.1n",
+		}},
+		// without the * it is an exact match, not a prefix
+		{inventory.PURL, "pkg:deb/debian/openssl", []string{}},
+		{inventory.Name, "OpenSSL", []string{
+			"0.1.0 deb-openssl-1.1.1k openssl",
+			"0.1.0 deb-openssl-1.1.1n openssl",
+		}},
+		{inventory.Name, "red*", []string{"0.1.0 deb-redis-6.2.6 redis"}},
+		{inventory.License, "gpl-2.0-only", []string{"0.1.0 binary-busybox-1.34.1 GPL-2.0-only"}},
+		{inventory.Digest, "sha256:3b3128d9*", []string{"0.1.0 image-docker.io-library-busybox-sha256-3b3128d9df6bbbcc92e2358e596c9fbd722a437a62bafbc51607970e9e3b8869 sha256:3b3128d9df6bbbcc92e2358e596c9fbd722a437a62bafbc51607970e9e3b8869"}},
+		{inventory.CPE, "cpe:2.3:a:demo:*", []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.field+" "+test.value, func(t *testing.T) {
+			if got := found(t, s, test.field, test.value); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("found %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	matches, err := s.Search(inventory.PURL, "pkg:deb/debian/redis@6.2.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Chart != "demo" || matches[0].Version != "6.2.6" || !reflect.DeepEqual(matches[0].Images, []string{"docker.io/library/redis"}) {
+		t.Errorf("matched %+v, want redis 6.2.6 in the redis image", matches)
+	}
+	if _, err := s.Search("supplier", "x"); err == nil {
+		t.Error("searched an unindexed field, want an error")
+	}
+}
+
+func TestAddReplaces(t *testing.T) {
+	s := openStore(t)
+	add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.1.0", "1.1.1k", "1.1.1k"))
+	add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.1.0", "1.1.1n", "1.1.1n"))
+	if got := found(t, s, inventory.PURL, "pkg:deb/debian/openssl@1.1.1k"); len(got) != 0 {
+		t.Errorf("found %q after replacing it, want nothing", got)
+	}
+	if got := found(t, s, inventory.PURL, "pkg:deb/debian/openssl@1.1.1n"); len(got) != 1 {
+		t.Errorf("found %q, want the replacement", got)
+	}
+	timelines, err := s.History("demo", "openssl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timelines) != 1 || len(timelines[0].Appearances) != 1 || timelines[0].Appearances[0].Version != "1.1.1n" {
+		t.Errorf("history %+v, want only the replacement's openssl", timelines)
+	}
+	if entries, _ := s.List(""); len(entries) != 1 {
+		t.Errorf("listed %+v, want one entry", entries)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	s := openStore(t)
+	add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.1.0", "1.1.1k", "1.1.1n"))
+	add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.2.0", "1.1.1n", "1.1.1n"))
+	if err := s.Remove("demo", "0.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range inventory.Fields {
+		matches, err := s.Search(field, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range matches {
+			if m.ChartVersion != "0.2.0" {
+				t.Errorf("%v index still has %+v", field, m)
+			}
+		}
+	}
+	timelines, err := s.History("demo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tl := range timelines {
+		for _, a := range tl.Appearances {
+			if a.ChartVersion != "0.2.0" {
+				t.Errorf("history of %v still has %+v", tl.Package, a)
+			}
+		}
+	}
+	if _, err := s.Get("demo", "0.1.0"); err == nil || !strings.Contains(err.Error(), "not in the inventory") {
+		t.Errorf("got %v, want demo@0.1.0 to be gone", err)
+	}
+	if _, err := s.Raw("demo", "0.1.0"); err == nil {
+		t.Error("raw SBOM of demo@0.1.0 is still there")
+	}
+	if err := s.Remove("demo", "0.1.0"); err == nil {
+		t.Error("removed demo@0.1.0 twice, want an error")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	s := openStore(t)
+	first := add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.1.0", "1.1.1k", "1.1.1k"))
+	// the images disagree on openssl's version in 0.2.0
+	second := add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.2.0", "1.1.1n", "1.1.1k"))
+	timelines, err := s.History("demo", "pkg:deb/debian/openssl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inventory.Timeline{{
+		Package:   "pkg:deb/debian/openssl",
+		Name:      "openssl",
+		FirstSeen: first.Added,
+		FirstIn:   "0.1.0",
+		Appearances: []inventory.Appearance{
+			{ChartVersion: "0.1.0", Added: first.Added, Version: "1.1.1k"},
+			{ChartVersion: "0.2.0", Added: second.Added, Version: "1.1.1k"},
+			{ChartVersion: "0.2.0", Added: second.Added, Version: "1.1.1n"},
+		},
+	}}
+	if !reflect.DeepEqual(timelines, want) {
+		t.Errorf("history\n%+v\nwant\n%+v", timelines, want)
+	}
+
+	// the order is by package then by when the chart version was added, not
+	// by chart version
+	add(t, s, chartSBOM(t, generator.FormatCycloneDXJSON, "0.0.9", "1.0.2u", "1.0.2u"))
+	timelines, err = s.History("demo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := make([]string, 0)
+	for _, tl := range timelines {
+		packages = append(packages, tl.Package)
+	}
+	if want := []string{"busybox", "pkg:deb/debian/nginx", "pkg:deb/debian/openssl", "pkg:deb/debian/redis"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("history of %v, want %v", packages, want)
+	}
+	openssl := timelines[2].Appearances
+	if last := openssl[len(openssl)-1]; last.ChartVersion != "0.0.9" || last.Version != "1.0.2u" {
+		t.Errorf("last appearance %+v, want the latest added 0.0.9", last)
+	}
+
+	for pkg, want := range map[string]int{"OPENSSL": 1, "pkg:deb/*": 3, "nothing": 0} {
+		if timelines, _ := s.History("demo", pkg); len(timelines) != want {
+			t.Errorf("history of %v has %d packages, want %d", pkg, len(timelines), want)
+		}
+	}
+	// charts are matched whole, not as a prefix
+	if timelines, _ := s.History("dem", ""); len(timelines) != 0 {
+		t.Errorf("history of chart dem has %+v, want nothing", timelines)
+	}
+}
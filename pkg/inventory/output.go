@@ -0,0 +1,103 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
+
+func table(w io.Writer, header string, rows func(tw io.Writer)) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, header)
+	rows(tw)
+	return tw.Flush()
+}
+
+// WriteEntries renders the entries of ls as a table or json
+func WriteEntries(w io.Writer, entries []Entry, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, entries)
+	case "table":
+		return table(w, "CHART\tVERSION\tFORMAT\tIMAGES\tADDED\tSOURCE", func(tw io.Writer) {
+			for _, e := range entries {
+				fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", e.Chart, e.Version, e.Format, len(e.Digests), e.Added, e.Source)
+			}
+		})
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+// WriteEntry renders a stored SBOM as a table of its components, or json
+func WriteEntry(w io.Writer, e *Entry, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, e)
+	case "table":
+		fmt.Fprintf(w, "%v %v (%v, added %v)\n", e.Chart, e.Version, e.Format, e.Added)
+		images := make([]string, 0, len(e.Digests))
+		for image := range e.Digests {
+			images = append(images, image)
+		}
+		sort.Strings(images)
+		for _, image := range images {
+			fmt.Fprintf(w, "  %v %v\n", image, e.Digests[image])
+		}
+		fmt.Fprintln(w)
+		return table(w, "NAME\tVERSION\tTYPE\tPURL\tLICENSES", func(tw io.Writer) {
+			for _, c := range e.Document.Components {
+				fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", c.Name, c.Version, c.Type, c.PURL, strings.Join(c.Licenses, "; "))
+			}
+		})
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+// WriteMatches renders search results as a table or json
+func WriteMatches(w io.Writer, matches []Match, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, matches)
+	case "table":
+		err := table(w, "CHART\tVERSION\tNAME\tPACKAGE VERSION\tMATCHED\tIMAGES", func(tw io.Writer) {
+			for _, m := range matches {
+				fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", m.Chart, m.ChartVersion, m.Name, m.Version, m.Value, strings.Join(m.Images, ", "))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "\n%v matches\n", len(matches))
+		return err
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+// WriteHistory renders package timelines as a table or json
+func WriteHistory(w io.Writer, timelines []Timeline, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, timelines)
+	case "table":
+		return table(w, "PACKAGE\tFIRST SEEN\tFIRST IN\tVERSIONS", func(tw io.Writer) {
+			for _, t := range timelines {
+				versions := make([]string, 0, len(t.Appearances))
+				for _, a := range t.Appearances {
+					versions = append(versions, fmt.Sprintf("%v (chart %v)", a.Version, a.ChartVersion))
+				}
+				fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", t.Package, t.FirstSeen, t.FirstIn, strings.Join(versions, " → "))
+			}
+		})
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
@@ -0,0 +1,180 @@
+package license
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operators joining licenses in an SPDX license expression
+const (
+	And  = "AND"
+	Or   = "OR"
+	With = "WITH"
+)
+
+// idPattern is the allowed shape of a license or exception identifier (SPDX 2.2 appendix IV)
+var idPattern = regexp.MustCompile(`^(DocumentRef-[A-Za-z0-9.\-]+:)?(LicenseRef-)?[A-Za-z0-9.\-]+\+?$`)
+
+// Expression is a parsed SPDX license expression. A leaf holds a single license
+// ID (with an optional exception); otherwise Op joins the Args.
+type Expression struct {
+	Op        string
+	ID        string
+	Exception string
+	Args      []*Expression
+}
+
+// Parse parses an SPDX license expression such as
+// "(MIT OR Apache-2.0) AND GPL-2.0-or-later WITH Classpath-exception-2.0"
+func Parse(s string) (*Expression, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	e, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", s, err)
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", s, p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// IDs returns every license ID used in the expression, exceptions excluded
+func (e *Expression) IDs() []string {
+	if e.Op == "" {
+		return []string{e.ID}
+	}
+	ids := make([]string, 0)
+	for _, a := range e.Args {
+		ids = append(ids, a.IDs()...)
+	}
+	return ids
+}
+
+func (e *Expression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.ID + " " + With + " " + e.Exception
+		}
+		return e.ID
+	}
+	parts := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		if a.Op != "" {
+			parts[i] = "(" + a.String() + ")"
+		} else {
+			parts[i] = a.String()
+		}
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+func tokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) operator(op string) bool {
+	if strings.ToUpper(p.peek()) == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (*Expression, error) {
+	return p.binary(Or, p.and)
+}
+
+func (p *parser) and() (*Expression, error) {
+	return p.binary(And, p.with)
+}
+
+func (p *parser) binary(op string, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	args := []*Expression{first}
+	for p.operator(op) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, next)
+	}
+	if len(args) == 1 {
+		return first, nil
+	}
+	return &Expression{Op: op, Args: args}, nil
+}
+
+func (p *parser) with() (*Expression, error) {
+	e, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	if !p.operator(With) {
+		return e, nil
+	}
+	if e.Op != "" {
+		return nil, fmt.Errorf("%v must follow a single license", With)
+	}
+	exception := p.next()
+	if !idPattern.MatchString(exception) || isOperator(exception) {
+		return nil, fmt.Errorf("invalid exception %q", exception)
+	}
+	e.Exception = exception
+	return e, nil
+}
+
+func (p *parser) atom() (*Expression, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case t == "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return e, nil
+	case t == ")" || isOperator(t):
+		return nil, fmt.Errorf("unexpected %q", t)
+	case !idPattern.MatchString(t):
+		return nil, fmt.Errorf("invalid license identifier %q", t)
+	}
+	return &Expression{ID: t}, nil
+}
+
+func isOperator(t string) bool {
+	switch strings.ToUpper(t) {
+	case And, Or, With:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package license_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/license"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		ids        []string
+	}{
+		{"MIT", "MIT", []string{"MIT"}},
+		{"GPL-2.0+", "GPL-2.0+", []string{"GPL-2.0+"}},
+		{"LicenseRef-custom", "LicenseRef-custom", []string{"LicenseRef-custom"}},
+		{"DocumentRef-other:LicenseRef-custom", "DocumentRef-other:LicenseRef-custom", []string{"DocumentRef-other:LicenseRef-custom"}},
+		// AND binds tighter than OR
+		{"MIT OR Apache-2.0 AND BSD-3-Clause", "MIT OR (Apache-2.0 AND BSD-3-Clause)", []string{"MIT", "Apache-2.0", "BSD-3-Clause"}},
+		{"MIT AND Apache-2.0 OR BSD-3-Clause", "(MIT AND Apache-2.0) OR BSD-3-Clause", []string{"MIT", "Apache-2.0", "BSD-3-Clause"}},
+		// and parentheses tighter still
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", "(MIT OR Apache-2.0) AND BSD-3-Clause", []string{"MIT", "Apache-2.0", "BSD-3-Clause"}},
+		{"((MIT))", "MIT", []string{"MIT"}},
+		{"MIT OR Apache-2.0 OR ISC", "MIT OR Apache-2.0 OR ISC", []string{"MIT", "Apache-2.0", "ISC"}},
+		// WITH binds tightest and its exception isn't a license
+		{"GPL-2.0-or-later WITH Classpath-exception-2.0 OR MIT", "GPL-2.0-or-later WITH Classpath-exception-2.0 OR MIT", []string{"GPL-2.0-or-later", "MIT"}},
+		{"(GPL-2.0-only) WITH Classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}},
+		// operators are case insensitive
+		{"mit or apache-2.0", "mit OR apache-2.0", []string{"mit", "apache-2.0"}},
+	}
+	for _, tt := range tests {
+		e, err := license.Parse(tt.expression)
+		if err != nil {
+			t.Errorf("%q: %v", tt.expression, err)
+			continue
+		}
+		if e.String() != tt.want {
+			t.Errorf("%q parses to %q, want %q", tt.expression, e.String(), tt.want)
+		}
+		if !reflect.DeepEqual(e.IDs(), tt.ids) {
+			t.Errorf("%q has IDs %q, want %q", tt.expression, e.IDs(), tt.ids)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"":                                 "empty license expression",
+		"   ":                              "empty license expression",
+		"MIT OR":                           "unexpected end of expression",
+		"MIT AND":                          "unexpected end of expression",
+		"OR MIT":                           `unexpected "OR"`,
+		"MIT Apache-2.0":                   `unexpected "Apache-2.0"`,
+		"(MIT OR Apache-2.0":               "missing )",
+		"MIT)":                             `unexpected ")"`,
+		"()":                               `unexpected ")"`,
+		"(MIT OR GPL-2.0-only) WITH Foo":   "WITH must follow a single license",
+		"GPL-2.0-only WITH":                `invalid exception ""`,
+		"GPL-2.0-only WITH OR":             `invalid exception "OR"`,
+		"Apache 2.0":                       `unexpected "2.0"`,
+		"Apache_2.0":                       `invalid license identifier "Apache_2.0"`,
+		"MIT AND (Apache-2.0 OR)":          "unexpected \")\"",
+		"GPL-2.0-only WITH Classpath WITH": `unexpected "WITH"`,
+	}
+	for expression, want := range tests {
+		_, err := license.Parse(expression)
+		if err == nil {
+			t.Errorf("%q parsed, want an error", expression)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: %v, want %v", expression, err, want)
+		}
+	}
+}
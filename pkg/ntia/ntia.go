@@ -0,0 +1,257 @@
+package ntia
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// The NTIA minimum elements (https://www.ntia.doc.gov/report/2021/minimum-elements-software-bill-materials-sbom)
+const (
+	Supplier     = "supplier"
+	Name         = "component-name"
+	Version      = "version"
+	Identifier   = "unique-identifier"
+	Relationship = "dependency-relationship"
+	Author       = "author"
+	Timestamp    = "timestamp"
+)
+
+// componentElements are checked for every component, the rest once per document
+var componentElements = []string{Supplier, Name, Version, Identifier, Relationship}
+
+var documentElements = []string{Author, Timestamp}
+
+var fixes = map[string]string{
+	Supplier:     "set PackageSupplier (SPDX) or supplier (CycloneDX) to the organization that publishes the component",
+	Name:         "set PackageName (SPDX) or name (CycloneDX)",
+	Version:      "set PackageVersion (SPDX) or version (CycloneDX)",
+	Identifier:   "add a PURL or CPE: an ExternalRef (SPDX) or purl/cpe (CycloneDX)",
+	Relationship: "add a CONTAINS/DEPENDS_ON relationship (SPDX) or a dependencies entry (CycloneDX) tying it to its parent",
+	Author:       "add a Creator: Person or Organization (SPDX) or metadata.authors (CycloneDX)",
+	Timestamp:    "set Created (SPDX) or metadata.timestamp (CycloneDX)",
+}
+
+// Gap is a missing minimum element
+type Gap struct {
+	Element      string `json:"element"`
+	Component    string `json:"component,omitempty"`
+	SuggestedFix string `json:"suggestedFix"`
+}
+
+// ElementScore is how many of the checked items have an element
+type ElementScore struct {
+	Element string  `json:"element"`
+	Present int     `json:"present"`
+	Total   int     `json:"total"`
+	Score   float64 `json:"score"`
+}
+
+// ComponentScore is how many of the component elements a component has
+type ComponentScore struct {
+	Ref     string   `json:"ref"`
+	Name    string   `json:"name"`
+	Missing []string `json:"missing,omitempty"`
+	Score   float64  `json:"score"`
+}
+
+// Report is the result of checking a document against the minimum elements
+type Report struct {
+	Document   string           `json:"document"`
+	Score      float64          `json:"score"`
+	Elements   []ElementScore   `json:"elements"`
+	Components []ComponentScore `json:"components"`
+	Gaps       []Gap            `json:"gaps"`
+}
+
+// Check scores the document against the NTIA minimum elements. Every component
+// is checked for supplier, name, version, unique identifier and a dependency
+// relationship; the document is checked for an author and a timestamp.
+func Check(doc *sbom.Document) *Report {
+	r := &Report{
+		Document:   doc.Name,
+		Components: make([]ComponentScore, 0),
+		Gaps:       make([]Gap, 0),
+	}
+	related := make(map[string]bool)
+	for from, tos := range doc.Dependencies {
+		for _, to := range tos {
+			related[from] = true
+			related[to] = true
+		}
+	}
+
+	present := make(map[string]int)
+	totals := make(map[string]int)
+	for _, c := range doc.Components {
+		has := map[string]bool{
+			Supplier:     c.Supplier != "",
+			Name:         c.Name != "",
+			Version:      c.Version != "",
+			Identifier:   c.PURL != "" || len(c.CPEs) > 0,
+			Relationship: related[c.Ref],
+		}
+		score := ComponentScore{Ref: c.Ref, Name: c.Name}
+		for _, e := range componentElements {
+			totals[e]++
+			if has[e] {
+				present[e]++
+				continue
+			}
+			score.Missing = append(score.Missing, e)
+			r.Gaps = append(r.Gaps, Gap{Element: e, Component: c.Ref, SuggestedFix: fixes[e]})
+		}
+		score.Score = ratio(len(componentElements)-len(score.Missing), len(componentElements))
+		r.Components = append(r.Components, score)
+	}
+
+	has := map[string]bool{
+		Author:    len(doc.Authors) > 0,
+		Timestamp: doc.Timestamp != "",
+	}
+	for _, e := range documentElements {
+		totals[e]++
+		if has[e] {
+			present[e]++
+		} else {
+			r.Gaps = append(r.Gaps, Gap{Element: e, SuggestedFix: fixes[e]})
+		}
+	}
+
+	sum := 0.0
+	for _, e := range append(append([]string{}, componentElements...), documentElements...) {
+		s := ElementScore{Element: e, Present: present[e], Total: totals[e], Score: ratio(present[e], totals[e])}
+		r.Elements = append(r.Elements, s)
+		sum += s.Score
+	}
+	r.Score = sum / float64(len(r.Elements))
+	return r
+}
+
+// ratio is present/total as a percentage, an element with nothing to check is met
+func ratio(present int, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(present) / float64(total)
+}
+
+// Write renders the report as text, json or junit
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(r, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "junit":
+		return r.writeJUnit(w)
+	case "text":
+		r.writeText(w)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+func (r *Report) writeText(w io.Writer) {
+	fmt.Fprintf(w, "NTIA minimum elements for %v: %.1f%%\n\n", r.Document, r.Score)
+	for _, e := range r.Elements {
+		fmt.Fprintf(w, "  %-25v %5.1f%% (%v/%v)\n", e.Element, e.Score, e.Present, e.Total)
+	}
+	if len(r.Gaps) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%v gaps:\n", len(r.Gaps))
+	for _, g := range r.Gaps {
+		if g.Component != "" {
+			fmt.Fprintf(w, "  %v: missing %v\n      fix: %v\n", g.Component, g.Element, g.SuggestedFix)
+		} else {
+			fmt.Fprintf(w, "  document: missing %v\n      fix: %v\n", g.Element, g.SuggestedFix)
+		}
+	}
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes one test suite per element, with a test case per component
+// (or one for the document) so CI systems show exactly what is missing where
+func (r *Report) writeJUnit(w io.Writer) error {
+	gaps := make(map[string]bool)
+	for _, g := range r.Gaps {
+		gaps[g.Element+"/"+g.Component] = true
+	}
+	suites := junitSuites{}
+	for _, e := range r.Elements {
+		suite := junitSuite{Name: "ntia." + e.Element}
+		targets := []string{""}
+		if contains(componentElements, e.Element) {
+			targets = make([]string, 0)
+			for _, c := range r.Components {
+				targets = append(targets, c.Ref)
+			}
+		}
+		for _, t := range targets {
+			name := t
+			if name == "" {
+				name = r.Document
+			}
+			tc := junitCase{Name: name, ClassName: "ntia." + e.Element}
+			if gaps[e.Element+"/"+t] {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("missing %v", e.Element),
+					Text:    fixes[e.Element],
+				}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package ntia_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/ntia"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// generated is the SBOM create makes of the test chart, in the given format
+func generated(t *testing.T, format string) *sbom.Document {
+	t.Helper()
+	doc, err := sbom.Parse(generatortest.Generate(t, generatortest.Chart()).Encoded[format])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// gaps lists the gaps of a report as component: element
+func gaps(r *ntia.Report) []string {
+	out := make([]string, 0, len(r.Gaps))
+	for _, g := range r.Gaps {
+		out = append(out, g.Component+": "+g.Element)
+	}
+	return out
+}
+
+func TestCheckGenerated(t *testing.T) {
+	doc := generated(t, generator.FormatSPDX)
+	// as if the packages of the redis image hadn't been tied to it
+	delete(doc.Dependencies, "image-docker.io-library-redis-6.2")
+	r := ntia.Check(doc)
+
+	want := []string{
+		"chart-demo: unique-identifier",
+		"deb-nginx-1.21.3: supplier",
+		"deb-openssl-1.1.1k: supplier",
+		"deb-redis-6.2.6: supplier",
+		"deb-redis-6.2.6: dependency-relationship",
+		"image-docker.io-library-nginx-1.21: supplier",
+		"image-docker.io-library-redis-6.2: supplier",
+	}
+	if got := gaps(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("gaps %q, want %q", got, want)
+	}
+	for _, g := range r.Gaps {
+		if g.SuggestedFix == "" {
+			t.Errorf("%v: missing %v has no suggested fix", g.Component, g.Element)
+		}
+	}
+	if fix := r.Gaps[1].SuggestedFix; !strings.Contains(fix, "PackageSupplier") || !strings.Contains(fix, "supplier (CycloneDX)") {
+		t.Errorf("the supplier fix is %q, want it to name the field in both formats", fix)
+	}
+	if fix := r.Gaps[4].SuggestedFix; !strings.Contains(fix, "CONTAINS") || !strings.Contains(fix, "dependencies") {
+		t.Errorf("the relationship fix is %q, want it to say how to relate the component", fix)
+	}
+
+	scores := make(map[string]ntia.ElementScore)
+	for _, e := range r.Elements {
+		scores[e.Element] = e
+	}
+	if s := scores[ntia.Supplier]; s.Present != 1 || s.Total != 6 {
+		t.Errorf("supplier is present for %v of %v components, want only the chart's", s.Present, s.Total)
+	}
+	if s := scores[ntia.Relationship]; s.Present != 5 || s.Total != 6 {
+		t.Errorf("a relationship is present for %v of %v components, want all but redis", s.Present, s.Total)
+	}
+	if s := scores[ntia.Author]; s.Present != 1 {
+		t.Errorf("the document has no author")
+	}
+	for _, c := range r.Components {
+		if c.Ref == "deb-redis-6.2.6" && (c.Score != 60 || !reflect.DeepEqual(c.Missing, []string{ntia.Supplier, ntia.Relationship})) {
+			t.Errorf("redis scores %v missing %v, want 60 missing a supplier and relationship", c.Score, c.Missing)
+		}
+	}
+	if r.Score <= 0 || r.Score >= 100 {
+		t.Errorf("score %v, want it between 0 and 100", r.Score)
+	}
+}
+
+func TestCheckGeneratedCycloneDX(t *testing.T) {
+	r := ntia.Check(generated(t, generator.FormatCycloneDXJSON))
+	for _, g := range r.Gaps {
+		if g.Element != ntia.Supplier {
+			t.Errorf("%v is missing %v, want only suppliers missing", g.Component, g.Element)
+		}
+	}
+	if len(r.Gaps) != 5 {
+		t.Errorf("gaps %q, want a supplier missing for each image and package", gaps(r))
+	}
+}
+
+// conformant has every minimum element
+func conformant() *sbom.Document {
+	return &sbom.Document{
+		Name:      "demo",
+		Root:      "chart",
+		Authors:   []string{"Organization: Example"},
+		Timestamp: "2021-10-01T00:00:00Z",
+		Components: []sbom.Component{
+			{Ref: "chart", Name: "demo", Version: "0.1.0", Supplier: "Example", PURL: "pkg:helm/demo@0.1.0"},
+			{Ref: "openssl", Name: "openssl", Version: "1.1.1k", Supplier: "Debian", CPEs: []string{"cpe:2.3:a:openssl:openssl:1.1.1k:*:*:*:*:*:*:*"}},
+		},
+		Dependencies: map[string][]string{"chart": {"openssl"}},
+	}
+}
+
+func TestCheckConformant(t *testing.T) {
+	r := ntia.Check(conformant())
+	if r.Score != 100 || len(r.Gaps) != 0 {
+		t.Errorf("score %v with gaps %q, want 100 and none", r.Score, gaps(r))
+	}
+	for _, c := range r.Components {
+		if c.Score != 100 || len(c.Missing) != 0 {
+			t.Errorf("%v scores %v missing %v, want 100", c.Ref, c.Score, c.Missing)
+		}
+	}
+
+	doc := conformant()
+	doc.Authors, doc.Timestamp = nil, ""
+	want := []string{": author", ": timestamp"}
+	if got := gaps(ntia.Check(doc)); !reflect.DeepEqual(got, want) {
+		t.Errorf("gaps %q, want %q", got, want)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	doc := conformant()
+	doc.Components[1].Supplier = ""
+	doc.Timestamp = ""
+	buf := &bytes.Buffer{}
+	if err := ntia.Check(doc).Write(buf, "junit"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("the report doesn't start with an XML header: %v", buf)
+	}
+
+	var report struct {
+		Suites []struct {
+			Name     string `xml:"name,attr"`
+			Tests    int    `xml:"tests,attr"`
+			Failures int    `xml:"failures,attr"`
+			Cases    []struct {
+				Name      string `xml:"name,attr"`
+				ClassName string `xml:"classname,attr"`
+				Failure   *struct {
+					Message string `xml:"message,attr"`
+					Text    string `xml:",chardata"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Suites) != 7 {
+		t.Fatalf("%v suites, want one for each minimum element", len(report.Suites))
+	}
+	suites := make(map[string]int)
+	for i, s := range report.Suites {
+		suites[s.Name] = i
+	}
+	supplier := report.Suites[suites["ntia.supplier"]]
+	if supplier.Tests != 2 || supplier.Failures != 1 || len(supplier.Cases) != 2 {
+		t.Fatalf("the supplier suite has %v tests and %v failures, want one of each component failing", supplier.Tests, supplier.Failures)
+	}
+	if c := supplier.Cases[0]; c.Name != "chart" || c.ClassName != "ntia.supplier" || c.Failure != nil {
+		t.Errorf("case %+v, want the chart to pass", c)
+	}
+	if c := supplier.Cases[1]; c.Name != "openssl" || c.Failure == nil || c.Failure.Message != "missing supplier" || !strings.Contains(c.Failure.Text, "PackageSupplier") {
+		t.Errorf("case %+v, want openssl to fail with the fix", c)
+	}
+	timestamp := report.Suites[suites["ntia.timestamp"]]
+	if timestamp.Tests != 1 || timestamp.Failures != 1 || timestamp.Cases[0].Name != "demo" {
+		t.Errorf("the timestamp suite is %+v, want the document failing", timestamp)
+	}
+	author := report.Suites[suites["ntia.author"]]
+	if author.Tests != 1 || author.Failures != 0 {
+		t.Errorf("the author suite is %+v, want the document passing", author)
+	}
+}
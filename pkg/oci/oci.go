@@ -0,0 +1,258 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// Media types of SBOMs stored in registries
+const (
+	MediaTypeSPDX          = "text/spdx"
+	MediaTypeSPDXJSON      = "application/spdx+json"
+	MediaTypeCycloneDX     = "application/vnd.cyclonedx+xml"
+	MediaTypeCycloneDXJSON = "application/vnd.cyclonedx+json"
+)
+
+// Media types of the manifests that link artifacts to their subject
+const (
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeImageIndex    = "application/vnd.oci.image.index.v1+json"
+	MediaTypeEmptyJSON     = "application/vnd.oci.empty.v1+json"
+)
+
+// isSBOM reports whether a media type is one SBOMs are stored as. cosign
+// uses a few more than the registered ones.
+func isSBOM(mediaType string) bool {
+	mt := strings.ToLower(mediaType)
+	switch {
+	case mt == MediaTypeSPDX, mt == MediaTypeSPDXJSON, mt == MediaTypeCycloneDX, mt == MediaTypeCycloneDXJSON:
+		return true
+	case strings.Contains(mt, "spdx"), strings.Contains(mt, "cyclonedx"):
+		return true
+	}
+	return false
+}
+
+// Client talks to registries with the credentials docker and podman use
+type Client struct {
+	// Insecure allows plain http, e.g. for a registry on localhost
+	Insecure bool
+	// Keychain finds credentials, authn.DefaultKeychain if nil
+	Keychain authn.Keychain
+}
+
+func (c *Client) nameOptions() []name.Option {
+	if c.Insecure {
+		return []name.Option{name.Insecure}
+	}
+	return nil
+}
+
+func (c *Client) keychain() authn.Keychain {
+	if c.Keychain == nil {
+		return authn.DefaultKeychain
+	}
+	return c.Keychain
+}
+
+func (c *Client) remoteOptions(ctx context.Context) []remote.Option {
+	return []remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(c.keychain())}
+}
+
+// ParseReference parses an image or artifact reference, with or without the
+// oci:// helm uses for charts
+func (c *Client) ParseReference(ref string) (name.Reference, error) {
+	return name.ParseReference(strings.TrimPrefix(ref, "oci://"), c.nameOptions()...)
+}
+
+// Digest resolves a reference to the digest of what it points at
+func (c *Client) Digest(ctx context.Context, ref name.Reference) (v1.Hash, error) {
+	desc, err := remote.Head(ref, c.remoteOptions(ctx)...)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	return desc.Digest, nil
+}
+
+// Descriptor is an OCI descriptor with the fields referrers use, which the
+// go-containerregistry version in use does not have
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+}
+
+// Platform is the platform the image of an index entry runs on
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String is the platform as docker writes it, linux/arm64/v8
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Manifest is an OCI image manifest or index, with a subject
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []Descriptor      `json:"layers,omitempty"`
+	Manifests     []Descriptor      `json:"manifests,omitempty"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// artifactType is the type of the artifact a manifest stores, from the
+// artifactType field or, for older clients, the config media type
+func (m *Manifest) artifactType() string {
+	if m.ArtifactType != "" {
+		return m.ArtifactType
+	}
+	if m.Config != nil && m.Config.MediaType != MediaTypeEmptyJSON {
+		return m.Config.MediaType
+	}
+	return ""
+}
+
+// MediaTypeDockerManifestList is the media type of docker's image indexes
+const MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+// Index fetches the image index a reference points at, with an entry for
+// each platform. It returns nil when the reference points at a single image.
+func (c *Client) Index(ctx context.Context, ref name.Reference) (*Manifest, error) {
+	m, err := c.manifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("%v: no such manifest", ref)
+	}
+	if m.MediaType != MediaTypeImageIndex && m.MediaType != MediaTypeDockerManifestList && (m.Config != nil || len(m.Manifests) == 0) {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// manifest fetches and decodes a manifest; it returns nil when there is none
+func (c *Client) manifest(ctx context.Context, ref name.Reference) (*Manifest, error) {
+	desc, err := remote.Get(ref, c.remoteOptions(ctx)...)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(desc.Manifest, m); err != nil {
+		return nil, fmt.Errorf("%v: %w", ref, err)
+	}
+	return m, nil
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	if terr.StatusCode == http.StatusNotFound {
+		return true
+	}
+	for _, e := range terr.Errors {
+		if e.Code == transport.ManifestUnknownErrorCode {
+			return true
+		}
+	}
+	return false
+}
+
+// blob reads a blob of a repository
+func (c *Client) blob(ctx context.Context, repo name.Repository, digest string) ([]byte, error) {
+	layer, err := remote.Layer(repo.Digest(digest), c.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// FallbackTag is the tag the referrers of a digest are listed under by
+// registries without the referrers API, e.g. sha256-<hex>
+func FallbackTag(repo name.Repository, digest v1.Hash) name.Tag {
+	return repo.Tag(digest.Algorithm + "-" + digest.Hex)
+}
+
+// Referrers lists the manifests whose subject is the digest, through the
+// referrers API or, when the registry does not have it, the fallback tag
+func (c *Client) Referrers(ctx context.Context, repo name.Repository, digest v1.Hash) ([]Descriptor, error) {
+	index, err := c.referrersAPI(ctx, repo, digest)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		if index, err = c.manifest(ctx, FallbackTag(repo, digest)); err != nil {
+			return nil, err
+		}
+	}
+	if index == nil {
+		return nil, nil
+	}
+	return index.Manifests, nil
+}
+
+// referrersAPI asks the registry for the referrers of a digest. It returns
+// nil when the registry does not have the API.
+func (c *Client) referrersAPI(ctx context.Context, repo name.Repository, digest v1.Hash) (*Manifest, error) {
+	auth, err := c.keychain().Resolve(repo.Registry)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := transport.NewWithContext(ctx, repo.Registry, auth, http.DefaultTransport, []string{repo.Scope(transport.PullScope)})
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%v://%v/v2/%v/referrers/%v", repo.Registry.Scheme(), repo.RegistryStr(), repo.RepositoryStr(), digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MediaTypeImageIndex)
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), MediaTypeImageIndex) {
+		return nil, nil
+	}
+	index := &Manifest{}
+	if err := json.NewDecoder(resp.Body).Decode(index); err != nil {
+		return nil, fmt.Errorf("referrers of %v: %w", digest, err)
+	}
+	return index, nil
+}
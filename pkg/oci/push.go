@@ -0,0 +1,229 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// MediaTypeDSSE is the media type of DSSE envelopes, which attestations are
+// stored as
+const MediaTypeDSSE = "application/vnd.dsse.envelope.v1+json"
+
+// Annotations of pushed artifacts
+const (
+	AnnotationTitle   = "org.opencontainers.image.title"
+	AnnotationCreated = "org.opencontainers.image.created"
+)
+
+// emptyJSON is the config of artifacts that have none
+var emptyJSON = []byte("{}")
+
+// MediaTypeOf works out the media type to store content as: a DSSE envelope
+// or an SBOM in one of the formats pkg/sbom reads
+func MediaTypeOf(b []byte) (string, error) {
+	envelope := struct {
+		PayloadType string `json:"payloadType"`
+	}{}
+	if json.Unmarshal(b, &envelope) == nil && envelope.PayloadType != "" {
+		return MediaTypeDSSE, nil
+	}
+	format, err := sbom.DetectFormat(b)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case sbom.FormatSPDX:
+		return MediaTypeSPDX, nil
+	case sbom.FormatSPDXJSON:
+		return MediaTypeSPDXJSON, nil
+	case sbom.FormatCycloneDX:
+		return MediaTypeCycloneDX, nil
+	}
+	return MediaTypeCycloneDXJSON, nil
+}
+
+// rawManifest is a manifest remote.Put can write as it is
+type rawManifest struct {
+	body      []byte
+	mediaType string
+}
+
+func (r rawManifest) RawManifest() ([]byte, error) {
+	return r.body, nil
+}
+
+func (r rawManifest) MediaType() (types.MediaType, error) {
+	return types.MediaType(r.mediaType), nil
+}
+
+// Push stores content as an artifact of the given type whose subject is the
+// manifest the subject reference points at, e.g. a chart pushed with helm
+// push. Registries without the referrers API also get the artifact listed
+// under the fallback tag. It returns the artifact's reference.
+func (c *Client) Push(ctx context.Context, subject string, artifactType string, content []byte, annotations map[string]string) (name.Digest, error) {
+	ref, err := c.ParseReference(subject)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	opts := c.remoteOptions(ctx)
+	desc, err := remote.Head(ref, opts...)
+	if err != nil {
+		return name.Digest{}, fmt.Errorf("resolving %v: %w", subject, err)
+	}
+	repo := ref.Context()
+
+	layer := static.NewLayer(content, types.MediaType(artifactType))
+	config := static.NewLayer(emptyJSON, types.MediaType(MediaTypeEmptyJSON))
+	for _, l := range []v1.Layer{config, layer} {
+		if err := remote.WriteLayer(repo, l, opts...); err != nil {
+			return name.Digest{}, fmt.Errorf("uploading to %v: %w", repo, err)
+		}
+	}
+	layerDigest, _ := layer.Digest()
+	configDigest, _ := config.Digest()
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if _, ok := annotations[AnnotationCreated]; !ok {
+		annotations[AnnotationCreated] = time.Now().UTC().Format(time.RFC3339)
+	}
+	m := &Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeImageManifest,
+		ArtifactType:  artifactType,
+		Config:        &Descriptor{MediaType: MediaTypeEmptyJSON, Digest: configDigest.String(), Size: int64(len(emptyJSON))},
+		Layers:        []Descriptor{{MediaType: artifactType, Digest: layerDigest.String(), Size: int64(len(content))}},
+		Subject:       &Descriptor{MediaType: string(desc.MediaType), Digest: desc.Digest.String(), Size: desc.Size},
+		Annotations:   annotations,
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	digest, size, err := v1.SHA256(bytes.NewReader(body))
+	if err != nil {
+		return name.Digest{}, err
+	}
+	artifact := repo.Digest(digest.String())
+	if err := remote.Put(artifact, rawManifest{body, MediaTypeImageManifest}, opts...); err != nil {
+		return name.Digest{}, fmt.Errorf("pushing %v: %w", artifact, err)
+	}
+
+	index, err := c.referrersAPI(ctx, repo, desc.Digest)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	if index == nil {
+		d := Descriptor{MediaType: MediaTypeImageManifest, ArtifactType: artifactType, Digest: digest.String(), Size: size, Annotations: annotations}
+		if err := c.addFallbackReferrer(ctx, repo, desc.Digest, d); err != nil {
+			return name.Digest{}, err
+		}
+	}
+	return artifact, nil
+}
+
+// addFallbackReferrer adds a descriptor to the index under the fallback tag
+// of a digest
+func (c *Client) addFallbackReferrer(ctx context.Context, repo name.Repository, digest v1.Hash, d Descriptor) error {
+	tag := FallbackTag(repo, digest)
+	index, err := c.manifest(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		index = &Manifest{SchemaVersion: 2, MediaType: MediaTypeImageIndex}
+	}
+	for _, have := range index.Manifests {
+		if have.Digest == d.Digest {
+			return nil
+		}
+	}
+	index.Manifests = append(index.Manifests, d)
+	body, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := remote.Put(tag, rawManifest{body, MediaTypeImageIndex}, c.remoteOptions(ctx)...); err != nil {
+		return fmt.Errorf("updating %v: %w", tag, err)
+	}
+	return nil
+}
+
+// Artifact is content stored as a referrer of a manifest
+type Artifact struct {
+	// Ref is the artifact manifest, by digest
+	Ref          string
+	ArtifactType string
+	Annotations  map[string]string
+	Content      []byte
+}
+
+// Pull returns the newest artifact among the referrers of the subject whose
+// type is one of the artifact types, or any SBOM type when none are given.
+// It returns nil when there is none.
+func (c *Client) Pull(ctx context.Context, subject string, artifactTypes ...string) (*Artifact, error) {
+	ref, err := c.ParseReference(subject)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := c.Digest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %v: %w", subject, err)
+	}
+	repo := ref.Context()
+	referrers, err := c.Referrers(ctx, repo, digest)
+	if err != nil {
+		return nil, fmt.Errorf("listing the referrers of %v: %w", subject, err)
+	}
+
+	var newest *Descriptor
+	for i, d := range referrers {
+		if !wanted(d.ArtifactType, artifactTypes) {
+			continue
+		}
+		if newest == nil || d.Annotations[AnnotationCreated] >= newest.Annotations[AnnotationCreated] {
+			newest = &referrers[i]
+		}
+	}
+	if newest == nil {
+		return nil, nil
+	}
+
+	artifactRef := repo.Digest(newest.Digest)
+	m, err := c.manifest(ctx, artifactRef)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || len(m.Layers) == 0 {
+		return nil, fmt.Errorf("%v has no content", artifactRef)
+	}
+	content, err := c.blob(ctx, repo, m.Layers[0].Digest)
+	if err != nil {
+		return nil, fmt.Errorf("reading %v: %w", artifactRef, err)
+	}
+	return &Artifact{Ref: artifactRef.String(), ArtifactType: m.artifactType(), Annotations: m.Annotations, Content: content}, nil
+}
+
+func wanted(artifactType string, artifactTypes []string) bool {
+	if len(artifactTypes) == 0 {
+		return isSBOM(artifactType)
+	}
+	for _, t := range artifactTypes {
+		if t == artifactType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package oci_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+const (
+	oldBOM   = `{"bomFormat": "CycloneDX", "specVersion": "1.4", "version": 1, "components": []}`
+	newBOM   = `{"bomFormat": "CycloneDX", "specVersion": "1.4", "version": 2, "components": []}`
+	envelope = `{"payloadType": "application/vnd.in-toto+json", "payload": "e30=", "signatures": []}`
+)
+
+// testSubject runs an in-process registry with a random image in it, which
+// stands in for a chart, and returns the image's reference
+func testSubject(t *testing.T) string {
+	t.Helper()
+	ts := httptest.NewServer(registry.New())
+	t.Cleanup(ts.Close)
+	subject := strings.TrimPrefix(ts.URL, "http://") + "/charts/demo:0.1.0"
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(subject, name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	return subject
+}
+
+func testClient() *oci.Client {
+	return &oci.Client{Insecure: true, Keychain: authn.NewMultiKeychain()}
+}
+
+func push(t *testing.T, c *oci.Client, subject string, content string, created string) {
+	t.Helper()
+	mediaType, err := oci.MediaTypeOf([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{oci.AnnotationCreated: created}
+	if _, err := c.Push(context.Background(), subject, mediaType, []byte(content), annotations); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPushPull(t *testing.T) {
+	ctx := context.Background()
+	subject := testSubject(t)
+	c := testClient()
+	if a, err := c.Pull(ctx, subject); err != nil || a != nil {
+		t.Fatalf("pulled %+v, %v before pushing, want nothing", a, err)
+	}
+
+	push(t, c, subject, oldBOM, "2021-10-01T00:00:00Z")
+	push(t, c, subject, envelope, "2021-10-02T00:00:00Z")
+	push(t, c, subject, newBOM, "2021-10-03T00:00:00Z")
+
+	a, err := c.Pull(ctx, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == nil || string(a.Content) != newBOM || a.ArtifactType != oci.MediaTypeCycloneDXJSON {
+		t.Fatalf("pulled %+v, want the newest SBOM", a)
+	}
+	a, err = c.Pull(ctx, subject, oci.MediaTypeDSSE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == nil || string(a.Content) != envelope {
+		t.Fatalf("pulled %+v, want the attestation", a)
+	}
+
+	ref, err := c.ParseReference(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := c.Digest(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	referrers, err := c.Referrers(ctx, ref.Context(), digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(referrers) != 3 {
+		t.Errorf("%v referrers, want the 3 pushed", len(referrers))
+	}
+}
+
+func TestFindSBOM(t *testing.T) {
+	ctx := context.Background()
+	subject := testSubject(t)
+	c := testClient()
+	push(t, c, subject, envelope, "2021-10-01T00:00:00Z")
+	push(t, c, subject, newBOM, "2021-10-02T00:00:00Z")
+
+	a, err := c.FindSBOM(ctx, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == nil {
+		t.Fatal("found no SBOM")
+	}
+	if string(a.SBOM) != newBOM || a.Source != oci.SourceReferrer || a.Format != sbom.FormatCycloneDXJSON {
+		t.Errorf("found %+v, want the pushed SBOM as a referrer", a)
+	}
+	if !strings.HasSuffix(a.Image, "@"+a.Digest) {
+		t.Errorf("found the SBOM of %v, want the subject by its digest %v", a.Image, a.Digest)
+	}
+}
+
+func TestFindSBOMWithout(t *testing.T) {
+	ctx := context.Background()
+	subject := testSubject(t)
+	c := testClient()
+	push(t, c, subject, envelope, "2021-10-01T00:00:00Z")
+	for _, image := range []string{subject, strings.Replace(subject, "demo", "missing", 1)} {
+		a, err := c.FindSBOM(ctx, image)
+		if err != nil || a != nil {
+			t.Errorf("found %+v, %v for %v, want nothing", a, err, image)
+		}
+	}
+}
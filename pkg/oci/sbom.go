@@ -0,0 +1,112 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Where an attached SBOM was found
+const (
+	SourceReferrer  = "oci-referrer"
+	SourceCosignTag = "cosign-sbom-tag"
+)
+
+// Attached is an SBOM found in a registry next to an image
+type Attached struct {
+	// Image is the image, by digest, the SBOM is attached to
+	Image  string
+	Digest string
+	SBOM   []byte
+	Format string
+	// Source is SourceReferrer or SourceCosignTag
+	Source string
+	// Ref is the manifest the SBOM was read from
+	Ref string
+}
+
+// CosignTag is the tag cosign attach sbom stores the SBOM of a digest under,
+// e.g. sha256-<hex>.sbom
+func CosignTag(repo name.Repository, digest v1.Hash) name.Tag {
+	return repo.Tag(fmt.Sprintf("%v-%v.sbom", digest.Algorithm, digest.Hex))
+}
+
+// FindSBOM looks for an SBOM attached to the digest the image resolves to:
+// first among its referrers, then under the cosign sbom tag. Only SBOMs that
+// parse as SPDX or CycloneDX count. It returns nil when there is none,
+// including when the registry does not have the image.
+func (c *Client) FindSBOM(ctx context.Context, image string) (*Attached, error) {
+	ref, err := c.ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := c.Digest(ctx, ref)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving %v: %w", image, err)
+	}
+	repo := ref.Context()
+	imageDigest := repo.Digest(digest.String()).String()
+
+	referrers, err := c.Referrers(ctx, repo, digest)
+	if err != nil {
+		return nil, fmt.Errorf("listing the referrers of %v: %w", imageDigest, err)
+	}
+	for _, d := range referrers {
+		if d.ArtifactType != "" && !isSBOM(d.ArtifactType) {
+			continue
+		}
+		manifestRef := repo.Digest(d.Digest)
+		a, err := c.readSBOM(ctx, manifestRef, true)
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			a.Image, a.Digest, a.Source = imageDigest, digest.String(), SourceReferrer
+			return a, nil
+		}
+	}
+
+	a, err := c.readSBOM(ctx, CosignTag(repo, digest), false)
+	if err != nil {
+		return nil, err
+	}
+	if a != nil {
+		a.Image, a.Digest, a.Source = imageDigest, digest.String(), SourceCosignTag
+	}
+	return a, nil
+}
+
+// readSBOM reads the first layer of a manifest that is a valid SBOM. With
+// typed, the manifest itself must say it holds an SBOM; cosign manifests
+// only say so in their layers.
+func (c *Client) readSBOM(ctx context.Context, ref name.Reference, typed bool) (*Attached, error) {
+	m, err := c.manifest(ctx, ref)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	if typed && !isSBOM(m.artifactType()) {
+		return nil, nil
+	}
+	for _, layer := range m.Layers {
+		if !isSBOM(layer.MediaType) && !typed {
+			continue
+		}
+		b, err := c.blob(ctx, ref.Context(), layer.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("reading %v from %v: %w", layer.Digest, ref, err)
+		}
+		if _, err := sbom.Parse(b); err != nil {
+			continue
+		}
+		format, _ := sbom.DetectFormat(b)
+		return &Attached{SBOM: b, Format: format, Ref: ref.String()}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,216 @@
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/license"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Decisions a policy makes about a license, from best to worst
+const (
+	Allow  = "allow"
+	Review = "review"
+	Deny   = "deny"
+)
+
+var rank = map[string]int{Allow: 0, Review: 1, Deny: 2}
+
+// Policy lists the licenses that are allowed, denied or need a review. The
+// lists hold license IDs or globs ("GPL-*"); an ID with an exception can be
+// listed as a whole ("GPL-2.0-only WITH Classpath-exception-2.0").
+type Policy struct {
+	Allow  []string `yaml:"allow" mapstructure:"allow"`
+	Deny   []string `yaml:"deny" mapstructure:"deny"`
+	Review []string `yaml:"review" mapstructure:"review"`
+	// Default is the decision for licenses on none of the lists, review if empty
+	Default string `yaml:"default" mapstructure:"default"`
+	// NoLicense is the decision for components without a license, review if empty
+	NoLicense string `yaml:"noLicense" mapstructure:"noLicense"`
+	// FailOnReview makes licenses needing a review fail the policy too
+	FailOnReview bool        `yaml:"failOnReview" mapstructure:"failOnReview"`
+	Exceptions   []Exception `yaml:"exceptions" mapstructure:"exceptions"`
+}
+
+// Exception allows licenses (all of them if none are listed) for the
+// components matching a glob on their PURL, name, name@version or ref
+type Exception struct {
+	Component string   `yaml:"component" mapstructure:"component"`
+	Licenses  []string `yaml:"licenses" mapstructure:"licenses"`
+	Reason    string   `yaml:"reason" mapstructure:"reason"`
+}
+
+// Load reads a policy from a YAML file
+func Load(filename string) (*Policy, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(b, p); err != nil {
+		return nil, fmt.Errorf("%v: %w", filename, err)
+	}
+	return p, p.Validate()
+}
+
+// Validate checks the decisions and globs in the policy
+func (p *Policy) Validate() error {
+	for _, d := range []string{p.Default, p.NoLicense} {
+		if _, ok := rank[d]; d != "" && !ok {
+			return fmt.Errorf("unknown decision %q, must be allow, review or deny", d)
+		}
+	}
+	globs := append(append(append([]string{}, p.Allow...), p.Deny...), p.Review...)
+	for _, e := range p.Exceptions {
+		if e.Component == "" {
+			return fmt.Errorf("exception without a component")
+		}
+		globs = append(append(globs, e.Component), e.Licenses...)
+	}
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", g, err)
+		}
+	}
+	return nil
+}
+
+// Result is the decision for one component
+type Result struct {
+	Ref        string   `json:"ref"`
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	PURL       string   `json:"purl,omitempty"`
+	Licenses   []string `json:"licenses,omitempty"`
+	Decision   string   `json:"decision"`
+	Reasons    []string `json:"reasons,omitempty"`
+	Exceptions []string `json:"exceptions,omitempty"`
+}
+
+// Evaluate decides every component of the document. Within a license
+// expression AND takes the worst decision of its operands and OR the best,
+// since the licensee can pick which license to comply with.
+func (p *Policy) Evaluate(doc *sbom.Document) *Report {
+	r := &Report{Document: doc.Name, Results: make([]Result, 0), FailOnReview: p.FailOnReview}
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			continue
+		}
+		res := Result{Ref: c.Ref, Name: c.Name, Version: c.Version, PURL: c.PURL, Licenses: c.Licenses, Decision: Allow}
+		exceptions := p.exceptionsFor(c)
+		for _, e := range exceptions {
+			res.Exceptions = append(res.Exceptions, fmt.Sprintf("%v: %v", e.Component, e.Reason))
+		}
+		if len(c.Licenses) == 0 {
+			res.Decision = decision(p.NoLicense)
+			res.Reasons = append(res.Reasons, "no license")
+		}
+		// concluded and declared licenses (or several CycloneDX licenses) must all pass
+		for _, l := range c.Licenses {
+			d, reasons := p.decide(l, exceptions)
+			res.Reasons = append(res.Reasons, reasons...)
+			if rank[d] > rank[res.Decision] {
+				res.Decision = d
+			}
+		}
+		r.Results = append(r.Results, res)
+	}
+	r.summarize()
+	return r
+}
+
+func (p *Policy) decide(expression string, exceptions []Exception) (string, []string) {
+	e, err := license.Parse(expression)
+	if err != nil {
+		// not a valid expression, treat it as a single (unknown) license name
+		return p.decideLicense(&license.Expression{ID: expression}, exceptions)
+	}
+	return p.decideExpression(e, exceptions)
+}
+
+func (p *Policy) decideExpression(e *license.Expression, exceptions []Exception) (string, []string) {
+	if e.Op == "" {
+		return p.decideLicense(e, exceptions)
+	}
+	var best string
+	var bestReasons []string
+	all := make([]string, 0)
+	for i, a := range e.Args {
+		d, reasons := p.decideExpression(a, exceptions)
+		all = append(all, reasons...)
+		better := rank[d] < rank[best]
+		if e.Op == license.And {
+			better = rank[d] > rank[best]
+		}
+		if i == 0 || better {
+			best, bestReasons = d, reasons
+		}
+	}
+	if e.Op == license.And {
+		return best, all
+	}
+	return best, bestReasons
+}
+
+func (p *Policy) decideLicense(e *license.Expression, exceptions []Exception) (string, []string) {
+	names := []string{e.ID}
+	if e.Exception != "" {
+		names = []string{e.String(), e.ID}
+	}
+	for _, ex := range exceptions {
+		if len(ex.Licenses) == 0 || matchAny(ex.Licenses, names) {
+			return Allow, []string{fmt.Sprintf("%v allowed by exception for %v", e, ex.Component)}
+		}
+	}
+	switch {
+	case matchAny(p.Deny, names):
+		return Deny, []string{fmt.Sprintf("%v is denied", e)}
+	case matchAny(p.Allow, names):
+		return Allow, nil
+	case matchAny(p.Review, names):
+		return Review, []string{fmt.Sprintf("%v requires review", e)}
+	}
+	d := decision(p.Default)
+	if d == Allow {
+		return d, nil
+	}
+	return d, []string{fmt.Sprintf("%v is not on the policy (default %v)", e, d)}
+}
+
+func (p *Policy) exceptionsFor(c sbom.Component) []Exception {
+	ids := []string{c.Ref, c.Name, c.Name + "@" + c.Version}
+	if c.PURL != "" {
+		ids = append(ids, c.PURL)
+	}
+	out := make([]Exception, 0)
+	for _, e := range p.Exceptions {
+		if matchAny([]string{e.Component}, ids) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func decision(d string) string {
+	if d == "" {
+		return Review
+	}
+	return d
+}
+
+// matchAny reports whether any name matches any of the globs, ignoring case
+func matchAny(globs []string, names []string) bool {
+	for _, g := range globs {
+		for _, n := range names {
+			if ok, _ := path.Match(strings.ToLower(g), strings.ToLower(n)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+package policy_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/policy"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// testPolicy is the example policy of the policy command
+const testPolicy = `allow: [MIT, Apache-2.0, BSD-*, ISC, GPL-2.0-only WITH Classpath-exception-2.0]
+deny: [AGPL-*, SSPL-*]
+review: [GPL-*, LGPL-*]
+default: review
+noLicense: deny
+failOnReview: true
+exceptions:
+  - component: pkg:deb/debian/bash@*
+    licenses: [GPL-3.0-*]
+    reason: shell in the base image, not linked
+`
+
+func component(licenses ...string) sbom.Component {
+	return sbom.Component{Ref: "deb-tool-1.0", Name: "tool", Version: "1.0", PURL: "pkg:deb/debian/tool@1.0", Licenses: licenses}
+}
+
+func evaluate(p *policy.Policy, c sbom.Component) policy.Result {
+	r := p.Evaluate(&sbom.Document{Name: "demo", Components: []sbom.Component{c}})
+	return r.Results[0]
+}
+
+func TestEvaluate(t *testing.T) {
+	lists := policy.Policy{
+		Allow:  []string{"MIT", "Apache-2.0", "BSD-*", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:   []string{"AGPL-*"},
+		Review: []string{"GPL-*"},
+	}
+	bash := sbom.Component{Ref: "deb-bash-5.1", Name: "bash", Version: "5.1", PURL: "pkg:deb/debian/bash@5.1", Licenses: []string{"GPL-3.0-or-later AND AGPL-3.0-only"}}
+	tests := []struct {
+		name      string
+		policy    policy.Policy
+		component sbom.Component
+		want      string
+	}{
+		{"allowed", lists, component("MIT"), policy.Allow},
+		{"denied", lists, component("AGPL-3.0-only"), policy.Deny},
+		{"review", lists, component("GPL-3.0-only"), policy.Review},
+		{"glob ignores case", lists, component("bsd-3-clause"), policy.Allow},
+		{"deny before allow", policy.Policy{Allow: []string{"*"}, Deny: []string{"AGPL-*"}}, component("AGPL-3.0-only"), policy.Deny},
+		{"and takes the worst", lists, component("MIT AND GPL-2.0-only"), policy.Review},
+		{"and with a denied license", lists, component("MIT AND (Apache-2.0 AND AGPL-3.0-only)"), policy.Deny},
+		{"or takes the best", lists, component("AGPL-3.0-only OR MIT"), policy.Allow},
+		{"or of reviews", lists, component("GPL-2.0-only OR AGPL-3.0-only"), policy.Review},
+		{"nested", lists, component("(AGPL-3.0-only OR GPL-3.0-only) AND MIT"), policy.Review},
+		{"with exception listed", lists, component("GPL-2.0-only WITH Classpath-exception-2.0"), policy.Allow},
+		{"with other exception", lists, component("GPL-2.0-only WITH GCC-exception-3.1"), policy.Review},
+		{"every license must pass", lists, component("MIT", "GPL-3.0-only"), policy.Review},
+		{"default review", lists, component("Zlib"), policy.Review},
+		{"default deny", policy.Policy{Default: policy.Deny}, component("Zlib"), policy.Deny},
+		{"default allow", policy.Policy{Default: policy.Allow}, component("Zlib"), policy.Allow},
+		{"not an expression", lists, component("Some License v2"), policy.Review},
+		{"no license", lists, component(), policy.Review},
+		{"no license denied", policy.Policy{NoLicense: policy.Deny}, component(), policy.Deny},
+		{"no license allowed", policy.Policy{NoLicense: policy.Allow}, component(), policy.Allow},
+		{
+			name:      "exception for listed licenses",
+			policy:    policy.Policy{Deny: []string{"GPL-*"}, Exceptions: []policy.Exception{{Component: "pkg:deb/debian/bash@*", Licenses: []string{"GPL-3.0-*"}}}},
+			component: bash,
+			want:      policy.Review,
+		},
+		{
+			name:      "exception for every license",
+			policy:    policy.Policy{Deny: []string{"*GPL-*"}, Exceptions: []policy.Exception{{Component: "bash"}}},
+			component: bash,
+			want:      policy.Allow,
+		},
+		{
+			name:      "exception by name and version",
+			policy:    policy.Policy{Deny: []string{"*GPL-*"}, Exceptions: []policy.Exception{{Component: "bash@5.*"}}},
+			component: bash,
+			want:      policy.Allow,
+		},
+		{
+			name:      "exception for another component",
+			policy:    policy.Policy{Deny: []string{"*GPL-*"}, Exceptions: []policy.Exception{{Component: "pkg:deb/debian/zsh@*"}}},
+			component: bash,
+			want:      policy.Deny,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if res := evaluate(&test.policy, test.component); res.Decision != test.want {
+				t.Errorf("%v is %v (%v), want %v", test.component.Licenses, res.Decision, res.Reasons, test.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateReasons(t *testing.T) {
+	p := &policy.Policy{Allow: []string{"MIT"}, Deny: []string{"AGPL-*"}, Review: []string{"GPL-*"}}
+	tests := []struct {
+		license string
+		want    []string
+	}{
+		{"MIT", nil},
+		// AND needs every license, so every reason counts
+		{"GPL-2.0-only AND AGPL-3.0-only", []string{"GPL-2.0-only requires review", "AGPL-3.0-only is denied"}},
+		// OR only needs the chosen one
+		{"GPL-2.0-only OR AGPL-3.0-only", []string{"GPL-2.0-only requires review"}},
+		{"Zlib", []string{"Zlib is not on the policy (default review)"}},
+	}
+	for _, test := range tests {
+		if res := evaluate(p, component(test.license)); !reflect.DeepEqual(res.Reasons, test.want) {
+			t.Errorf("%v has reasons %q, want %q", test.license, res.Reasons, test.want)
+		}
+	}
+
+	p.Exceptions = []policy.Exception{{Component: "tool", Reason: "vendored"}}
+	res := evaluate(p, component("AGPL-3.0-only"))
+	if want := []string{"tool: vendored"}; !reflect.DeepEqual(res.Exceptions, want) {
+		t.Errorf("exceptions %q, want %q", res.Exceptions, want)
+	}
+	if want := []string{"AGPL-3.0-only allowed by exception for tool"}; !reflect.DeepEqual(res.Reasons, want) {
+		t.Errorf("reasons %q, want %q", res.Reasons, want)
+	}
+}
+
+func TestEvaluateSkipsImages(t *testing.T) {
+	p := &policy.Policy{}
+	doc := &sbom.Document{Components: []sbom.Component{{Ref: "image-nginx", Type: "container"}, component("MIT")}}
+	if r := p.Evaluate(doc); len(r.Results) != 1 || r.Results[0].Ref != "deb-tool-1.0" {
+		t.Errorf("results %+v, want only deb-tool-1.0", r.Results)
+	}
+}
+
+func TestFailOnReview(t *testing.T) {
+	doc := &sbom.Document{Name: "demo", Components: []sbom.Component{component("GPL-2.0-only")}}
+	p := &policy.Policy{Review: []string{"GPL-*"}}
+	if r := p.Evaluate(doc); !r.Pass || len(r.Violations()) != 0 {
+		t.Errorf("pass %v with violations %+v, want a pass when reviews don't fail", r.Pass, r.Violations())
+	}
+	p.FailOnReview = true
+	r := p.Evaluate(doc)
+	if r.Pass || len(r.Violations()) != 1 {
+		t.Errorf("pass %v with violations %+v, want the review to fail", r.Pass, r.Violations())
+	}
+	if want := map[string]int{policy.Allow: 0, policy.Review: 1, policy.Deny: 0}; !reflect.DeepEqual(r.Summary, want) {
+		t.Errorf("summary %v, want %v", r.Summary, want)
+	}
+}
+
+func TestUnmarshalKey(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	// the config file has the policy under its policy key
+	config := "policy:\n  " + strings.ReplaceAll(strings.TrimSpace(testPolicy), "\n", "\n  ")
+	if err := v.ReadConfig(strings.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+	p := &policy.Policy{}
+	if err := v.UnmarshalKey("policy", p); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := &policy.Policy{
+		Allow:        []string{"MIT", "Apache-2.0", "BSD-*", "ISC", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:         []string{"AGPL-*", "SSPL-*"},
+		Review:       []string{"GPL-*", "LGPL-*"},
+		Default:      policy.Review,
+		NoLicense:    policy.Deny,
+		FailOnReview: true,
+		Exceptions: []policy.Exception{{
+			Component: "pkg:deb/debian/bash@*",
+			Licenses:  []string{"GPL-3.0-*"},
+			Reason:    "shell in the base image, not linked",
+		}},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("unmarshaled\n%+v\nwant\n%+v", p, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	p, err := policy.Load(write("policy.yaml", testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.NoLicense != policy.Deny || len(p.Exceptions) != 1 || !p.FailOnReview {
+		t.Errorf("loaded %+v", p)
+	}
+
+	for name, content := range map[string]string{
+		"unknown field":    "allow: [MIT]\nallowed: [ISC]\n",
+		"unknown decision": "default: maybe\n",
+		"invalid glob":     "deny: ['GPL-[']\n",
+		"no component":     "exceptions:\n  - reason: missing\n",
+	} {
+		if _, err := policy.Load(write("bad.yaml", content)); err == nil {
+			t.Errorf("%v: loaded %q, want an error", name, content)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	p := &policy.Policy{Allow: []string{"MIT"}, Deny: []string{"AGPL-*"}}
+	doc := &sbom.Document{Name: "demo", Components: []sbom.Component{component("AGPL-3.0-only"), component("MIT")}}
+	r := p.Evaluate(doc)
+
+	var text bytes.Buffer
+	if err := r.Write(&text, "text"); err != nil {
+		t.Fatal(err)
+	}
+	want := `License policy for demo: FAIL
+  1 allowed, 0 need review, 1 denied
+
+DENY:
+  tool@1.0 [AGPL-3.0-only]
+      AGPL-3.0-only is denied
+`
+	if text.String() != want {
+		t.Errorf("text\n%v\nwant\n%v", text.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := r.Write(&js, "json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"pass": false`, `"decision": "deny"`, `"document": "demo"`} {
+		if !strings.Contains(js.String(), s) {
+			t.Errorf("json has no %v:\n%v", s, js.String())
+		}
+	}
+	if err := r.Write(&js, "xml"); err == nil {
+		t.Error("wrote xml, want an error")
+	}
+}
@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report is the outcome of evaluating a policy against an SBOM
+type Report struct {
+	Document     string         `json:"document"`
+	Pass         bool           `json:"pass"`
+	FailOnReview bool           `json:"failOnReview,omitempty"`
+	Summary      map[string]int `json:"summary"`
+	Results      []Result       `json:"results"`
+}
+
+func (r *Report) summarize() {
+	r.Summary = map[string]int{Allow: 0, Review: 0, Deny: 0}
+	for _, res := range r.Results {
+		r.Summary[res.Decision]++
+	}
+	r.Pass = len(r.Violations()) == 0
+}
+
+// Violations are the results that fail the policy: denied licenses, and
+// licenses needing review when FailOnReview is set
+func (r *Report) Violations() []Result {
+	out := make([]Result, 0)
+	for _, res := range r.Results {
+		if res.Decision == Deny || (r.FailOnReview && res.Decision == Review) {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Write renders the report as text or json
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(r, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "text":
+		r.writeText(w)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+func (r *Report) writeText(w io.Writer) {
+	result := "PASS"
+	if !r.Pass {
+		result = "FAIL"
+	}
+	fmt.Fprintf(w, "License policy for %v: %v\n", r.Document, result)
+	fmt.Fprintf(w, "  %v allowed, %v need review, %v denied\n", r.Summary[Allow], r.Summary[Review], r.Summary[Deny])
+	for _, d := range []string{Deny, Review} {
+		first := true
+		for _, res := range r.Results {
+			if res.Decision != d {
+				continue
+			}
+			if first {
+				fmt.Fprintf(w, "\n%v:\n", strings.ToUpper(d))
+				first = false
+			}
+			fmt.Fprintf(w, "  %v@%v [%v]\n", res.Name, res.Version, strings.Join(res.Licenses, "; "))
+			for _, reason := range res.Reasons {
+				fmt.Fprintf(w, "      %v\n", reason)
+			}
+		}
+	}
+}
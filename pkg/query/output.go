@@ -0,0 +1,51 @@
+package query
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+var columns = []string{"chart", "image", "name", "version", "purl", "licenses", "file"}
+
+func (h Hit) row() []string {
+	return []string{h.Chart, h.Image, h.Name, h.Version, h.PURL, strings.Join(h.Licenses, "; "), h.File}
+}
+
+// Write renders the hits as a table, json or csv
+func Write(w io.Writer, hits []Hit, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "\t")
+		return enc.Encode(hits)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(append(columns, "path")); err != nil {
+			return err
+		}
+		for _, h := range hits {
+			if err := cw.Write(append(h.row(), h.Path)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PATH\tPURL\tLICENSES\tFILE")
+		for _, h := range hits {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", h.Path, h.PURL, strings.Join(h.Licenses, "; "), h.File)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\n%v matches\n", len(hits))
+		return err
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
@@ -0,0 +1,195 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/license"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+// Filter selects components. Empty fields match everything; globs (* and ?)
+// are matched case insensitively.
+type Filter struct {
+	// Name is a glob on the component name
+	Name string
+	// PURLType is the PURL type, e.g. deb, apk, npm or maven
+	PURLType string
+	// Versions is a comma separated list of constraints that must all hold,
+	// e.g. ">=1.1.1a, <1.1.1l" or "=1.1.1k"
+	Versions string
+	// License is a glob on the license IDs of the component
+	License string
+	// CPE is a glob on the component's CPEs
+	CPE string
+	// Image is a glob on the names of the images the component is in
+	Image string
+}
+
+type constraint struct {
+	op      string
+	version string
+}
+
+func parseConstraints(s string) ([]constraint, error) {
+	out := make([]constraint, 0)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		c := constraint{op: "="}
+		for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(part, op) {
+				c.op = op
+				part = strings.TrimSpace(part[len(op):])
+				break
+			}
+		}
+		if part == "" {
+			return nil, fmt.Errorf("version constraint %q has no version", s)
+		}
+		c.version = part
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (c constraint) holds(scheme version.Scheme, v string) bool {
+	cmp := version.CompareWith(scheme, v, c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "!=":
+		return cmp != 0
+	}
+	return cmp == 0
+}
+
+// Hit is a matching component, once per image it is in
+type Hit struct {
+	File     string   `json:"file"`
+	Chart    string   `json:"chart"`
+	Image    string   `json:"image,omitempty"`
+	Ref      string   `json:"ref"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	PURL     string   `json:"purl,omitempty"`
+	Licenses []string `json:"licenses,omitempty"`
+	CPEs     []string `json:"cpes,omitempty"`
+	// Path is chart → image → package
+	Path string `json:"path"`
+}
+
+// Search returns the components of the document that match the filter
+func Search(file string, doc *sbom.Document, f Filter) ([]Hit, error) {
+	constraints, err := parseConstraints(f.Versions)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, 0)
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			continue
+		}
+		purlType := ""
+		if p, err := packageurl.FromString(c.PURL); err == nil {
+			purlType = p.Type
+		}
+		switch {
+		case f.Name != "" && !glob(f.Name, c.Name),
+			f.PURLType != "" && !strings.EqualFold(f.PURLType, purlType),
+			f.License != "" && !anyGlob(f.License, licenseIDs(c.Licenses)),
+			f.CPE != "" && !anyGlob(f.CPE, c.CPEs):
+			continue
+		}
+		if !satisfies(constraints, version.SchemeFor(purlType), c.Version) {
+			continue
+		}
+		images := c.Images
+		if len(images) == 0 {
+			images = []string{""}
+		}
+		for _, image := range images {
+			if f.Image != "" && !glob(f.Image, image) {
+				continue
+			}
+			hit := Hit{
+				File:     file,
+				Chart:    doc.Name,
+				Image:    image,
+				Ref:      c.Ref,
+				Name:     c.Name,
+				Version:  c.Version,
+				PURL:     c.PURL,
+				Licenses: c.Licenses,
+				CPEs:     c.CPEs,
+			}
+			parts := []string{doc.Name}
+			if image != "" {
+				parts = append(parts, image)
+			}
+			hit.Path = strings.Join(append(parts, c.Name+"@"+c.Version), " → ")
+			hits = append(hits, hit)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Path < hits[j].Path
+	})
+	return hits, nil
+}
+
+func satisfies(constraints []constraint, scheme version.Scheme, v string) bool {
+	if len(constraints) > 0 && v == "" {
+		return false
+	}
+	for _, c := range constraints {
+		if !c.holds(scheme, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// licenseIDs are the license IDs in the expressions, or the expression
+// itself when it does not parse
+func licenseIDs(expressions []string) []string {
+	ids := make([]string, 0)
+	for _, l := range expressions {
+		e, err := license.Parse(l)
+		if err != nil {
+			ids = append(ids, l)
+			continue
+		}
+		ids = append(ids, e.IDs()...)
+	}
+	return ids
+}
+
+// glob matches * and ? anywhere, unlike path.Match they also match the /
+// in image names and PURLs
+func glob(pattern, s string) bool {
+	re := "(?i)^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern)) + "$"
+	ok, _ := regexp.MatchString(re, s)
+	return ok
+}
+
+func anyGlob(pattern string, list []string) bool {
+	for _, s := range list {
+		if glob(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
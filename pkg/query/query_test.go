@@ -0,0 +1,210 @@
+package query_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/query"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// testDocument has packages of several ecosystems, whose versions only order
+// correctly with their own scheme
+func testDocument() *sbom.Document {
+	nginx, alpine := "docker.io/library/nginx:1.21", "docker.io/library/alpine:3.16"
+	return &sbom.Document{
+		Name: "demo",
+		Components: []sbom.Component{
+			{Ref: "image-nginx", Name: nginx, Type: "container"},
+			{Ref: "image-alpine", Name: alpine, Type: "container"},
+			{Ref: "deb-openssl", Name: "openssl", Version: "1.1.1k-1+deb11u1", PURL: "pkg:deb/debian/openssl@1.1.1k-1+deb11u1", Licenses: []string{"OpenSSL"}, Images: []string{nginx}},
+			{Ref: "deb-libssl", Name: "libssl1.1", Version: "1.1.1n-0+deb11u3", PURL: "pkg:deb/debian/libssl1.1@1.1.1n-0+deb11u3", Licenses: []string{"OpenSSL"}, Images: []string{nginx}},
+			{Ref: "apk-zlib", Name: "zlib", Version: "1.2.12-r1", PURL: "pkg:apk/alpine/zlib@1.2.12-r1", Licenses: []string{"Zlib"}, Images: []string{alpine, nginx}},
+			{Ref: "maven-log4j", Name: "log4j-core", Version: "2.17.0", PURL: "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.0", Licenses: []string{"Apache-2.0"}, Images: []string{alpine}},
+			{Ref: "maven-log4j-rc", Name: "log4j-core", Version: "2.17.1-rc1", PURL: "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1-rc1", Licenses: []string{"Apache-2.0"}, Images: []string{alpine}},
+			{Ref: "npm-lodash", Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15", Licenses: []string{"MIT OR Apache-2.0"}, Images: []string{alpine}},
+			{Ref: "cpe-nginx", Name: "nginx", Version: "1.21.3", CPEs: []string{"cpe:2.3:a:f5:nginx:1.21.3:*:*:*:*:*:*:*"}, Licenses: []string{"BSD-2-Clause"}, Images: []string{nginx}},
+			{Ref: "file-notes", Name: "notes", Licenses: []string{"Some License v2"}},
+		},
+	}
+}
+
+// refs are the refs of the hits, with the image each is in, in the order of
+// their chart → image → package paths
+func refs(hits []query.Hit) []string {
+	out := make([]string, 0)
+	for _, h := range hits {
+		out = append(out, h.Ref+" "+h.Image)
+	}
+	return out
+}
+
+func search(t *testing.T, f query.Filter) []string {
+	t.Helper()
+	hits, err := query.Search("demo.spdx", testDocument(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return refs(hits)
+}
+
+const (
+	nginx  = "docker.io/library/nginx:1.21"
+	alpine = "docker.io/library/alpine:3.16"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter query.Filter
+		want   []string
+	}{
+		{"name", query.Filter{Name: "openssl"}, []string{"deb-openssl " + nginx}},
+		{"name glob", query.Filter{Name: "*SSL*"}, []string{"deb-libssl " + nginx, "deb-openssl " + nginx}},
+		{"name single character", query.Filter{Name: "zli?"}, []string{"apk-zlib " + alpine, "apk-zlib " + nginx}},
+		{"purl type", query.Filter{PURLType: "MAVEN"}, []string{"maven-log4j " + alpine, "maven-log4j-rc " + alpine}},
+		{"license", query.Filter{License: "openssl"}, []string{"deb-libssl " + nginx, "deb-openssl " + nginx}},
+		{"license in expression", query.Filter{License: "Apache-*"}, []string{"npm-lodash " + alpine, "maven-log4j " + alpine, "maven-log4j-rc " + alpine}},
+		{"license not an expression", query.Filter{License: "Some License*"}, []string{"file-notes "}},
+		{"cpe", query.Filter{CPE: "cpe:2.3:a:f5:nginx:*"}, []string{"cpe-nginx " + nginx}},
+		{"image", query.Filter{Image: "*/alpine:*"}, []string{"npm-lodash " + alpine, "maven-log4j " + alpine, "maven-log4j-rc " + alpine, "apk-zlib " + alpine}},
+		{"image and name", query.Filter{Image: "*nginx*", Name: "zlib"}, []string{"apk-zlib " + nginx}},
+		{"no match", query.Filter{Name: "bash"}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := search(t, test.filter); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter query.Filter
+		want   []string
+	}{
+		// >= must be read before >, or it would look for version "=1.1.1k-1+deb11u1"
+		{"at least", query.Filter{PURLType: "deb", Versions: ">=1.1.1k-1+deb11u1"}, []string{"deb-libssl " + nginx, "deb-openssl " + nginx}},
+		{"greater", query.Filter{PURLType: "deb", Versions: "> 1.1.1k-1+deb11u1"}, []string{"deb-libssl " + nginx}},
+		{"at most", query.Filter{PURLType: "deb", Versions: "<=1.1.1k-1+deb11u1"}, []string{"deb-openssl " + nginx}},
+		{"range", query.Filter{PURLType: "deb", Versions: ">=1.1.1a, <1.1.1l"}, []string{"deb-openssl " + nginx}},
+		{"equal without operator", query.Filter{Name: "zlib", Versions: "1.2.12-r1"}, []string{"apk-zlib " + alpine, "apk-zlib " + nginx}},
+		{"not equal", query.Filter{Name: "zlib", Versions: "!=1.2.12-r1"}, []string{}},
+		// apk revisions order after the release, r1 < r2
+		{"apk revision", query.Filter{Name: "zlib", Versions: "<1.2.12-r2"}, []string{"apk-zlib " + alpine, "apk-zlib " + nginx}},
+		// maven release candidates order before the release
+		{"maven qualifier", query.Filter{PURLType: "maven", Versions: "<2.17.1"}, []string{"maven-log4j " + alpine, "maven-log4j-rc " + alpine}},
+		{"semver", query.Filter{PURLType: "npm", Versions: ">=4.17.0, <4.17.19"}, []string{"npm-lodash " + alpine}},
+		{"generic", query.Filter{Name: "nginx", Versions: "<1.21.10"}, []string{"cpe-nginx " + nginx}},
+		// a component without a version can't satisfy a constraint
+		{"no version", query.Filter{Name: "notes", Versions: ">0"}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := search(t, test.filter); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchConstraintErrors(t *testing.T) {
+	for _, versions := range []string{">=", "1.0, <", " = "} {
+		if _, err := query.Search("demo.spdx", testDocument(), query.Filter{Versions: versions}); err == nil {
+			t.Errorf("searched for %q, want an error", versions)
+		}
+	}
+}
+
+func TestSearchGenerated(t *testing.T) {
+	res := generatortest.Generate(t, generatortest.Chart())
+	doc, err := sbom.Parse(res.Encoded[generator.FormatSPDX])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits, err := query.Search("demo.spdx", doc, query.Filter{Name: "openssl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, 0)
+	for _, h := range hits {
+		paths = append(paths, h.Path)
+	}
+	want := []string{
+		"demo → docker.io/library/nginx → openssl@1.1.1k",
+		"demo → docker.io/library/redis → openssl@1.1.1k",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths %v, want %v", paths, want)
+	}
+	if h := hits[0]; h.File != "demo.spdx" || h.Chart != "demo" || h.Ref != "deb-openssl-1.1.1k" || !reflect.DeepEqual(h.Licenses, []string{"OpenSSL"}) {
+		t.Errorf("hit %+v, want openssl of the demo chart", h)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	hits, err := query.Search("demo.spdx", testDocument(), query.Filter{Name: "lodash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits = append(hits, query.Hit{File: "demo.spdx", Chart: "demo", Ref: "file-notes", Name: "notes", Path: "demo → notes@"})
+
+	var table bytes.Buffer
+	if err := query.Write(&table, hits, "table"); err != nil {
+		t.Fatal(err)
+	}
+	wantTable := `PATH                                                   PURL                    LICENSES           FILE
+demo → docker.io/library/alpine:3.16 → lodash@4.17.15  pkg:npm/lodash@4.17.15  MIT OR Apache-2.0  demo.spdx
+demo → notes@                                                                                     demo.spdx
+
+2 matches
+`
+	if table.String() != wantTable {
+		t.Errorf("table\n%v\nwant\n%v", table.String(), wantTable)
+	}
+
+	var js bytes.Buffer
+	if err := query.Write(&js, hits, "json"); err != nil {
+		t.Fatal(err)
+	}
+	decoded := make([]query.Hit, 0)
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, hits) {
+		t.Errorf("json decoded to %+v, want %+v", decoded, hits)
+	}
+	if !strings.Contains(js.String(), "→") {
+		t.Errorf("json escapes the path arrows:\n%v", js.String())
+	}
+
+	var csvOut bytes.Buffer
+	if err := query.Write(&csvOut, hits, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"chart", "image", "name", "version", "purl", "licenses", "file", "path"},
+		{"demo", alpine, "lodash", "4.17.15", "pkg:npm/lodash@4.17.15", "MIT OR Apache-2.0", "demo.spdx", "demo → docker.io/library/alpine:3.16 → lodash@4.17.15"},
+		{"demo", "", "notes", "", "", "", "demo.spdx", "demo → notes@"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("csv %q, want %q", records, want)
+	}
+
+	if err := query.Write(&csvOut, hits, "yaml"); err == nil {
+		t.Error("wrote yaml, want an error")
+	}
+}
@@ -0,0 +1,155 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter/functions"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+// Result is a rule that failed on a node
+type Result struct {
+	RuleID  string `json:"ruleId"`
+	Level   string `json:"level"`
+	Kind    string `json:"kind"`
+	Ref     string `json:"ref"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Engine evaluates compiled rules in process
+type Engine struct {
+	rules    []Rule
+	programs map[string][2]cel.Program
+}
+
+// variables are the names rules use for the node of each kind; package is a
+// reserved word in CEL
+var variables = map[string]string{Chart: Chart, Image: Image, Package: "pkg"}
+
+// env declares the variables and functions rules can use:
+//
+//	chart, image, pkg: maps of the node's fields
+//	compareVersions(a, b) and compareVersions(a, b, scheme): -1, 0 or 1, with
+//	scheme one of generic, deb, rpm, apk, semver or maven
+func env() (*cel.Env, error) {
+	node := decls.NewMapType(decls.String, decls.Dyn)
+	return cel.NewEnv(
+		cel.Declarations(
+			decls.NewVar(Chart, node),
+			decls.NewVar(Image, node),
+			decls.NewVar(variables[Package], node),
+			decls.NewFunction("compareVersions",
+				decls.NewOverload("compareVersions_string_string",
+					[]*exprpb.Type{decls.String, decls.String}, decls.Int),
+				decls.NewOverload("compareVersions_string_string_string",
+					[]*exprpb.Type{decls.String, decls.String, decls.String}, decls.Int),
+			),
+		),
+	)
+}
+
+var celFunctions = []*functions.Overload{
+	{
+		Operator: "compareVersions_string_string",
+		Binary: func(a, b ref.Val) ref.Val {
+			return types.Int(version.Compare(string(a.(types.String)), string(b.(types.String))))
+		},
+	},
+	{
+		Operator: "compareVersions_string_string_string",
+		Function: func(args ...ref.Val) ref.Val {
+			scheme := version.Scheme(args[2].(types.String))
+			return types.Int(version.CompareWith(scheme, string(args[0].(types.String)), string(args[1].(types.String))))
+		},
+	},
+}
+
+// NewEngine compiles the rules, reporting every rule that does not compile
+func NewEngine(rules []Rule) (*Engine, error) {
+	e, err := env()
+	if err != nil {
+		return nil, err
+	}
+	engine := &Engine{rules: rules, programs: make(map[string][2]cel.Program)}
+	for _, r := range rules {
+		var programs [2]cel.Program
+		for i, expr := range []string{r.When, r.Condition} {
+			if expr == "" {
+				continue
+			}
+			ast, issues := e.Compile(expr)
+			if issues != nil && issues.Err() != nil {
+				return nil, fmt.Errorf("rule %v: %w", r.ID, issues.Err())
+			}
+			if t := ast.ResultType(); t.GetPrimitive() != exprpb.Type_BOOL && t.GetDyn() == nil {
+				return nil, fmt.Errorf("rule %v: %q must be a bool, not %v", r.ID, expr, cel.FormatType(ast.ResultType()))
+			}
+			programs[i], err = e.Program(ast, cel.Functions(celFunctions...))
+			if err != nil {
+				return nil, fmt.Errorf("rule %v: %w", r.ID, err)
+			}
+		}
+		engine.programs[r.ID] = programs
+	}
+	return engine, nil
+}
+
+// Evaluate runs every rule on the nodes of its kind and returns the failures.
+// A rule that cannot be evaluated on a node (e.g. a missing annotation) fails
+// on it, with the error as the message.
+func (e *Engine) Evaluate(doc *sbom.Document, chart *ChartInfo) []Result {
+	all := nodes(doc, chart)
+	chartVars := all[0].vars
+	results := make([]Result, 0)
+	for _, r := range e.rules {
+		programs := e.programs[r.ID]
+		for _, n := range all {
+			if n.kind != r.On {
+				continue
+			}
+			input := map[string]interface{}{Chart: chartVars, variables[n.kind]: n.vars}
+			if programs[0] != nil {
+				ok, err := evalBool(programs[0], input)
+				if err != nil {
+					results = append(results, failure(r, n, fmt.Sprintf("%v: when: %v", n.label(), err)))
+					continue
+				}
+				if !ok {
+					continue
+				}
+			}
+			ok, err := evalBool(programs[1], input)
+			switch {
+			case err != nil:
+				results = append(results, failure(r, n, fmt.Sprintf("%v: %v", n.label(), err)))
+			case !ok:
+				results = append(results, failure(r, n, r.message(n)))
+			}
+		}
+	}
+	return results
+}
+
+func evalBool(p cel.Program, input map[string]interface{}) (bool, error) {
+	out, _, err := p.Eval(input)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("result is %v, not a bool", out.Type())
+	}
+	return b, nil
+}
+
+func failure(r Rule, n node, message string) Result {
+	return Result{RuleID: r.ID, Level: r.Level, Kind: n.kind, Ref: n.ref, Name: n.name, Message: message}
+}
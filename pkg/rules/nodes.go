@@ -0,0 +1,149 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// ChartInfo is what the rules can see of the chart beyond the SBOM, from
+// pkg/helm when the chart is at hand
+type ChartInfo struct {
+	Name        string
+	Version     string
+	Annotations map[string]string
+	CPEs        []string
+}
+
+// node is something a rule is evaluated on, with the variables CEL sees
+type node struct {
+	kind string
+	ref  string
+	name string
+	vars map[string]interface{}
+}
+
+func (n node) label() string {
+	return fmt.Sprintf("%v %v", n.kind, n.name)
+}
+
+// nodes builds the chart node, then a node for each image and package in the
+// document
+func nodes(doc *sbom.Document, chart *ChartInfo) []node {
+	if chart == nil {
+		chart = &ChartInfo{Name: doc.Name}
+	}
+	annotations := make(map[string]interface{})
+	for k, v := range chart.Annotations {
+		annotations[k] = v
+	}
+	images := make([]interface{}, 0)
+	packagesIn := make(map[string][]interface{})
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			images = append(images, c.Name)
+			continue
+		}
+		for _, image := range c.Images {
+			packagesIn[image] = append(packagesIn[image], map[string]interface{}{
+				"name":    c.Name,
+				"version": c.Version,
+				"purl":    c.PURL,
+			})
+		}
+	}
+	name := chart.Name
+	if name == "" {
+		name = doc.Name
+	}
+	chartVars := map[string]interface{}{
+		"name":        name,
+		"version":     chart.Version,
+		"annotations": annotations,
+		"images":      images,
+		"cpes":        strings2i(chart.CPEs),
+	}
+	out := []node{{kind: Chart, ref: doc.Root, name: name, vars: chartVars}}
+
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			ref := ParseImageRef(c.Name)
+			packages := packagesIn[c.Name]
+			if packages == nil {
+				packages = make([]interface{}, 0)
+			}
+			out = append(out, node{kind: Image, ref: c.Ref, name: c.Name, vars: map[string]interface{}{
+				"ref":        c.Ref,
+				"name":       c.Name,
+				"registry":   ref.Registry,
+				"repository": ref.Repository,
+				"tag":        ref.Tag,
+				"digest":     ref.Digest,
+				"packages":   packages,
+			}})
+			continue
+		}
+		purlType := ""
+		if p, err := packageurl.FromString(c.PURL); err == nil {
+			purlType = p.Type
+		}
+		out = append(out, node{kind: Package, ref: c.Ref, name: c.Name + "@" + c.Version, vars: map[string]interface{}{
+			"ref":      c.Ref,
+			"name":     c.Name,
+			"version":  c.Version,
+			"type":     c.Type,
+			"purl":     c.PURL,
+			"purlType": purlType,
+			"cpes":     strings2i(c.CPEs),
+			"licenses": strings2i(c.Licenses),
+			"supplier": c.Supplier,
+			"images":   strings2i(c.Images),
+		}})
+	}
+	return out
+}
+
+func strings2i(list []string) []interface{} {
+	out := make([]interface{}, 0, len(list))
+	for _, s := range list {
+		out = append(out, s)
+	}
+	return out
+}
+
+// ImageRef is an image reference split into its parts
+type ImageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageRef splits registry/repository:tag@digest, defaulting the registry
+// to docker.io the way docker does
+func ParseImageRef(image string) ImageRef {
+	ref := ImageRef{}
+	if i := strings.Index(image, "@"); i >= 0 {
+		ref.Digest = image[i+1:]
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		ref.Tag = image[i+1:]
+		image = image[:i]
+	}
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = "docker.io"
+		ref.Repository = image
+		if len(parts) == 1 {
+			ref.Repository = "library/" + image
+		}
+	}
+	return ref
+}
@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Kinds of node a rule applies to
+const (
+	Chart   = "chart"
+	Image   = "image"
+	Package = "package"
+)
+
+// Levels a rule reports at, the SARIF levels
+const (
+	Error   = "error"
+	Warning = "warning"
+	Note    = "note"
+)
+
+// Rule is a CEL assertion over every node of a kind. Condition must be true
+// for the node to pass; When, if set, limits the rule to the nodes it is true
+// for. Both see the node (as chart, image or package) and the chart.
+type Rule struct {
+	ID          string `yaml:"id"`
+	Description string `yaml:"description"`
+	On          string `yaml:"on"`
+	Level       string `yaml:"level"`
+	When        string `yaml:"when"`
+	Condition   string `yaml:"condition"`
+	Message     string `yaml:"message"`
+	HelpURI     string `yaml:"helpUri"`
+}
+
+// File is a YAML file of rules
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Load reads rules from YAML files, or directories of them
+func Load(paths []string) ([]Rule, error) {
+	rules := make([]Rule, 0)
+	seen := make(map[string]string)
+	for _, p := range paths {
+		files := []string{p}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			files = make([]string, 0)
+			for _, pattern := range []string{"*.yaml", "*.yml"} {
+				matches, _ := filepath.Glob(filepath.Join(p, pattern))
+				files = append(files, matches...)
+			}
+		}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			rf := File{}
+			if err := yaml.UnmarshalStrict(b, &rf); err != nil {
+				return nil, fmt.Errorf("%v: %w", f, err)
+			}
+			for _, r := range rf.Rules {
+				if err := r.validate(); err != nil {
+					return nil, fmt.Errorf("%v: %w", f, err)
+				}
+				if other, ok := seen[r.ID]; ok {
+					return nil, fmt.Errorf("%v: rule %v is already defined in %v", f, r.ID, other)
+				}
+				seen[r.ID] = f
+				if r.Level == "" {
+					r.Level = Error
+				}
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules, nil
+}
+
+func (r Rule) validate() error {
+	switch {
+	case r.ID == "":
+		return fmt.Errorf("rule without an id")
+	case r.Condition == "":
+		return fmt.Errorf("rule %v has no condition", r.ID)
+	}
+	switch r.On {
+	case Chart, Image, Package:
+	default:
+		return fmt.Errorf("rule %v: on must be one of chart, image or package, not %q", r.ID, r.On)
+	}
+	switch r.Level {
+	case "", Error, Warning, Note:
+	default:
+		return fmt.Errorf("rule %v: level must be one of error, warning or note, not %q", r.ID, r.Level)
+	}
+	return nil
+}
+
+// message is the rule's message for a failing node
+func (r Rule) message(n node) string {
+	msg := r.Message
+	if msg == "" {
+		msg = r.Description
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("%v does not hold", r.Condition)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v: %v", n.label(), msg))
+}
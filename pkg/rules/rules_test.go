@@ -0,0 +1,314 @@
+package rules_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/rules"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// testDocument has an image from registry1 pinned by digest, one from Docker
+// Hub that isn't, and three versions of log4j-core, one without a PURL
+func testDocument() *sbom.Document {
+	registry1 := "registry1.dso.mil/ironbank/opensource/nginx/nginx:1.21@sha256:0123456789abcdef"
+	hub := "docker.io/library/tomcat:9"
+	return &sbom.Document{
+		Name: "demo",
+		Root: "chart-demo",
+		Components: []sbom.Component{
+			{Ref: "image-registry1", Name: registry1, Type: "container"},
+			{Ref: "image-hub", Name: hub, Type: "container"},
+			{Ref: "maven-log4j-2.14.1", Name: "log4j-core", Version: "2.14.1", PURL: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", Images: []string{hub}},
+			{Ref: "maven-log4j-2.17.1", Name: "log4j-core", Version: "2.17.1", PURL: "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1", Images: []string{hub}},
+			{Ref: "file-log4j-2.3", Name: "log4j-core", Version: "2.3", Images: []string{hub}},
+			{Ref: "deb-openssl", Name: "openssl", Version: "1.1.1k", PURL: "pkg:deb/debian/openssl@1.1.1k", Images: []string{registry1}},
+		},
+	}
+}
+
+func evaluate(t *testing.T, loaded []rules.Rule, doc *sbom.Document, chart *rules.ChartInfo) []rules.Result {
+	t.Helper()
+	engine, err := rules.NewEngine(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine.Evaluate(doc, chart)
+}
+
+func TestExampleRules(t *testing.T) {
+	loaded, err := rules.Load([]string{"testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := evaluate(t, loaded, testDocument(), nil)
+	want := []rules.Result{
+		{RuleID: "registry1-only", Level: rules.Error, Kind: rules.Image, Ref: "image-hub", Name: "docker.io/library/tomcat:9", Message: "image docker.io/library/tomcat:9: Images must come from registry1.dso.mil"},
+		{RuleID: "pinned-by-digest", Level: rules.Warning, Kind: rules.Image, Ref: "image-hub", Name: "docker.io/library/tomcat:9", Message: `image docker.io/library/tomcat:9: image.digest != "" does not hold`},
+		{RuleID: "has-purl", Level: rules.Note, Kind: rules.Package, Ref: "file-log4j-2.3", Name: "log4j-core@2.3", Message: "package log4j-core@2.3: Packages need a PURL to be matched against vulnerabilities"},
+		// openssl is older than 2.17.1 too, but when leaves it out
+		{RuleID: "log4shell", Level: rules.Error, Kind: rules.Package, Ref: "maven-log4j-2.14.1", Name: "log4j-core@2.14.1", Message: "package log4j-core@2.14.1: log4j-core before 2.17.1 is vulnerable to Log4Shell"},
+		{RuleID: "log4shell", Level: rules.Error, Kind: rules.Package, Ref: "file-log4j-2.3", Name: "log4j-core@2.3", Message: "package log4j-core@2.3: log4j-core before 2.17.1 is vulnerable to Log4Shell"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestNodeVariables(t *testing.T) {
+	loaded := []rules.Rule{
+		{ID: "image-parts", On: rules.Image, Level: rules.Error, When: `image.registry == "registry1.dso.mil"`,
+			Condition: `image.repository == "ironbank/opensource/nginx/nginx" && image.tag == "1.21" && image.digest == "sha256:0123456789abcdef"`},
+		{ID: "image-packages", On: rules.Image, Level: rules.Error,
+			Condition: `image.packages.all(p, p.name != "openssl" || p.purl.startsWith("pkg:deb/"))`},
+		{ID: "purl-type", On: rules.Package, Level: rules.Error, When: `pkg.purl != ""`,
+			Condition: `pkg.purlType in ["maven", "deb"]`},
+		{ID: "chart-images", On: rules.Chart, Level: rules.Error,
+			Condition: `chart.name == "demo" && size(chart.images) == 2`},
+		{ID: "package-sees-chart", On: rules.Package, Level: rules.Error,
+			Condition: `chart.name == "demo"`},
+		{ID: "default-scheme", On: rules.Package, Level: rules.Error, When: `pkg.name == "openssl"`,
+			Condition: `compareVersions(pkg.version, "1.1.0") > 0`},
+	}
+	if got := evaluate(t, loaded, testDocument(), nil); len(got) != 0 {
+		t.Errorf("results %+v, want every rule to pass", got)
+	}
+}
+
+func TestChartAnnotations(t *testing.T) {
+	loaded := []rules.Rule{{
+		ID:        "approved",
+		On:        rules.Chart,
+		Level:     rules.Error,
+		Condition: `"example.com/approved-by" in chart.annotations && chart.annotations["example.com/approved-by"] != ""`,
+	}, {
+		ID:        "chart-cpe",
+		On:        rules.Chart,
+		Level:     rules.Warning,
+		Condition: `chart.cpes.exists(c, c.startsWith("cpe:2.3:a:example:demo:"))`,
+	}}
+	chart := &rules.ChartInfo{
+		Name:        "demo",
+		Version:     "0.1.0",
+		Annotations: map[string]string{"example.com/approved-by": "security"},
+		CPEs:        []string{"cpe:2.3:a:example:demo:0.1.0:*:*:*:*:*:*:*"},
+	}
+	if got := evaluate(t, loaded, testDocument(), chart); len(got) != 0 {
+		t.Errorf("results %+v, want the annotation and CPE to pass", got)
+	}
+	// without the chart at hand only the SBOM's name is known
+	got := evaluate(t, loaded, testDocument(), nil)
+	if len(got) != 2 || got[0].RuleID != "approved" || got[0].Ref != "chart-demo" || got[0].Name != "demo" {
+		t.Errorf("results %+v, want both chart rules to fail on chart-demo", got)
+	}
+}
+
+func TestEvaluationErrors(t *testing.T) {
+	loaded := []rules.Rule{{
+		ID:        "missing-annotation",
+		On:        rules.Chart,
+		Level:     rules.Error,
+		Condition: `chart.annotations["example.com/team"] == "platform"`,
+	}, {
+		ID:        "missing-when",
+		On:        rules.Chart,
+		Level:     rules.Error,
+		When:      `chart.annotations["example.com/team"] == "platform"`,
+		Condition: `true`,
+	}}
+	got := evaluate(t, loaded, testDocument(), nil)
+	if len(got) != 2 {
+		t.Fatalf("results %+v, want both rules to fail", got)
+	}
+	if !strings.HasPrefix(got[0].Message, "chart demo: ") || !strings.Contains(got[0].Message, "no such key") {
+		t.Errorf("message %q, want the evaluation error", got[0].Message)
+	}
+	if !strings.HasPrefix(got[1].Message, "chart demo: when: ") {
+		t.Errorf("message %q, want the when error", got[1].Message)
+	}
+}
+
+func TestNewEngineErrors(t *testing.T) {
+	for name, rule := range map[string]rules.Rule{
+		"syntax":        {ID: "syntax", On: rules.Image, Condition: `image.registry ==`},
+		"unknown":       {ID: "unknown", On: rules.Image, Condition: `container.registry == ""`},
+		"not a bool":    {ID: "not-a-bool", On: rules.Image, Condition: `image.registry + "/"`},
+		"when":          {ID: "when", On: rules.Image, When: `size("x")`, Condition: `true`},
+		"compare types": {ID: "compare-types", On: rules.Package, Condition: `compareVersions(1, 2) == 0`},
+	} {
+		if _, err := rules.NewEngine([]rules.Rule{rule}); err == nil || !strings.Contains(err.Error(), rule.ID) {
+			t.Errorf("%v: got %v, want an error naming the rule", name, err)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	valid := write("valid.yaml", "rules:\n  - id: pinned\n    on: image\n    condition: image.digest != \"\"\n")
+
+	loaded, err := rules.Load([]string{valid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].Level != rules.Error {
+		t.Errorf("loaded %+v, want one rule defaulting to error", loaded)
+	}
+
+	tests := map[string]string{
+		"duplicate id":  "rules:\n  - id: pinned\n    on: image\n    condition: \"true\"\n",
+		"invalid level": "rules:\n  - id: fatal\n    on: image\n    level: fatal\n    condition: \"true\"\n",
+		"invalid on":    "rules:\n  - id: on-file\n    on: file\n    condition: \"true\"\n",
+		"no id":         "rules:\n  - on: image\n    condition: \"true\"\n",
+		"no condition":  "rules:\n  - id: empty\n    on: image\n",
+		"unknown field": "rules:\n  - id: typo\n    on: image\n    conditon: \"true\"\n",
+	}
+	for name, content := range tests {
+		bad := write("bad.yaml", content)
+		if _, err := rules.Load([]string{valid, bad}); err == nil {
+			t.Errorf("%v: loaded %q, want an error", name, content)
+		}
+	}
+	if _, err := rules.Load([]string{valid, valid}); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("loading a file twice got %v, want a duplicate id error", err)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	loaded, err := rules.Load([]string{"testdata/rules.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &rules.Report{Input: "demo.spdx", Rules: loaded, Results: evaluate(t, loaded, testDocument(), nil)}
+	var buf bytes.Buffer
+	if err := report.Write(&buf, "sarif"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `\u003e`) {
+		t.Error("sarif escapes > in conditions")
+	}
+
+	var log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID                   string `json:"id"`
+						ShortDescription     struct{ Text string }
+						HelpURI              string `json:"helpUri"`
+						DefaultConfiguration struct{ Level string }
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+					}
+					LogicalLocations []struct {
+						Name               string
+						FullyQualifiedName string
+						Kind               string
+					}
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || !strings.Contains(log.Schema, "sarif-2.1.0") || len(log.Runs) != 1 {
+		t.Fatalf("sarif %v with schema %v and %d runs, want one SARIF 2.1.0 run", log.Version, log.Schema, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "sbom-cli" || len(run.Tool.Driver.Rules) != 4 {
+		t.Fatalf("driver %+v, want sbom-cli with the four rules", run.Tool.Driver)
+	}
+	registry1, pinned := run.Tool.Driver.Rules[0], run.Tool.Driver.Rules[1]
+	if registry1.ShortDescription.Text != "Images must come from registry1.dso.mil" || registry1.HelpURI != "https://registry1.dso.mil" {
+		t.Errorf("rule %+v, want its description and help", registry1)
+	}
+	// rules without a description describe themselves with their condition
+	if pinned.ShortDescription.Text != `image.digest != ""` || pinned.DefaultConfiguration.Level != rules.Warning {
+		t.Errorf("rule %+v, want the condition and warning level", pinned)
+	}
+
+	if len(run.Results) != 5 {
+		t.Fatalf("%d results, want 5", len(run.Results))
+	}
+	for _, res := range run.Results {
+		if run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID {
+			t.Errorf("result of %v has the index of %v", res.RuleID, run.Tool.Driver.Rules[res.RuleIndex].ID)
+		}
+		if len(res.Locations) != 1 || res.Locations[0].PhysicalLocation.ArtifactLocation.URI != "demo.spdx" {
+			t.Errorf("result %+v, want a location in demo.spdx", res)
+		}
+	}
+	log4shell := run.Results[3]
+	location := log4shell.Locations[0].LogicalLocations[0]
+	if log4shell.Level != rules.Error || location.FullyQualifiedName != "maven-log4j-2.14.1" || location.Name != "log4j-core@2.14.1" || location.Kind != "package" {
+		t.Errorf("result %+v at %+v, want an error at package maven-log4j-2.14.1", log4shell, location)
+	}
+	if kind := run.Results[0].Locations[0].LogicalLocations[0].Kind; kind != "module" {
+		t.Errorf("image result kind %v, want module", kind)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	loaded := []rules.Rule{{ID: "pinned", On: rules.Image, Level: rules.Warning, Condition: `image.digest != ""`}}
+	report := &rules.Report{Input: "demo.spdx", Rules: loaded, Results: evaluate(t, loaded, testDocument(), nil)}
+	var buf bytes.Buffer
+	if err := report.Write(&buf, "text"); err != nil {
+		t.Fatal(err)
+	}
+	want := "warning pinned: image docker.io/library/tomcat:9: image.digest != \"\" does not hold\n"
+	if buf.String() != want {
+		t.Errorf("text %q, want %q", buf.String(), want)
+	}
+	if len(report.Errors()) != 0 {
+		t.Errorf("errors %+v, want none for a warning", report.Errors())
+	}
+
+	buf.Reset()
+	report.Results = nil
+	if err := report.Write(&buf, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "demo.spdx: all 1 rules pass\n"; buf.String() != want {
+		t.Errorf("text %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	tests := map[string]rules.ImageRef{
+		"nginx":                                   {Registry: "docker.io", Repository: "library/nginx"},
+		"bitnami/redis:6.2":                       {Registry: "docker.io", Repository: "bitnami/redis", Tag: "6.2"},
+		"localhost/app:1":                         {Registry: "localhost", Repository: "app", Tag: "1"},
+		"localhost:5000/app":                      {Registry: "localhost:5000", Repository: "app"},
+		"ghcr.io/org/app:v1@sha256:abc":           {Registry: "ghcr.io", Repository: "org/app", Tag: "v1", Digest: "sha256:abc"},
+		"registry1.dso.mil/ironbank/x@sha256:abc": {Registry: "registry1.dso.mil", Repository: "ironbank/x", Digest: "sha256:abc"},
+	}
+	for image, want := range tests {
+		if got := rules.ParseImageRef(image); got != want {
+			t.Errorf("%v parsed to %+v, want %+v", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The parts of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/) code
+// scanning dashboards read
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// Report is the outcome of evaluating rules on an SBOM
+type Report struct {
+	Input   string   `json:"input"`
+	Rules   []Rule   `json:"-"`
+	Results []Result `json:"results"`
+}
+
+// Errors are the results at error level
+func (r *Report) Errors() []Result {
+	out := make([]Result, 0)
+	for _, res := range r.Results {
+		if res.Level == Error {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Write renders the report as sarif, json or text
+func (r *Report) Write(w io.Writer, format string) error {
+	var v interface{}
+	switch format {
+	case "sarif":
+		v = r.sarif()
+	case "json":
+		v = r
+	case "text":
+		if len(r.Results) == 0 {
+			_, err := fmt.Fprintf(w, "%v: all %v rules pass\n", r.Input, len(r.Rules))
+			return err
+		}
+		for _, res := range r.Results {
+			if _, err := fmt.Fprintf(w, "%-7v %v: %v\n", res.Level, res.RuleID, res.Message); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %v", format)
+	}
+	// conditions are full of < and >, keep them readable
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (r *Report) sarif() sarifLog {
+	driver := sarifDriver{
+		Name:           "sbom-cli",
+		InformationURI: "https://github.com/defenseunicorns/spdx-cli",
+		Rules:          make([]sarifRule, 0),
+	}
+	index := make(map[string]int)
+	for i, rule := range r.Rules {
+		index[rule.ID] = i
+		description := rule.Description
+		if description == "" {
+			description = rule.Condition
+		}
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifText{Text: description},
+			HelpURI:              rule.HelpURI,
+			DefaultConfiguration: sarifConfiguration{Level: rule.Level},
+		})
+	}
+	results := make([]sarifResult, 0)
+	for _, res := range r.Results {
+		kind := "module"
+		if res.Kind == Package {
+			kind = "package"
+		}
+		results = append(results, sarifResult{
+			RuleID:    res.RuleID,
+			RuleIndex: index[res.RuleID],
+			Level:     res.Level,
+			Message:   sarifText{Text: res.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: r.Input}},
+				LogicalLocations: []sarifLogicalLocation{{Name: res.Name, FullyQualifiedName: res.Ref, Kind: kind}},
+			}},
+		})
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
@@ -1,10 +1,15 @@
 package sbom
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 
 	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/jsonloader"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tvloader"
 )
 
 func ReadCycloneDX(filename string) (*cyclonedx.BOM, error) {
@@ -40,6 +45,7 @@ func WriteCycloneDX(filename string, bom *cyclonedx.BOM) error {
 func MergeCycloneDX(boms []*cyclonedx.BOM, name string) (*cyclonedx.BOM, error) {
 	meta := &cyclonedx.Metadata{
 		Component: &cyclonedx.Component{
+			Type: cyclonedx.ComponentTypeApplication,
 			Name: name,
 		},
 		// Tools:     "sbom-cli",
@@ -50,6 +56,9 @@ func MergeCycloneDX(boms []*cyclonedx.BOM, name string) (*cyclonedx.BOM, error)
 	mComponents := make([]cyclonedx.Component, 0)
 
 	for _, bom := range boms {
+		if bom.Components == nil {
+			continue
+		}
 		for _, c := range *bom.Components {
 			if !present(mComponents, c) {
 				fmt.Printf("Adding new component: %v\n", c.Name)
@@ -80,3 +89,121 @@ func present(current []cyclonedx.Component, new cyclonedx.Component) bool {
 
 	return false
 }
+
+// AddCycloneDXDependency nests leaf inside root: the leaf's metadata component
+// and its components are added as components of root, the leaf is recorded as
+// a dependency of parentRef and the leaf's own dependency graph is carried over.
+func AddCycloneDXDependency(root *cyclonedx.BOM, leaf *cyclonedx.BOM, parentRef string) error {
+	if leaf.Metadata == nil || leaf.Metadata.Component == nil {
+		return fmt.Errorf("BOM to add does not have a metadata component")
+	}
+	var comp []cyclonedx.Component
+	if root.Components == nil {
+		comp = make([]cyclonedx.Component, 0)
+	} else {
+		comp = *root.Components
+	}
+	// Add the component in the leaf's metadata as a component
+	comp = append(comp, *leaf.Metadata.Component)
+	// Add each component in the leaf bom as a component
+	if leaf.Components != nil {
+		comp = append(comp, *leaf.Components...)
+	}
+	root.Components = &comp
+
+	var rootDeps []cyclonedx.Dependency
+	if root.Dependencies != nil {
+		rootDeps = *root.Dependencies
+	}
+	rootDeps = addCycloneDXEdge(rootDeps, parentRef, leaf.Metadata.Component.BOMRef)
+
+	// add the dependency object of the leaf as a dependency of the top level
+	rootDeps = append(rootDeps, cyclonedx.Dependency{
+		Ref:          leaf.Metadata.Component.BOMRef,
+		Dependencies: leafDependenciesOf(leaf),
+	})
+	// and keep the rest of the leaf's graph (images -> packages, etc.)
+	if leaf.Dependencies != nil {
+		for _, dep := range *leaf.Dependencies {
+			if dep.Ref != leaf.Metadata.Component.BOMRef {
+				rootDeps = append(rootDeps, dep)
+			}
+		}
+	}
+	root.Dependencies = &rootDeps
+	return nil
+}
+
+// addCycloneDXEdge records that from depends on to, adding an entry for from if
+// it does not have one yet
+func addCycloneDXEdge(deps []cyclonedx.Dependency, from string, to string) []cyclonedx.Dependency {
+	for i, dep := range deps {
+		if dep.Ref != from {
+			continue
+		}
+		var tmp []cyclonedx.Dependency
+		if dep.Dependencies != nil {
+			tmp = *dep.Dependencies
+		}
+		tmp = append(tmp, cyclonedx.Dependency{Ref: to})
+		deps[i].Dependencies = &tmp
+		return deps
+	}
+	return append(deps, cyclonedx.Dependency{
+		Ref:          from,
+		Dependencies: &[]cyclonedx.Dependency{{Ref: to}},
+	})
+}
+
+// leafDependenciesOf returns the direct dependencies of the leaf's metadata component
+func leafDependenciesOf(leaf *cyclonedx.BOM) *[]cyclonedx.Dependency {
+	if leaf.Dependencies == nil {
+		return nil
+	}
+	for _, dep := range *leaf.Dependencies {
+		if dep.Ref == leaf.Metadata.Component.BOMRef {
+			return dep.Dependencies
+		}
+	}
+	return nil
+}
+
+// LoadCycloneDX reads an SBOM in any of the supported formats as a CycloneDX
+// BOM, converting SPDX documents with ToCycloneDX
+func LoadCycloneDX(filename string) (*cyclonedx.BOM, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCycloneDX(b)
+}
+
+// ParseCycloneDX is LoadCycloneDX for an SBOM already in memory
+func ParseCycloneDX(b []byte) (*cyclonedx.BOM, error) {
+	format, err := DetectFormat(b)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatSPDX, FormatSPDXJSON:
+		var spdxDoc *spdx.Document2_2
+		if format == FormatSPDX {
+			spdxDoc, err = tvloader.Load2_2(bytes.NewReader(b))
+		} else {
+			spdxDoc, err = jsonloader.Load2_2(bytes.NewReader(b))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return ToCycloneDX(spdxDoc), nil
+	}
+	fileFormat := cyclonedx.BOMFileFormatXML
+	if format == FormatCycloneDXJSON {
+		fileFormat = cyclonedx.BOMFileFormatJSON
+	}
+	bom := &cyclonedx.BOM{}
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(b), fileFormat).Decode(bom); err != nil {
+		return nil, err
+	}
+	return bom, nil
+}
@@ -0,0 +1,439 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/license"
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+// ComponentChange is a component that was added, removed or changed between two SBOMs
+type ComponentChange struct {
+	Image       string   `json:"image,omitempty"`
+	Name        string   `json:"name"`
+	OldVersion  string   `json:"oldVersion,omitempty"`
+	NewVersion  string   `json:"newVersion,omitempty"`
+	OldLicenses []string `json:"oldLicenses,omitempty"`
+	NewLicenses []string `json:"newLicenses,omitempty"`
+	Downgrade   bool     `json:"downgrade,omitempty"`
+}
+
+// Edge is a dependency between two components, named by component name
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diff is the difference between two SBOMs
+type Diff struct {
+	Old                 string            `json:"old"`
+	New                 string            `json:"new"`
+	Added               []ComponentChange `json:"added"`
+	Removed             []ComponentChange `json:"removed"`
+	VersionChanges      []ComponentChange `json:"versionChanges"`
+	LicenseChanges      []ComponentChange `json:"licenseChanges"`
+	AddedDependencies   []Edge            `json:"addedDependencies"`
+	RemovedDependencies []Edge            `json:"removedDependencies"`
+}
+
+// Compare works out what changed between oldDoc and newDoc, image by image
+func Compare(oldDoc *Document, newDoc *Document) *Diff {
+	d := &Diff{
+		Old:                 oldDoc.Name,
+		New:                 newDoc.Name,
+		Added:               make([]ComponentChange, 0),
+		Removed:             make([]ComponentChange, 0),
+		VersionChanges:      make([]ComponentChange, 0),
+		LicenseChanges:      make([]ComponentChange, 0),
+		AddedDependencies:   make([]Edge, 0),
+		RemovedDependencies: make([]Edge, 0),
+	}
+	oldGroups, newGroups := groupComponents(oldDoc), groupComponents(newDoc)
+
+	for _, key := range unionKeys(oldGroups, newGroups) {
+		before, after := oldGroups[key], newGroups[key]
+		switch {
+		case len(before) == 0:
+			for _, c := range after {
+				d.Added = append(d.Added, change(key, nil, &c))
+			}
+		case len(after) == 0:
+			for _, c := range before {
+				d.Removed = append(d.Removed, change(key, &c, nil))
+			}
+		case len(before) == 1 && len(after) == 1:
+			ch := change(key, &before[0], &after[0])
+			if before[0].Version != after[0].Version {
+				ch.Downgrade = version.Compare(after[0].Version, before[0].Version) < 0
+				d.VersionChanges = append(d.VersionChanges, ch)
+			}
+			if !sameStrings(before[0].Licenses, after[0].Licenses) {
+				d.LicenseChanges = append(d.LicenseChanges, ch)
+			}
+		default:
+			// several versions of the same thing, match them up by version
+			for _, c := range after {
+				b := withVersion(before, c.Version)
+				switch {
+				case b == nil:
+					d.Added = append(d.Added, change(key, nil, &c))
+				case !sameStrings(b.Licenses, c.Licenses):
+					d.LicenseChanges = append(d.LicenseChanges, change(key, b, &c))
+				}
+			}
+			for _, c := range before {
+				if !hasVersion(after, c.Version) {
+					d.Removed = append(d.Removed, change(key, &c, nil))
+				}
+			}
+		}
+	}
+
+	oldEdges, newEdges := edges(oldDoc), edges(newDoc)
+	for e := range newEdges {
+		if !oldEdges[e] {
+			d.AddedDependencies = append(d.AddedDependencies, e)
+		}
+	}
+	for e := range oldEdges {
+		if !newEdges[e] {
+			d.RemovedDependencies = append(d.RemovedDependencies, e)
+		}
+	}
+	sortEdges(d.AddedDependencies)
+	sortEdges(d.RemovedDependencies)
+	return d
+}
+
+// groupKey identifies the "same" component across two SBOMs
+type groupKey struct {
+	image string
+	name  string
+}
+
+func groupComponents(doc *Document) map[groupKey][]Component {
+	groups := make(map[groupKey][]Component)
+	for _, c := range doc.Components {
+		images := c.Images
+		if len(images) == 0 {
+			images = []string{""}
+		}
+		for _, image := range images {
+			k := groupKey{image: image, name: c.Name}
+			if !hasVersion(groups[k], c.Version) {
+				groups[k] = append(groups[k], c)
+			}
+		}
+	}
+	return groups
+}
+
+func unionKeys(a, b map[groupKey][]Component) []groupKey {
+	seen := make(map[groupKey]bool)
+	keys := make([]groupKey, 0)
+	for _, m := range []map[groupKey][]Component{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].image != keys[j].image {
+			return keys[i].image < keys[j].image
+		}
+		return keys[i].name < keys[j].name
+	})
+	return keys
+}
+
+func change(key groupKey, before *Component, after *Component) ComponentChange {
+	ch := ComponentChange{Image: key.image, Name: key.name}
+	if before != nil {
+		ch.OldVersion = before.Version
+		ch.OldLicenses = before.Licenses
+	}
+	if after != nil {
+		ch.NewVersion = after.Version
+		ch.NewLicenses = after.Licenses
+	}
+	return ch
+}
+
+func hasVersion(list []Component, v string) bool {
+	return withVersion(list, v) != nil
+}
+
+// withVersion returns the component in the list at version v
+func withVersion(list []Component, v string) *Component {
+	for i := range list {
+		if list[i].Version == v {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]string{}, a...)
+	y := append([]string{}, b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// edges returns the dependency graph with refs swapped for component names, refs
+// embed versions and so would make every edge look changed
+func edges(doc *Document) map[Edge]bool {
+	names := make(map[string]string)
+	for _, c := range doc.Components {
+		names[c.Ref] = c.Name
+	}
+	name := func(ref string) string {
+		if ref == doc.Root {
+			return doc.Name
+		}
+		if n, ok := names[ref]; ok {
+			return n
+		}
+		return ref
+	}
+	out := make(map[Edge]bool)
+	for from, tos := range doc.Dependencies {
+		for _, to := range tos {
+			out[Edge{From: name(from), To: name(to)}] = true
+		}
+	}
+	return out
+}
+
+func sortEdges(e []Edge) {
+	sort.Slice(e, func(i, j int) bool {
+		if e[i].From != e[j].From {
+			return e[i].From < e[j].From
+		}
+		return e[i].To < e[j].To
+	})
+}
+
+// Empty reports whether nothing changed
+func (d *Diff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.VersionChanges)+len(d.LicenseChanges)+
+		len(d.AddedDependencies)+len(d.RemovedDependencies) == 0
+}
+
+// Failures evaluates the --fail-on conditions against the diff and returns a
+// description of each one that was hit. Supported conditions are:
+//
+//	added, removed, version-change, downgrade, license-change, dependency-change
+//	license:<glob>   a license matching the glob (e.g. GPL-*) was introduced
+func (d *Diff) Failures(conditions []string) ([]string, error) {
+	failures := make([]string, 0)
+	for _, cond := range conditions {
+		switch {
+		case cond == "added" && len(d.Added) > 0:
+			failures = append(failures, fmt.Sprintf("%v components added", len(d.Added)))
+		case cond == "removed" && len(d.Removed) > 0:
+			failures = append(failures, fmt.Sprintf("%v components removed", len(d.Removed)))
+		case cond == "version-change" && len(d.VersionChanges) > 0:
+			failures = append(failures, fmt.Sprintf("%v components changed version", len(d.VersionChanges)))
+		case cond == "license-change" && len(d.LicenseChanges) > 0:
+			failures = append(failures, fmt.Sprintf("%v components changed license", len(d.LicenseChanges)))
+		case cond == "dependency-change" && len(d.AddedDependencies)+len(d.RemovedDependencies) > 0:
+			failures = append(failures, fmt.Sprintf("%v dependencies changed", len(d.AddedDependencies)+len(d.RemovedDependencies)))
+		case cond == "downgrade":
+			for _, c := range d.VersionChanges {
+				if c.Downgrade {
+					failures = append(failures, fmt.Sprintf("%v was downgraded from %v to %v", c.label(), c.OldVersion, c.NewVersion))
+				}
+			}
+		case strings.HasPrefix(cond, "license:"):
+			pattern := strings.ToUpper(strings.TrimPrefix(cond, "license:"))
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid license pattern in %v: %w", cond, err)
+			}
+			for _, c := range append(append([]ComponentChange{}, d.Added...), d.LicenseChanges...) {
+				for _, l := range newLicenseIDs(c) {
+					if ok, _ := filepath.Match(pattern, strings.ToUpper(l)); ok {
+						failures = append(failures, fmt.Sprintf("%v introduces license %v", c.label(), l))
+					}
+				}
+			}
+		case contains([]string{"added", "removed", "version-change", "license-change", "dependency-change"}, cond):
+		default:
+			return nil, fmt.Errorf("unknown --fail-on condition %v", cond)
+		}
+	}
+	return failures, nil
+}
+
+// newLicenseIDs returns the license identifiers in the new licenses that were not there before
+func newLicenseIDs(c ComponentChange) []string {
+	old := make(map[string]bool)
+	for _, l := range c.OldLicenses {
+		for _, id := range LicenseIDs(l) {
+			old[id] = true
+		}
+	}
+	ids := make([]string, 0)
+	for _, l := range c.NewLicenses {
+		for _, id := range LicenseIDs(l) {
+			if !old[id] {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// LicenseIDs returns the license identifiers used in an SPDX license
+// expression, or the expression itself when it doesn't parse (e.g. a free text
+// license name)
+func LicenseIDs(expression string) []string {
+	e, err := license.Parse(expression)
+	if err != nil {
+		return []string{expression}
+	}
+	return e.IDs()
+}
+
+func (c ComponentChange) label() string {
+	if c.Image != "" {
+		return fmt.Sprintf("%v (in %v)", c.Name, c.Image)
+	}
+	return c.Name
+}
+
+// Write renders the diff as text, json or markdown
+func (d *Diff) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(d, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "markdown":
+		d.writeMarkdown(w)
+	case "text":
+		d.writeText(w)
+	default:
+		return fmt.Errorf("unknown output format %v", format)
+	}
+	return nil
+}
+
+func (d *Diff) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Comparing %v to %v\n", d.Old, d.New)
+	if d.Empty() {
+		fmt.Fprintln(w, "No differences")
+		return
+	}
+	sections := []struct {
+		title   string
+		changes []ComponentChange
+	}{
+		{"Added", d.Added},
+		{"Removed", d.Removed},
+		{"Version changes", d.VersionChanges},
+		{"License changes", d.LicenseChanges},
+	}
+	for _, s := range sections {
+		if len(s.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%v:\n", s.title)
+		image := "-"
+		for _, c := range s.changes {
+			if c.Image != image {
+				image = c.Image
+				if image == "" {
+					fmt.Fprintln(w, "  [chart]")
+				} else {
+					fmt.Fprintf(w, "  [%v]\n", image)
+				}
+			}
+			fmt.Fprintf(w, "    %v %v\n", c.Name, c.describe(s.title))
+		}
+	}
+	for _, s := range []struct {
+		title string
+		edges []Edge
+	}{{"Added dependencies", d.AddedDependencies}, {"Removed dependencies", d.RemovedDependencies}} {
+		if len(s.edges) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%v:\n", s.title)
+		for _, e := range s.edges {
+			fmt.Fprintf(w, "    %v -> %v\n", e.From, e.To)
+		}
+	}
+}
+
+func (c ComponentChange) describe(section string) string {
+	switch section {
+	case "Added":
+		return c.NewVersion
+	case "Removed":
+		return c.OldVersion
+	case "License changes":
+		return fmt.Sprintf("%v -> %v", strings.Join(c.OldLicenses, ", "), strings.Join(c.NewLicenses, ", "))
+	}
+	if c.Downgrade {
+		return fmt.Sprintf("%v -> %v (downgrade)", c.OldVersion, c.NewVersion)
+	}
+	return fmt.Sprintf("%v -> %v", c.OldVersion, c.NewVersion)
+}
+
+func (d *Diff) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# SBOM diff: %v → %v\n\n", d.Old, d.New)
+	if d.Empty() {
+		fmt.Fprintln(w, "No differences.")
+		return
+	}
+	fmt.Fprintln(w, "| Change | Image | Component | Old | New |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	row := func(kind string, c ComponentChange, oldValue string, newValue string) {
+		fmt.Fprintf(w, "| %v | %v | %v | %v | %v |\n", kind, c.Image, c.Name, oldValue, newValue)
+	}
+	for _, c := range d.Added {
+		row("added", c, "", c.NewVersion)
+	}
+	for _, c := range d.Removed {
+		row("removed", c, c.OldVersion, "")
+	}
+	for _, c := range d.VersionChanges {
+		kind := "upgraded"
+		if c.Downgrade {
+			kind = "**downgraded**"
+		}
+		row(kind, c, c.OldVersion, c.NewVersion)
+	}
+	for _, c := range d.LicenseChanges {
+		row("license", c, strings.Join(c.OldLicenses, ", "), strings.Join(c.NewLicenses, ", "))
+	}
+	if len(d.AddedDependencies)+len(d.RemovedDependencies) > 0 {
+		fmt.Fprint(w, "\n## Dependencies\n\n")
+		for _, e := range d.AddedDependencies {
+			fmt.Fprintf(w, "- added `%v` → `%v`\n", e.From, e.To)
+		}
+		for _, e := range d.RemovedDependencies {
+			fmt.Fprintf(w, "- removed `%v` → `%v`\n", e.From, e.To)
+		}
+	}
+}
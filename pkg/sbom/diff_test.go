@@ -0,0 +1,285 @@
+package sbom_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
+)
+
+// upgradeNginx changes the version of nginx the scanner finds
+func upgradeNginx(s *scanner.Fake, version string) {
+	nginx := &s.Packages["nginx:1.21"][1]
+	nginx.Version, nginx.PURL = version, "pkg:deb/debian/nginx@"+version
+}
+
+func TestCompareSPDXPerImage(t *testing.T) {
+	old, err := sbom.Parse(generateSPDX(t, generatortest.Scanner()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := generatortest.Scanner()
+	upgradeNginx(s, "1.21.4")
+	s.Packages["redis:6.2"] = append(s.Packages["redis:6.2"], sbom.Component{Ref: "deb-zlib", Name: "zlib", Version: "1.2.11"})
+	newDoc, err := sbom.Parse(generateSPDX(t, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := sbom.Compare(old, newDoc)
+	wantChanges := []sbom.ComponentChange{{Image: "docker.io/library/nginx", Name: "nginx", OldVersion: "1.21.3", NewVersion: "1.21.4", OldLicenses: []string{}, NewLicenses: []string{}}}
+	if !reflect.DeepEqual(d.VersionChanges, wantChanges) {
+		t.Errorf("version changes %+v, want %+v", d.VersionChanges, wantChanges)
+	}
+	wantAdded := []sbom.ComponentChange{{Image: "docker.io/library/redis", Name: "zlib", NewVersion: "1.2.11", NewLicenses: []string{}}}
+	if !reflect.DeepEqual(d.Added, wantAdded) {
+		t.Errorf("added %+v, want %+v", d.Added, wantAdded)
+	}
+	if len(d.Removed) != 0 {
+		t.Errorf("removed %+v, want none", d.Removed)
+	}
+	wantEdges := []sbom.Edge{{From: "docker.io/library/redis", To: "zlib"}}
+	if !reflect.DeepEqual(d.AddedDependencies, wantEdges) {
+		t.Errorf("added dependencies %+v, want %+v", d.AddedDependencies, wantEdges)
+	}
+}
+
+func TestCompareCycloneDX(t *testing.T) {
+	old, err := sbom.Parse(generatortest.Generate(t, generatortest.Chart()).Encoded[generator.FormatCycloneDXJSON])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := generatortest.Scanner()
+	upgradeNginx(s, "1.21.4")
+	newDoc, err := sbom.Parse(generatortest.Generate(t, generatortest.Chart(), generator.WithScanner(s)).Encoded[generator.FormatCycloneDX])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := sbom.Compare(old, newDoc)
+	wantChanges := []sbom.ComponentChange{{Image: "docker.io/library/nginx", Name: "nginx", OldVersion: "1.21.3", NewVersion: "1.21.4", OldLicenses: []string{}, NewLicenses: []string{}}}
+	if !reflect.DeepEqual(d.VersionChanges, wantChanges) {
+		t.Errorf("version changes %+v, want %+v", d.VersionChanges, wantChanges)
+	}
+	if len(d.Added)+len(d.Removed)+len(d.LicenseChanges)+len(d.AddedDependencies)+len(d.RemovedDependencies) != 0 {
+		t.Errorf("diff %+v, want only nginx's version to change", d)
+	}
+}
+
+func TestCompareLicenseChanges(t *testing.T) {
+	old, err := sbom.Parse(generateSPDX(t, generatortest.Scanner()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := generatortest.Scanner()
+	for _, image := range []string{"nginx:1.21", "redis:6.2"} {
+		s.Packages[image][0].Licenses = []string{"OpenSSL OR GPL-2.0-only"}
+	}
+	newDoc, err := sbom.Parse(generateSPDX(t, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := sbom.Compare(old, newDoc)
+	want := make([]sbom.ComponentChange, 0, 2)
+	for _, image := range []string{"docker.io/library/nginx", "docker.io/library/redis"} {
+		want = append(want, sbom.ComponentChange{
+			Image:       image,
+			Name:        "openssl",
+			OldVersion:  "1.1.1k",
+			NewVersion:  "1.1.1k",
+			OldLicenses: []string{"OpenSSL"},
+			NewLicenses: []string{"OpenSSL OR GPL-2.0-only"},
+		})
+	}
+	if !reflect.DeepEqual(d.LicenseChanges, want) {
+		t.Errorf("license changes %+v, want %+v", d.LicenseChanges, want)
+	}
+	if len(d.VersionChanges) != 0 {
+		t.Errorf("version changes %+v, want none", d.VersionChanges)
+	}
+}
+
+// document is an SBOM of a chart with one image, which depends on each of
+// the given components
+func document(components ...sbom.Component) *sbom.Document {
+	doc := &sbom.Document{Name: "demo", Root: "chart", Dependencies: map[string][]string{"chart": {"image"}}}
+	doc.Components = append(doc.Components, sbom.Component{Ref: "image", Name: "app", Type: "container"})
+	for _, c := range components {
+		c.Images = []string{"app"}
+		doc.Components = append(doc.Components, c)
+		doc.Dependencies["image"] = append(doc.Dependencies["image"], c.Ref)
+	}
+	return doc
+}
+
+func TestCompareLicenseChangesAmongVersions(t *testing.T) {
+	old := document(
+		sbom.Component{Ref: "jq-1.5", Name: "jq", Version: "1.5", Licenses: []string{"MIT"}},
+		sbom.Component{Ref: "jq-1.6", Name: "jq", Version: "1.6", Licenses: []string{"MIT"}},
+	)
+	newDoc := document(
+		sbom.Component{Ref: "jq-1.5", Name: "jq", Version: "1.5", Licenses: []string{"MIT"}},
+		sbom.Component{Ref: "jq-1.6", Name: "jq", Version: "1.6", Licenses: []string{"GPL-3.0-only"}},
+		sbom.Component{Ref: "jq-1.7", Name: "jq", Version: "1.7", Licenses: []string{"MIT"}},
+	)
+
+	d := sbom.Compare(old, newDoc)
+	want := []sbom.ComponentChange{{Image: "app", Name: "jq", OldVersion: "1.6", NewVersion: "1.6", OldLicenses: []string{"MIT"}, NewLicenses: []string{"GPL-3.0-only"}}}
+	if !reflect.DeepEqual(d.LicenseChanges, want) {
+		t.Errorf("license changes %+v, want %+v", d.LicenseChanges, want)
+	}
+	wantAdded := []sbom.ComponentChange{{Image: "app", Name: "jq", NewVersion: "1.7", NewLicenses: []string{"MIT"}}}
+	if !reflect.DeepEqual(d.Added, wantAdded) {
+		t.Errorf("added %+v, want %+v", d.Added, wantAdded)
+	}
+}
+
+func TestCompareRemovedDependencies(t *testing.T) {
+	old := document(
+		sbom.Component{Ref: "curl", Name: "curl", Version: "7.79.1"},
+		sbom.Component{Ref: "zlib", Name: "zlib", Version: "1.2.11"},
+	)
+	old.Dependencies["curl"] = []string{"zlib"}
+	newDoc := document(sbom.Component{Ref: "curl", Name: "curl", Version: "7.79.1"})
+
+	d := sbom.Compare(old, newDoc)
+	wantRemoved := []sbom.ComponentChange{{Image: "app", Name: "zlib", OldVersion: "1.2.11"}}
+	if !reflect.DeepEqual(d.Removed, wantRemoved) {
+		t.Errorf("removed %+v, want %+v", d.Removed, wantRemoved)
+	}
+	wantEdges := []sbom.Edge{{From: "app", To: "zlib"}, {From: "curl", To: "zlib"}}
+	if !reflect.DeepEqual(d.RemovedDependencies, wantEdges) {
+		t.Errorf("removed dependencies %+v, want %+v", d.RemovedDependencies, wantEdges)
+	}
+	if len(d.AddedDependencies) != 0 {
+		t.Errorf("added dependencies %+v, want none", d.AddedDependencies)
+	}
+}
+
+// testDiff has one change of every kind
+func testDiff() *sbom.Diff {
+	return &sbom.Diff{
+		Old:                 "demo-0.1.0",
+		New:                 "demo-0.2.0",
+		Added:               []sbom.ComponentChange{{Image: "app", Name: "readline", NewVersion: "8.1", NewLicenses: []string{"GPL-3.0-or-later"}}},
+		Removed:             []sbom.ComponentChange{{Image: "app", Name: "zlib", OldVersion: "1.2.11"}},
+		VersionChanges:      []sbom.ComponentChange{{Image: "app", Name: "curl", OldVersion: "7.79.1", NewVersion: "7.68.0", Downgrade: true}},
+		LicenseChanges:      []sbom.ComponentChange{{Name: "jq", OldVersion: "1.6", NewVersion: "1.6", OldLicenses: []string{"MIT"}, NewLicenses: []string{"MIT OR Apache-2.0"}}},
+		AddedDependencies:   []sbom.Edge{{From: "app", To: "readline"}},
+		RemovedDependencies: []sbom.Edge{{From: "app", To: "zlib"}},
+	}
+}
+
+func TestDiffFailures(t *testing.T) {
+	tests := []struct {
+		conditions []string
+		want       []string
+	}{
+		{[]string{"added"}, []string{"1 components added"}},
+		{[]string{"removed"}, []string{"1 components removed"}},
+		{[]string{"version-change"}, []string{"1 components changed version"}},
+		{[]string{"license-change"}, []string{"1 components changed license"}},
+		{[]string{"dependency-change"}, []string{"2 dependencies changed"}},
+		{[]string{"downgrade"}, []string{"curl (in app) was downgraded from 7.79.1 to 7.68.0"}},
+		{[]string{"license:gpl-*"}, []string{"readline (in app) introduces license GPL-3.0-or-later"}},
+		{[]string{"license:Apache-*"}, []string{"jq introduces license Apache-2.0"}},
+		{[]string{"license:MIT"}, []string{}},
+		{[]string{"added", "downgrade"}, []string{"1 components added", "curl (in app) was downgraded from 7.79.1 to 7.68.0"}},
+	}
+	for _, tt := range tests {
+		failures, err := testDiff().Failures(tt.conditions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(failures, tt.want) {
+			t.Errorf("--fail-on %v gave %q, want %q", tt.conditions, failures, tt.want)
+		}
+	}
+
+	if failures, err := (&sbom.Diff{}).Failures([]string{"added", "downgrade", "license:GPL-*"}); err != nil || len(failures) != 0 {
+		t.Errorf("an empty diff failed with %q, %v", failures, err)
+	}
+	for _, cond := range []string{"everything", "license:[GPL"} {
+		if _, err := testDiff().Failures([]string{cond}); err == nil {
+			t.Errorf("--fail-on %v succeeded, want an error", cond)
+		}
+	}
+}
+
+func TestDiffWrite(t *testing.T) {
+	tests := map[string]string{
+		"text": `Comparing demo-0.1.0 to demo-0.2.0
+
+Added:
+  [app]
+    readline 8.1
+
+Removed:
+  [app]
+    zlib 1.2.11
+
+Version changes:
+  [app]
+    curl 7.79.1 -> 7.68.0 (downgrade)
+
+License changes:
+  [chart]
+    jq MIT -> MIT OR Apache-2.0
+
+Added dependencies:
+    app -> readline
+
+Removed dependencies:
+    app -> zlib
+`,
+		"markdown": `# SBOM diff: demo-0.1.0 → demo-0.2.0
+
+| Change | Image | Component | Old | New |
+|---|---|---|---|---|
+| added | app | readline |  | 8.1 |
+| removed | app | zlib | 1.2.11 |  |
+| **downgraded** | app | curl | 7.79.1 | 7.68.0 |
+| license |  | jq | MIT | MIT OR Apache-2.0 |
+
+## Dependencies
+
+- added ` + "`app` → `readline`" + `
+- removed ` + "`app` → `zlib`" + `
+`,
+	}
+	for format, want := range tests {
+		buf := &bytes.Buffer{}
+		if err := testDiff().Write(buf, format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%v diff is\n%v\nwant\n%v", format, buf, want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := testDiff().Write(buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var d sbom.Diff
+	if err := json.Unmarshal(buf.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&d, testDiff()) {
+		t.Errorf("json diff decodes to %+v, want %+v", d, testDiff())
+	}
+
+	buf.Reset()
+	if err := (&sbom.Diff{Old: "a", New: "b"}).Write(buf, "text"); err != nil || buf.String() != "Comparing a to b\nNo differences\n" {
+		t.Errorf("an empty diff is %q, %v", buf, err)
+	}
+	if err := testDiff().Write(buf, "html"); err == nil {
+		t.Errorf("writing html succeeded, want an error")
+	}
+}
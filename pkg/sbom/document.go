@@ -0,0 +1,306 @@
+package sbom
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/jsonloader"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tvloader"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// Formats Load knows how to detect
+const (
+	FormatSPDX          = "spdx"
+	FormatSPDXJSON      = "spdx-json"
+	FormatCycloneDX     = "cyclonedx"
+	FormatCycloneDXJSON = "cyclonedx-json"
+)
+
+// Component is a format neutral view of an SPDX package or a CycloneDX component
+type Component struct {
+	Ref      string   `json:"ref"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	PURL     string   `json:"purl,omitempty"`
+	CPEs     []string `json:"cpes,omitempty"`
+	Licenses []string `json:"licenses,omitempty"`
+	Supplier string   `json:"supplier,omitempty"`
+	// Digest is the sha256 digest of an image, when the SBOM records it
+	Digest string `json:"digest,omitempty"`
+	// Images are the names of the container images this component was found in
+	Images []string `json:"images,omitempty"`
+}
+
+// IsImage reports whether the component represents a container image
+func (c Component) IsImage() bool {
+	return c.Type == string(cyclonedx.ComponentTypeContainer) || strings.HasPrefix(c.Ref, "image-")
+}
+
+// Document is a format neutral view of an SBOM: the thing it describes, its
+// components and the dependency graph between them.
+type Document struct {
+	Name       string      `json:"name"`
+	Format     string      `json:"format"`
+	Root       string      `json:"root,omitempty"`
+	Version    string      `json:"version,omitempty"`
+	Authors    []string    `json:"authors,omitempty"`
+	Timestamp  string      `json:"timestamp,omitempty"`
+	Components []Component `json:"components"`
+	// Dependencies maps a component ref to the refs it depends on / contains
+	Dependencies map[string][]string `json:"dependencies,omitempty"`
+}
+
+// Component returns the component with the given ref
+func (d *Document) Component(ref string) (Component, bool) {
+	for _, c := range d.Components {
+		if c.Ref == ref {
+			return c, true
+		}
+	}
+	return Component{}, false
+}
+
+// Load reads an SBOM in any of the supported formats and encodings
+func Load(filename string) (*Document, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// DetectFormat works out which format the SBOM content is in
+func DetectFormat(b []byte) (string, error) {
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return FormatCycloneDX, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		if bytes.Contains(trimmed, []byte(`"bomFormat"`)) {
+			return FormatCycloneDXJSON, nil
+		}
+		if bytes.Contains(trimmed, []byte(`"spdxVersion"`)) {
+			return FormatSPDXJSON, nil
+		}
+	case bytes.Contains(trimmed, []byte("SPDXVersion:")):
+		return FormatSPDX, nil
+	}
+	return "", fmt.Errorf("unable to determine the format of the SBOM")
+}
+
+// Parse reads an SBOM from its content in any of the supported formats
+func Parse(b []byte) (*Document, error) {
+	format, err := DetectFormat(b)
+	if err != nil {
+		return nil, err
+	}
+	var doc *Document
+	switch format {
+	case FormatSPDX, FormatSPDXJSON:
+		var spdxDoc *spdx.Document2_2
+		if format == FormatSPDX {
+			spdxDoc, err = tvloader.Load2_2(bytes.NewReader(b))
+		} else {
+			spdxDoc, err = jsonloader.Load2_2(bytes.NewReader(b))
+		}
+		if err != nil {
+			return nil, err
+		}
+		doc = FromSPDX(spdxDoc)
+	default:
+		bom := &cyclonedx.BOM{}
+		fileFormat := cyclonedx.BOMFileFormatXML
+		if format == FormatCycloneDXJSON {
+			fileFormat = cyclonedx.BOMFileFormatJSON
+		}
+		if err := cyclonedx.NewBOMDecoder(bytes.NewReader(b), fileFormat).Decode(bom); err != nil {
+			return nil, err
+		}
+		doc = FromCycloneDX(bom)
+	}
+	doc.Format = format
+	return doc, nil
+}
+
+// FromSPDX builds the neutral view of an SPDX document
+func FromSPDX(spdxDoc *spdx.Document2_2) *Document {
+	doc := &Document{
+		Format:       FormatSPDX,
+		Root:         spdxDocumentID,
+		Dependencies: spdxGraph(spdxDoc),
+	}
+	if ci := spdxDoc.CreationInfo; ci != nil {
+		doc.Name = ci.DocumentName
+		doc.Timestamp = ci.Created
+		doc.Authors = append(append(doc.Authors, ci.CreatorPersons...), ci.CreatorOrganizations...)
+	}
+	for _, p := range spdxDoc.Packages {
+		c := Component{
+			Ref:      string(p.PackageSPDXIdentifier),
+			Name:     p.PackageName,
+			Version:  p.PackageVersion,
+			Licenses: spdxLicenses(p),
+		}
+		switch {
+		case p.PackageSupplierOrganization != "":
+			c.Supplier = p.PackageSupplierOrganization
+		case p.PackageSupplierPerson != "":
+			c.Supplier = p.PackageSupplierPerson
+		}
+		if sum, ok := p.PackageChecksums[spdx.SHA256]; ok {
+			c.Digest = "sha256:" + sum.Value
+		}
+		for _, ext := range p.PackageExternalReferences {
+			switch ext.RefType {
+			case string(syft.PurlExternalRefType):
+				c.PURL = ext.Locator
+			case string(syft.Cpe23ExternalRefType), string(syft.Cpe22ExternalRefType):
+				c.CPEs = append(c.CPEs, ext.Locator)
+			}
+		}
+		// the same conventions SPDXPackageToCycloneComponent uses
+		switch {
+		case strings.HasPrefix(c.Ref, "image-"):
+			c.Type = string(cyclonedx.ComponentTypeContainer)
+		case strings.HasPrefix(c.Ref, "cpe-"):
+			c.Type = string(cyclonedx.ComponentTypeApplication)
+		default:
+			c.Type = string(cyclonedx.ComponentTypeLibrary)
+		}
+		if c.IsImage() && c.Digest == "" {
+			c.Digest = imageDigest(c.Name + ":" + c.Version)
+		}
+		doc.Components = append(doc.Components, c)
+	}
+	doc.finish()
+	return doc
+}
+
+func spdxLicenses(p *spdx.Package2_2) []string {
+	licenses := make([]string, 0)
+	for _, l := range []string{p.PackageLicenseConcluded, p.PackageLicenseDeclared} {
+		if l == "" || l == "NOASSERTION" || l == "NONE" || contains(licenses, l) {
+			continue
+		}
+		licenses = append(licenses, l)
+	}
+	return licenses
+}
+
+// FromCycloneDX builds the neutral view of a CycloneDX BOM
+func FromCycloneDX(bom *cyclonedx.BOM) *Document {
+	doc := &Document{
+		Format:       FormatCycloneDX,
+		Root:         cycloneDXRoot(bom),
+		Dependencies: cycloneDXGraph(bom),
+	}
+	if bom.Metadata != nil {
+		doc.Timestamp = bom.Metadata.Timestamp
+		if bom.Metadata.Component != nil {
+			doc.Name = bom.Metadata.Component.Name
+			doc.Version = bom.Metadata.Component.Version
+		}
+		if bom.Metadata.Authors != nil {
+			for _, a := range *bom.Metadata.Authors {
+				doc.Authors = append(doc.Authors, a.Name)
+			}
+		}
+	}
+	if bom.Components != nil {
+		for _, comp := range *bom.Components {
+			doc.Components = append(doc.Components, fromCycloneDXComponent(comp))
+		}
+	}
+	doc.finish()
+	return doc
+}
+
+func fromCycloneDXComponent(comp cyclonedx.Component) Component {
+	c := Component{
+		Ref:      comp.BOMRef,
+		Name:     comp.Name,
+		Version:  comp.Version,
+		Type:     string(comp.Type),
+		PURL:     comp.PackageURL,
+		Licenses: make([]string, 0),
+	}
+	if comp.CPE != "" {
+		c.CPEs = []string{comp.CPE}
+	}
+	if comp.Supplier != nil {
+		c.Supplier = comp.Supplier.Name
+	}
+	if comp.Hashes != nil {
+		for _, h := range *comp.Hashes {
+			if h.Algorithm == cyclonedx.HashAlgoSHA256 {
+				c.Digest = "sha256:" + h.Value
+			}
+		}
+	}
+	if c.IsImage() && c.Digest == "" {
+		c.Digest = imageDigest(c.Name + ":" + c.Version)
+	}
+	if comp.Licenses != nil {
+		for _, l := range *comp.Licenses {
+			switch {
+			case l.Expression != "":
+				c.Licenses = append(c.Licenses, l.Expression)
+			case l.License != nil && l.License.ID != "":
+				c.Licenses = append(c.Licenses, l.License.ID)
+			case l.License != nil && l.License.Name != "":
+				c.Licenses = append(c.Licenses, l.License.Name)
+			}
+		}
+	}
+	return c
+}
+
+// finish sorts the components and works out which images each one belongs to
+func (d *Document) finish() {
+	sort.Slice(d.Components, func(i, j int) bool {
+		return d.Components[i].Ref < d.Components[j].Ref
+	})
+	g := graph(d.Dependencies)
+	images := make(map[string][]string)
+	for _, c := range d.Components {
+		if !c.IsImage() {
+			continue
+		}
+		for ref := range g.reachable(c.Ref) {
+			if ref != c.Ref {
+				images[ref] = append(images[ref], c.Name)
+			}
+		}
+	}
+	for i, c := range d.Components {
+		if names, ok := images[c.Ref]; ok {
+			sort.Strings(names)
+			d.Components[i].Images = names
+		}
+	}
+}
+
+// imageDigest is the digest an image reference is pinned to, if any
+func imageDigest(image string) string {
+	if i := strings.Index(image, "@sha256:"); i >= 0 {
+		return strings.TrimSuffix(image[i+1:], ":")
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package sbom
+
+import (
+	"fmt"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+)
+
+// Kinds of entities
+const (
+	EntityPerson       = "Person"
+	EntityOrganization = "Organization"
+)
+
+// Entity is a person or organization that made or supplies something, written
+// the SPDX way: "Person: Jane Doe (jane@example.com)" or "Organization: Acme"
+type Entity struct {
+	Kind  string
+	Name  string
+	Email string
+}
+
+// ParseEntity reads an entity the SPDX way. Without a Person: or
+// Organization: prefix it is an organization.
+func ParseEntity(s string) Entity {
+	e := Entity{Kind: EntityOrganization}
+	s = strings.TrimSpace(s)
+	for _, kind := range []string{EntityPerson, EntityOrganization} {
+		if strings.HasPrefix(s, kind+":") {
+			e.Kind = kind
+			s = strings.TrimSpace(strings.TrimPrefix(s, kind+":"))
+		}
+	}
+	if i := strings.LastIndex(s, "("); i >= 0 && strings.HasSuffix(s, ")") {
+		e.Email = strings.TrimSpace(s[i+1 : len(s)-1])
+		s = strings.TrimSpace(s[:i])
+	}
+	e.Name = s
+	return e
+}
+
+// IsZero reports whether the entity is unset
+func (e Entity) IsZero() bool {
+	return e.Name == ""
+}
+
+// Value is the entity as SPDX writes it after the prefix, "Jane Doe (jane@example.com)"
+func (e Entity) Value() string {
+	if e.Email == "" {
+		return e.Name
+	}
+	return fmt.Sprintf("%v (%v)", e.Name, e.Email)
+}
+
+// String is the entity as SPDX writes it, "Person: Jane Doe (jane@example.com)"
+func (e Entity) String() string {
+	return e.Kind + ": " + e.Value()
+}
+
+// SetSupplier sets the supplier of an SPDX package, NOASSERTION if the
+// entity is unset
+func (e Entity) SetSupplier(p *spdx.Package2_2) {
+	p.PackageSupplierPerson, p.PackageSupplierOrganization, p.PackageSupplierNOASSERTION = "", "", e.IsZero()
+	switch {
+	case e.IsZero():
+	case e.Kind == EntityPerson:
+		p.PackageSupplierPerson = e.Value()
+	default:
+		p.PackageSupplierOrganization = e.Value()
+	}
+}
+
+// SetOriginator sets the originator of an SPDX package, NOASSERTION if the
+// entity is unset
+func (e Entity) SetOriginator(p *spdx.Package2_2) {
+	p.PackageOriginatorPerson, p.PackageOriginatorOrganization, p.PackageOriginatorNOASSERTION = "", "", e.IsZero()
+	switch {
+	case e.IsZero():
+	case e.Kind == EntityPerson:
+		p.PackageOriginatorPerson = e.Value()
+	default:
+		p.PackageOriginatorOrganization = e.Value()
+	}
+}
+
+// CycloneDX is the entity as a CycloneDX organizational entity; a person is
+// the contact of an entity with their name
+func (e Entity) CycloneDX() *cyclonedx.OrganizationalEntity {
+	if e.IsZero() {
+		return nil
+	}
+	if e.Kind == EntityPerson {
+		return &cyclonedx.OrganizationalEntity{Name: e.Name, Contact: &[]cyclonedx.OrganizationalContact{{Name: e.Name, Email: e.Email}}}
+	}
+	return &cyclonedx.OrganizationalEntity{Name: e.Name}
+}
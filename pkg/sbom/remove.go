@@ -0,0 +1,351 @@
+package sbom
+
+import (
+	"fmt"
+	"sort"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdxlib"
+)
+
+// spdxDocumentID is the element ID of the document itself, the root of the relationship graph
+const spdxDocumentID = "DOCUMENT"
+
+// graph is a directed parent -> children view of a BOM's dependency tree
+type graph map[string][]string
+
+// reachable returns every node that can be reached from root, including root
+func (g graph) reachable(root string) map[string]bool {
+	seen := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, c := range g[n] {
+			if !seen[c] {
+				seen[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+	return seen
+}
+
+// parents returns the nodes that have ref as a direct child
+func (g graph) parents(ref string) []string {
+	parents := make([]string, 0)
+	for p, children := range g {
+		for _, c := range children {
+			if c == ref {
+				parents = append(parents, p)
+				break
+			}
+		}
+	}
+	sort.Strings(parents)
+	return parents
+}
+
+// without returns a copy of the graph with the given nodes and their edges dropped
+func (g graph) without(removed map[string]bool) graph {
+	out := make(graph)
+	for p, children := range g {
+		if removed[p] {
+			continue
+		}
+		for _, c := range children {
+			if !removed[c] {
+				out[p] = append(out[p], c)
+			}
+		}
+	}
+	return out
+}
+
+// prune works out everything to delete when the matched nodes are removed: the
+// matched nodes plus anything that was reachable from root before and no longer is
+func (g graph) prune(root string, matched map[string]bool) map[string]bool {
+	before := g.reachable(root)
+	after := g.without(matched).reachable(root)
+	removed := make(map[string]bool)
+	for n := range matched {
+		removed[n] = true
+	}
+	for n := range before {
+		if !after[n] {
+			removed[n] = true
+		}
+	}
+	return removed
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func cycloneDXGraph(bom *cyclonedx.BOM) graph {
+	g := make(graph)
+	if bom.Dependencies == nil {
+		return g
+	}
+	for _, dep := range *bom.Dependencies {
+		if dep.Dependencies == nil {
+			continue
+		}
+		for _, d := range *dep.Dependencies {
+			g[dep.Ref] = append(g[dep.Ref], d.Ref)
+		}
+	}
+	return g
+}
+
+func cycloneDXRoot(bom *cyclonedx.BOM) string {
+	if bom.Metadata == nil || bom.Metadata.Component == nil {
+		return ""
+	}
+	return bom.Metadata.Component.BOMRef
+}
+
+func matchCycloneDX(bom *cyclonedx.BOM, sel Selector) map[string]bool {
+	matched := make(map[string]bool)
+	if bom.Components == nil {
+		return matched
+	}
+	for _, c := range *bom.Components {
+		if sel.MatchesComponent(c) {
+			matched[c.BOMRef] = true
+		}
+	}
+	return matched
+}
+
+// RemoveCycloneDX deletes the selected components and the subtree below them.
+// Components that are no longer reachable from the metadata component are pruned,
+// components shared with another part of the tree are kept. The BOMRefs that were
+// removed are returned.
+func RemoveCycloneDX(bom *cyclonedx.BOM, sel Selector) ([]string, error) {
+	matched := matchCycloneDX(bom, sel)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no component matches %v", sel)
+	}
+	removed := cycloneDXGraph(bom).prune(cycloneDXRoot(bom), matched)
+	dropCycloneDX(bom, removed)
+	return sortedKeys(removed), nil
+}
+
+// dropCycloneDX removes the components and every dependency edge that mentions them
+func dropCycloneDX(bom *cyclonedx.BOM, removed map[string]bool) {
+	if bom.Components != nil {
+		comps := make([]cyclonedx.Component, 0)
+		for _, c := range *bom.Components {
+			if !removed[c.BOMRef] {
+				comps = append(comps, c)
+			}
+		}
+		bom.Components = &comps
+	}
+	if bom.Dependencies != nil {
+		deps := make([]cyclonedx.Dependency, 0)
+		for _, dep := range *bom.Dependencies {
+			if removed[dep.Ref] {
+				continue
+			}
+			if dep.Dependencies != nil {
+				children := make([]cyclonedx.Dependency, 0)
+				for _, c := range *dep.Dependencies {
+					if !removed[c.Ref] {
+						children = append(children, c)
+					}
+				}
+				dep.Dependencies = &children
+			}
+			deps = append(deps, dep)
+		}
+		bom.Dependencies = &deps
+	}
+}
+
+// ReplaceCycloneDX removes the selected components like RemoveCycloneDX and nests
+// leaf in their place, under each of the removed components' parents.
+func ReplaceCycloneDX(bom *cyclonedx.BOM, sel Selector, leaf *cyclonedx.BOM) ([]string, error) {
+	matched := matchCycloneDX(bom, sel)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no component matches %v", sel)
+	}
+	g := cycloneDXGraph(bom)
+	parents := make(map[string]bool)
+	for ref := range matched {
+		for _, p := range g.parents(ref) {
+			parents[p] = true
+		}
+	}
+	removed, err := RemoveCycloneDX(bom, sel)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range removed {
+		delete(parents, ref)
+	}
+	if len(parents) == 0 {
+		parents[cycloneDXRoot(bom)] = true
+	}
+
+	keys := sortedKeys(parents)
+	if err := AddCycloneDXDependency(bom, leaf, keys[0]); err != nil {
+		return nil, err
+	}
+	for _, p := range keys[1:] {
+		deps := addCycloneDXEdge(*bom.Dependencies, p, leaf.Metadata.Component.BOMRef)
+		bom.Dependencies = &deps
+	}
+	return removed, nil
+}
+
+// spdxEdge returns the parent and child of a relationship that forms part of the
+// document's containment/dependency tree
+func spdxEdge(r *spdx.Relationship2_2) (string, string, bool) {
+	if r.RefA.DocumentRefID != "" || r.RefB.DocumentRefID != "" || r.RefA.SpecialID != "" || r.RefB.SpecialID != "" {
+		return "", "", false
+	}
+	a, b := string(r.RefA.ElementRefID), string(r.RefB.ElementRefID)
+	switch r.Relationship {
+	case "DESCRIBES", "CONTAINS", "DEPENDS_ON":
+		return a, b, true
+	case "DESCRIBED_BY", "CONTAINED_BY", "DEPENDENCY_OF":
+		return b, a, true
+	}
+	return "", "", false
+}
+
+func spdxGraph(doc *spdx.Document2_2) graph {
+	g := make(graph)
+	for _, r := range doc.Relationships {
+		if p, c, ok := spdxEdge(r); ok {
+			g[p] = append(g[p], c)
+		}
+	}
+	return g
+}
+
+func matchSPDX(doc *spdx.Document2_2, sel Selector) map[string]bool {
+	matched := make(map[string]bool)
+	for id, p := range doc.Packages {
+		if sel.MatchesPackage(p) {
+			matched[string(id)] = true
+		}
+	}
+	return matched
+}
+
+// RemoveSPDX deletes the selected packages and the packages they contain or depend
+// on, as long as nothing else in the document still refers to them. Relationships
+// and annotations that mention a removed package are dropped. The element IDs that
+// were removed are returned.
+func RemoveSPDX(doc *spdx.Document2_2, sel Selector) ([]string, error) {
+	matched := matchSPDX(doc, sel)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no package matches %v", sel)
+	}
+	removed := spdxGraph(doc).prune(spdxDocumentID, matched)
+	dropSPDX(doc, removed)
+	return sortedKeys(removed), nil
+}
+
+func dropSPDX(doc *spdx.Document2_2, removed map[string]bool) {
+	for id := range removed {
+		delete(doc.Packages, spdx.ElementID(id))
+	}
+	rels := make([]*spdx.Relationship2_2, 0)
+	for _, r := range doc.Relationships {
+		if removed[string(r.RefA.ElementRefID)] && r.RefA.DocumentRefID == "" {
+			continue
+		}
+		if removed[string(r.RefB.ElementRefID)] && r.RefB.DocumentRefID == "" {
+			continue
+		}
+		rels = append(rels, r)
+	}
+	doc.Relationships = rels
+	annotations := make([]*spdx.Annotation2_2, 0)
+	for _, a := range doc.Annotations {
+		if !removed[string(a.AnnotationSPDXIdentifier.ElementRefID)] {
+			annotations = append(annotations, a)
+		}
+	}
+	doc.Annotations = annotations
+}
+
+// ReplaceSPDX removes the selected packages like RemoveSPDX and adds the packages of
+// leaf in their place. The packages leaf describes are attached to each of the removed
+// packages' parents; when leaf does not say what it describes (as with an image scan)
+// a package for the leaf document itself is created to hold its packages.
+func ReplaceSPDX(doc *spdx.Document2_2, sel Selector, leaf *spdx.Document2_2) ([]string, error) {
+	matched := matchSPDX(doc, sel)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no package matches %v", sel)
+	}
+	g := spdxGraph(doc)
+	parents := make(map[string]bool)
+	for id := range matched {
+		for _, p := range g.parents(id) {
+			parents[p] = true
+		}
+	}
+	removed, err := RemoveSPDX(doc, sel)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range removed {
+		delete(parents, id)
+	}
+	if len(parents) == 0 {
+		parents[spdxDocumentID] = true
+	}
+
+	if doc.Packages == nil {
+		doc.Packages = make(map[spdx.ElementID]*spdx.Package2_2)
+	}
+	for id, p := range leaf.Packages {
+		doc.Packages[id] = p
+	}
+	tops, err := spdxlib.GetDescribedPackageIDs2_2(leaf)
+	if err != nil {
+		// nothing is described, so wrap the leaf's packages in a package for the document
+		top := ImageToPackage(leaf.CreationInfo.DocumentName)
+		doc.Packages[top.PackageSPDXIdentifier] = top
+		for _, id := range sortedElementIDs(leaf.Packages) {
+			doc.Relationships = append(doc.Relationships, spdxRelationship(string(top.PackageSPDXIdentifier), "CONTAINS", string(id)))
+		}
+		tops = []spdx.ElementID{top.PackageSPDXIdentifier}
+	}
+	for _, r := range leaf.Relationships {
+		if r.RefA.ElementRefID == spdxDocumentID || r.RefB.ElementRefID == spdxDocumentID {
+			continue
+		}
+		doc.Relationships = append(doc.Relationships, r)
+	}
+	for _, p := range sortedKeys(parents) {
+		rel := "CONTAINS"
+		if p == spdxDocumentID {
+			rel = "DESCRIBES"
+		}
+		for _, top := range tops {
+			doc.Relationships = append(doc.Relationships, spdxRelationship(p, rel, string(top)))
+		}
+	}
+	return removed, nil
+}
+
+func spdxRelationship(a string, rel string, b string) *spdx.Relationship2_2 {
+	return &spdx.Relationship2_2{
+		RefA:         spdx.MakeDocElementID("", a),
+		RefB:         spdx.MakeDocElementID("", b),
+		Relationship: rel,
+	}
+}
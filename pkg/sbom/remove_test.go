@@ -0,0 +1,239 @@
+package sbom_test
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tvloader"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
+)
+
+// generateSPDX makes the tag-value SPDX document of the test chart with s
+func generateSPDX(t *testing.T, s scanner.Scanner, opts ...generator.Option) []byte {
+	t.Helper()
+	opts = append([]generator.Option{generator.WithScanner(s)}, opts...)
+	return generatortest.Generate(t, generatortest.Chart(), opts...).Encoded[generator.FormatSPDX]
+}
+
+func TestRemoveSPDXImage(t *testing.T) {
+	doc, err := tvloader.Load2_2(bytes.NewReader(generateSPDX(t, generatortest.Scanner())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := sbom.RemoveSPDX(doc, sbom.ParseSelector("SPDXRef-image-docker.io-library-nginx-1.21"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"deb-nginx-1.21.3", "image-docker.io-library-nginx-1.21"}
+	if len(removed) != len(want) || removed[0] != want[0] || removed[1] != want[1] {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+	for _, id := range []spdx.ElementID{"deb-openssl-1.1.1k", "deb-redis-6.2.6", "image-docker.io-library-redis-6.2", "chart-demo"} {
+		if _, ok := doc.Packages[id]; !ok {
+			t.Errorf("%v was removed, though it is still in the chart", id)
+		}
+	}
+	for _, r := range doc.Relationships {
+		for _, id := range []spdx.ElementID{r.RefA.ElementRefID, r.RefB.ElementRefID} {
+			if id == "deb-nginx-1.21.3" || id == "image-docker.io-library-nginx-1.21" {
+				t.Errorf("relationship %v %v %v of a removed package was kept", r.RefA.ElementRefID, r.Relationship, r.RefB.ElementRefID)
+			}
+		}
+	}
+	if r := sbom.ValidateSPDX(doc); !r.Valid() {
+		t.Errorf("the document is not valid after removing the image: %+v", r.Findings)
+	}
+}
+
+// relationships lists a document's relationships in order, as text
+func relationships(doc *spdx.Document2_2) []string {
+	rels := make([]string, 0, len(doc.Relationships))
+	for _, r := range doc.Relationships {
+		rels = append(rels, string(r.RefA.ElementRefID)+" "+r.Relationship+" "+string(r.RefB.ElementRefID))
+	}
+	return rels
+}
+
+// dependencies lists what each component of a BOM depends on
+func dependencies(bom *cyclonedx.BOM) map[string][]string {
+	deps := make(map[string][]string)
+	for _, dep := range *bom.Dependencies {
+		deps[dep.Ref] = []string{}
+		if dep.Dependencies == nil {
+			continue
+		}
+		for _, d := range *dep.Dependencies {
+			deps[dep.Ref] = append(deps[dep.Ref], d.Ref)
+		}
+	}
+	return deps
+}
+
+func bomRefs(bom *cyclonedx.BOM) []string {
+	refs := make([]string, 0, len(*bom.Components))
+	for _, c := range *bom.Components {
+		refs = append(refs, c.BOMRef)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func TestRemoveCycloneDXImage(t *testing.T) {
+	bom := generatortest.Generate(t, generatortest.Chart()).CycloneDX
+	removed, err := sbom.RemoveCycloneDX(bom, sbom.ParseSelector("image-docker.io-library-nginx-1.21"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"deb-nginx-1.21.3", "image-docker.io-library-nginx-1.21"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+	wantRefs := []string{"deb-openssl-1.1.1k", "deb-redis-6.2.6", "image-docker.io-library-redis-6.2"}
+	if refs := bomRefs(bom); !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("components %v, want %v", refs, wantRefs)
+	}
+	wantDeps := map[string][]string{
+		"demo":                              {"image-docker.io-library-redis-6.2"},
+		"image-docker.io-library-redis-6.2": {"deb-openssl-1.1.1k", "deb-redis-6.2.6"},
+	}
+	if deps := dependencies(bom); !reflect.DeepEqual(deps, wantDeps) {
+		t.Errorf("dependencies %v, want %v", deps, wantDeps)
+	}
+	if r := sbom.ValidateCycloneDX(bom); !r.Valid() {
+		t.Errorf("the BOM is not valid after removing the image: %+v", r.Findings)
+	}
+}
+
+func TestRemoveNothing(t *testing.T) {
+	res := generatortest.Generate(t, generatortest.Chart())
+	if _, err := sbom.RemoveCycloneDX(res.CycloneDX, sbom.ParseSelector("nginx@9.9.9")); err == nil {
+		t.Errorf("removing a component that isn't in the BOM succeeded, want an error")
+	}
+	if _, err := sbom.RemoveSPDX(res.SPDX, sbom.ParseSelector("nginx@9.9.9")); err == nil {
+		t.Errorf("removing a package that isn't in the document succeeded, want an error")
+	}
+}
+
+// scannedImage is a document like a scan of an image writes, which says
+// nothing about what it describes
+func scannedImage() *spdx.Document2_2 {
+	doc := &spdx.Document2_2{
+		CreationInfo: &spdx.CreationInfo2_2{DocumentName: "nginx:1.22"},
+		Packages:     make(map[spdx.ElementID]*spdx.Package2_2),
+	}
+	for _, c := range []sbom.Component{
+		{Ref: "deb-zlib", Name: "zlib", Version: "1.2.11"},
+		{Ref: "deb-openssl", Name: "openssl", Version: "1.1.1n"},
+		{Ref: "deb-nginx", Name: "nginx", Version: "1.22.0"},
+	} {
+		p := sbom.ComponentToPackage(c)
+		doc.Packages[p.PackageSPDXIdentifier] = p
+	}
+	return doc
+}
+
+func TestReplaceSPDXImage(t *testing.T) {
+	doc := generatortest.Generate(t, generatortest.Chart()).SPDX
+	removed, err := sbom.ReplaceSPDX(doc, sbom.ParseSelector("SPDXRef-image-docker.io-library-nginx-1.21"), scannedImage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"deb-nginx-1.21.3", "image-docker.io-library-nginx-1.21"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+	// the shared openssl is kept and the new image's packages are in order
+	wantRels := []string{
+		"DOCUMENT DESCRIBES chart-demo",
+		"chart-demo CONTAINS image-docker.io-library-redis-6.2",
+		"image-docker.io-library-redis-6.2 CONTAINS deb-openssl-1.1.1k",
+		"image-docker.io-library-redis-6.2 CONTAINS deb-redis-6.2.6",
+		"image-nginx-1.22 CONTAINS deb-nginx",
+		"image-nginx-1.22 CONTAINS deb-openssl",
+		"image-nginx-1.22 CONTAINS deb-zlib",
+		"chart-demo CONTAINS image-nginx-1.22",
+	}
+	if rels := relationships(doc); !reflect.DeepEqual(rels, wantRels) {
+		t.Errorf("relationships %q, want %q", rels, wantRels)
+	}
+	if r := sbom.ValidateSPDX(doc); !r.Valid() {
+		t.Errorf("the document is not valid after replacing the image: %+v", r.Findings)
+	}
+}
+
+func TestReplaceSPDXWithDescribedDocument(t *testing.T) {
+	doc := generatortest.Generate(t, generatortest.Chart()).SPDX
+	s := generatortest.Scanner()
+	upgradeNginx(s, "1.21.4")
+	leaf := generatortest.Generate(t, generatortest.Chart(), generator.WithScanner(s), generator.WithImageDocuments(true)).ImageSPDX["docker.io/library/nginx:1.21"]
+	if _, err := sbom.ReplaceSPDX(doc, sbom.ParseSelector("SPDXRef-image-docker.io-library-nginx-1.21"), leaf); err != nil {
+		t.Fatal(err)
+	}
+	// the image the leaf describes takes the old one's place, with no package
+	// made for the leaf document
+	wantRels := []string{
+		"DOCUMENT DESCRIBES chart-demo",
+		"chart-demo CONTAINS image-docker.io-library-redis-6.2",
+		"image-docker.io-library-redis-6.2 CONTAINS deb-openssl-1.1.1k",
+		"image-docker.io-library-redis-6.2 CONTAINS deb-redis-6.2.6",
+		"image-docker.io-library-nginx-1.21 CONTAINS deb-nginx-1.21.4",
+		"image-docker.io-library-nginx-1.21 CONTAINS deb-openssl-1.1.1k",
+		"chart-demo CONTAINS image-docker.io-library-nginx-1.21",
+	}
+	if rels := relationships(doc); !reflect.DeepEqual(rels, wantRels) {
+		t.Errorf("relationships %q, want %q", rels, wantRels)
+	}
+	if _, ok := doc.Packages["deb-nginx-1.21.3"]; ok {
+		t.Errorf("the replaced nginx package is still in the document")
+	}
+	if r := sbom.ValidateSPDX(doc); !r.Valid() {
+		t.Errorf("the document is not valid after replacing the image: %+v", r.Findings)
+	}
+}
+
+func TestReplaceCycloneDXImage(t *testing.T) {
+	bom := generatortest.Generate(t, generatortest.Chart()).CycloneDX
+	leaf := cyclonedx.NewBOM()
+	leaf.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{Type: cyclonedx.ComponentTypeContainer, BOMRef: "image-nginx-1.22", Name: "nginx", Version: "1.22"}}
+	leaf.Components = &[]cyclonedx.Component{
+		{Type: cyclonedx.ComponentTypeLibrary, BOMRef: "deb-nginx-1.22.0", Name: "nginx", Version: "1.22.0"},
+		{Type: cyclonedx.ComponentTypeLibrary, BOMRef: "deb-zlib-1.2.11", Name: "zlib", Version: "1.2.11"},
+	}
+	leaf.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "image-nginx-1.22", Dependencies: &[]cyclonedx.Dependency{{Ref: "deb-nginx-1.22.0"}}},
+		{Ref: "deb-nginx-1.22.0", Dependencies: &[]cyclonedx.Dependency{{Ref: "deb-zlib-1.2.11"}}},
+	}
+
+	removed, err := sbom.ReplaceCycloneDX(bom, sbom.ParseSelector("image-docker.io-library-nginx-1.21"), leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"deb-nginx-1.21.3", "image-docker.io-library-nginx-1.21"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+	wantRefs := []string{"deb-nginx-1.22.0", "deb-openssl-1.1.1k", "deb-redis-6.2.6", "deb-zlib-1.2.11", "image-docker.io-library-redis-6.2", "image-nginx-1.22"}
+	if refs := bomRefs(bom); !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("components %v, want %v", refs, wantRefs)
+	}
+	wantDeps := map[string][]string{
+		"demo":                              {"image-docker.io-library-redis-6.2", "image-nginx-1.22"},
+		"image-docker.io-library-redis-6.2": {"deb-openssl-1.1.1k", "deb-redis-6.2.6"},
+		"image-nginx-1.22":                  {"deb-nginx-1.22.0"},
+		"deb-nginx-1.22.0":                  {"deb-zlib-1.2.11"},
+	}
+	if deps := dependencies(bom); !reflect.DeepEqual(deps, wantDeps) {
+		t.Errorf("dependencies %v, want %v", deps, wantDeps)
+	}
+	if r := sbom.ValidateCycloneDX(bom); !r.Valid() {
+		t.Errorf("the BOM is not valid after replacing the image: %+v", r.Findings)
+	}
+}
@@ -0,0 +1,70 @@
+package sbom
+
+import (
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// Selector identifies components in a BOM by BOMRef/SPDX identifier, PURL or name@version
+type Selector struct {
+	Ref     string
+	PURL    string
+	Name    string
+	Version string
+}
+
+// ParseSelector turns a user supplied selector into a Selector.
+//
+//	pkg:...        is matched against the PURL
+//	name@version   is matched against name and version
+//	anything else  is matched against the BOMRef (CycloneDX) or element ID (SPDX)
+func ParseSelector(s string) Selector {
+	if strings.HasPrefix(s, "pkg:") {
+		return Selector{PURL: s}
+	}
+	if i := strings.LastIndex(s, "@"); i > 0 {
+		return Selector{Name: s[:i], Version: s[i+1:]}
+	}
+	return Selector{Ref: strings.TrimPrefix(s, "SPDXRef-")}
+}
+
+func (s Selector) String() string {
+	switch {
+	case s.PURL != "":
+		return s.PURL
+	case s.Name != "":
+		return s.Name + "@" + s.Version
+	}
+	return s.Ref
+}
+
+// MatchesComponent reports whether the CycloneDX component is selected
+func (s Selector) MatchesComponent(c cyclonedx.Component) bool {
+	switch {
+	case s.PURL != "":
+		return c.PackageURL == s.PURL
+	case s.Name != "":
+		return c.Name == s.Name && c.Version == s.Version
+	}
+	return c.BOMRef == s.Ref
+}
+
+// MatchesPackage reports whether the SPDX package is selected
+func (s Selector) MatchesPackage(p *spdx.Package2_2) bool {
+	switch {
+	case s.PURL != "":
+		for _, ext := range p.PackageExternalReferences {
+			if ext.RefType == string(syft.PurlExternalRefType) && ext.Locator == s.PURL {
+				return true
+			}
+		}
+		return false
+	case s.Name != "":
+		return p.PackageName == s.Name && p.PackageVersion == s.Version
+	}
+	return string(p.PackageSPDXIdentifier) == s.Ref
+}
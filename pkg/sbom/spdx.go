@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/spdx/tools-golang/spdx"
@@ -95,6 +96,18 @@ func ReadSPDX(filename string) (*spdx.Document2_2, error) {
 	return doc, err
 }
 
+// LoadSPDX reads a tag-value SPDX document without the checks of ReadSPDX,
+// for documents that need not describe a package, such as those of image
+// scans that are nested into another with ReplaceSPDX
+func LoadSPDX(filename string) (*spdx.Document2_2, error) {
+	r, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return tvloader.Load2_2(r)
+}
+
 func WriteSPDX(filename string, doc *spdx.Document2_2) error {
 	r, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
@@ -106,9 +119,18 @@ func WriteSPDX(filename string, doc *spdx.Document2_2) error {
 	return tvsaver.Save2_2(doc, r)
 }
 
+// ImageToPackage is the package of an image, named after its repository and
+// versioned by its tag or digest
 func ImageToPackage(image string) *spdx.Package2_2 {
-	parts := strings.Split(image, ":")
-	id := fmt.Sprintf("image-%v", image)
+	name, version := image, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, version = name[:i], name[i+1:]
+	}
+	// a : before a / is a registry port, not a tag
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i+1:], "/") {
+		name, version = name[:i], name[i+1:]
+	}
+	id := ElementID("image-" + image)
 	return &spdx.Package2_2{
 
 		// NOT PART OF SPEC
@@ -118,15 +140,15 @@ func ImageToPackage(image string) *spdx.Package2_2 {
 
 		// 3.1: Package Name
 		// Cardinality: mandatory, one
-		PackageName: parts[0],
+		PackageName: name,
 
 		// 3.2: Package SPDX Identifier: "SPDXRef-[idstring]"
 		// Cardinality: mandatory, one
-		PackageSPDXIdentifier: spdx.ElementID(id),
+		PackageSPDXIdentifier: id,
 
 		// 3.3: Package Version
 		// Cardinality: optional, one
-		PackageVersion: parts[1],
+		PackageVersion: version,
 
 		// 3.4: Package File Name
 		// Cardinality: optional, one
@@ -258,16 +280,72 @@ func ImageToPackage(image string) *spdx.Package2_2 {
 	}
 }
 
-func ToCycloneDX(spdxBom *spdx.Document2_2) *cyclonedx.BOM {
-	cyclone := &cyclonedx.BOM{
-		// BOMFormat: "",
-		XMLNS: "http://cyclonedx.org/schema/bom/1.2",
+// ElementID makes a valid SPDX element ID of a component ref, replacing the
+// characters SPDX does not allow
+func ElementID(ref string) spdx.ElementID {
+	return spdx.ElementID(invalidElementIDChars.ReplaceAllString(ref, "-"))
+}
+
+var invalidElementIDChars = regexp.MustCompile(`[^A-Za-z0-9.\-]`)
+
+// ComponentToPackage is the SPDX package of a component found by a scanner
+func ComponentToPackage(c Component) *spdx.Package2_2 {
+	p := &spdx.Package2_2{
+		PackageName:                 c.Name,
+		PackageSPDXIdentifier:       ElementID(c.Ref),
+		PackageVersion:              c.Version,
+		PackageSupplierOrganization: c.Supplier,
+		PackageDownloadLocation:     "NOASSERTION",
+		FilesAnalyzed:               false,
+		IsFilesAnalyzedTagPresent:   true,
+		PackageLicenseConcluded:     "NOASSERTION",
+		PackageLicenseDeclared:      "NOASSERTION",
+		PackageCopyrightText:        "NOASSERTION",
+	}
+	switch len(c.Licenses) {
+	case 0:
+	case 1:
+		p.PackageLicenseDeclared = c.Licenses[0]
+	default:
+		parts := make([]string, 0, len(c.Licenses))
+		for _, l := range c.Licenses {
+			if strings.Contains(l, " ") {
+				l = "(" + l + ")"
+			}
+			parts = append(parts, l)
+		}
+		p.PackageLicenseDeclared = strings.Join(parts, " AND ")
+	}
+	if strings.HasPrefix(c.Digest, "sha256:") {
+		p.PackageChecksums = map[spdx.ChecksumAlgorithm]spdx.Checksum{
+			spdx.SHA256: {Algorithm: spdx.SHA256, Value: strings.TrimPrefix(c.Digest, "sha256:")},
+		}
 	}
+	for _, cpe := range c.CPEs {
+		p.PackageExternalReferences = append(p.PackageExternalReferences, &spdx.PackageExternalReference2_2{
+			Category: string(syft.SecurityReferenceCategory),
+			RefType:  string(syft.Cpe23ExternalRefType),
+			Locator:  cpe,
+		})
+	}
+	if c.PURL != "" {
+		p.PackageExternalReferences = append(p.PackageExternalReferences, &spdx.PackageExternalReference2_2{
+			Category: string(syft.PackageManagerReferenceCategory),
+			RefType:  string(syft.PurlExternalRefType),
+			Locator:  c.PURL,
+		})
+	}
+	return p
+}
+
+func ToCycloneDX(spdxBom *spdx.Document2_2) *cyclonedx.BOM {
+	// the format, spec version and BOM version 1 of a new BOM
+	cyclone := cyclonedx.NewBOM()
 	components := make([]cyclonedx.Component, 0)
-	// cyclone.Version = spdxBom.CreationInfo.P
 	cyclone.Metadata = &cyclonedx.Metadata{
 		Timestamp: spdxBom.CreationInfo.Created, //
 		Component: &cyclonedx.Component{
+			Type: cyclonedx.ComponentTypeApplication,
 			Name: spdxBom.CreationInfo.DocumentName,
 			// Version:
 			BOMRef: spdxBom.CreationInfo.DocumentName,
@@ -287,9 +365,8 @@ func SPDXPackageToCycloneComponent(p *spdx.Package2_2) cyclonedx.Component {
 	component := cyclonedx.Component{
 		Name:    p.PackageName,
 		Version: p.PackageVersion,
-		//TODO @runyontr see how we pull this out
-		Type:   "container",
-		BOMRef: string(p.PackageSPDXIdentifier),
+		Type:    cyclonedx.ComponentTypeLibrary,
+		BOMRef:  string(p.PackageSPDXIdentifier),
 		// CPE: p. SPDX has multiple CPEs... What do we do here?
 		// PackageURL: p.PURL,
 		// PackageURL: p.
@@ -297,7 +374,7 @@ func SPDXPackageToCycloneComponent(p *spdx.Package2_2) cyclonedx.Component {
 	}
 
 	//look for image
-	if strings.Contains(string(p.PackageSPDXIdentifier), "-image-") { //how we made them from the helm chart
+	if strings.HasPrefix(string(p.PackageSPDXIdentifier), "image-") { //how we made them from the helm chart
 		component.Type = cyclonedx.ComponentTypeContainer
 	}
 	if strings.Contains(string(p.PackageSPDXIdentifier), "cpe-") {
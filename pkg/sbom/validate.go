@@ -0,0 +1,484 @@
+package sbom
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/anchore/packageurl-go"
+	"github.com/spdx/tools-golang/jsonloader"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tvloader"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/license"
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// The SPDX schema is the official one from spdx-spec v2.2.2, the CycloneDX schemas are
+// the official 1.2-1.4 ones from the CycloneDX specification, with the SPDX license
+// list and JSF signature schemas they refer to.
+//
+//go:embed schema/*.json
+var schemas embed.FS
+
+// Severity of a validation finding
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a single problem found while validating an SBOM
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Element  string   `json:"element,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// ValidationReport is the result of validating an SBOM
+type ValidationReport struct {
+	Format      string    `json:"format"`
+	SpecVersion string    `json:"specVersion"`
+	Findings    []Finding `json:"findings"`
+}
+
+// Valid reports whether no errors were found, warnings don't count
+func (r *ValidationReport) Valid() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ValidationReport) add(severity Severity, rule string, element string, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{
+		Severity: severity,
+		Rule:     rule,
+		Element:  element,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *ValidationReport) sort() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		if r.Findings[i].Severity != r.Findings[j].Severity {
+			return r.Findings[i].Severity == SeverityError
+		}
+		return r.Findings[i].Rule < r.Findings[j].Rule
+	})
+}
+
+// Write prints the report as text or json
+func (r *ValidationReport) Write(w io.Writer, format string) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(r, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	if format != "text" {
+		return fmt.Errorf("unknown output format %v", format)
+	}
+	fmt.Fprintf(w, "%v %v: %v findings\n", r.Format, r.SpecVersion, len(r.Findings))
+	for _, f := range r.Findings {
+		if f.Element != "" {
+			fmt.Fprintf(w, "  %-7v %-22v %v: %v\n", f.Severity, f.Rule, f.Element, f.Message)
+		} else {
+			fmt.Fprintf(w, "  %-7v %-22v %v\n", f.Severity, f.Rule, f.Message)
+		}
+	}
+	if r.Valid() {
+		fmt.Fprintln(w, "VALID")
+	} else {
+		fmt.Fprintln(w, "INVALID")
+	}
+	return nil
+}
+
+// Validate checks an SBOM in any supported format against the schema for its spec
+// version and against the semantic rules of the spec. Tag-value SPDX has no schema,
+// it's checked against the semantic rules only; CycloneDX XML is checked against the
+// JSON schema of the same version after decoding.
+func Validate(b []byte) (*ValidationReport, error) {
+	format, err := DetectFormat(b)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatSPDX, FormatSPDXJSON:
+		var doc *spdx.Document2_2
+		if format == FormatSPDX {
+			doc, err = tvloader.Load2_2(bytes.NewReader(b))
+		} else {
+			doc, err = jsonloader.Load2_2(bytes.NewReader(b))
+		}
+		if err != nil {
+			return unparseable(format, err), nil
+		}
+		r := ValidateSPDX(doc)
+		r.Format = format
+		if format == FormatSPDXJSON {
+			r.Findings = append(schemaFindings("schema/spdx-2.2.schema.json", b), r.Findings...)
+		}
+		r.sort()
+		return r, nil
+	default:
+		bom := &cyclonedx.BOM{}
+		fileFormat := cyclonedx.BOMFileFormatXML
+		if format == FormatCycloneDXJSON {
+			fileFormat = cyclonedx.BOMFileFormatJSON
+		}
+		if err := cyclonedx.NewBOMDecoder(bytes.NewReader(b), fileFormat).Decode(bom); err != nil {
+			return unparseable(format, err), nil
+		}
+		if format == FormatCycloneDXJSON {
+			// validate what was actually written rather than what we understood of it
+			r := validateCycloneDXSemantics(bom)
+			r.Format = format
+			r.Findings = append(schemaFindings(cycloneDXSchema(r.SpecVersion), b), r.Findings...)
+			r.sort()
+			return r, nil
+		}
+		r := ValidateCycloneDX(bom)
+		r.Format = format
+		return r, nil
+	}
+}
+
+// unparseable is the report for a document that couldn't be read at all
+func unparseable(format string, err error) *ValidationReport {
+	r := &ValidationReport{Format: format}
+	r.add(SeverityError, "parse", "", "unable to parse document: %v", err)
+	return r
+}
+
+// ValidateSPDX checks an in memory SPDX document against the semantic rules of SPDX 2.2
+func ValidateSPDX(doc *spdx.Document2_2) *ValidationReport {
+	r := &ValidationReport{Format: FormatSPDX, Findings: make([]Finding, 0)}
+	ci := doc.CreationInfo
+	if ci == nil {
+		r.add(SeverityError, "missing-field", "", "document has no creation info")
+		return r
+	}
+	r.SpecVersion = ci.SPDXVersion
+	if ci.SPDXVersion != "SPDX-2.2" {
+		r.add(SeverityError, "spec-version", "", "SPDXVersion is %q, expected SPDX-2.2", ci.SPDXVersion)
+	}
+	if ci.DataLicense != "CC0-1.0" {
+		r.add(SeverityError, "missing-field", "", "DataLicense must be CC0-1.0")
+	}
+	if ci.DocumentName == "" {
+		r.add(SeverityError, "missing-field", "", "DocumentName is required")
+	}
+	validateNamespace(r, ci.DocumentNamespace)
+	if len(ci.CreatorPersons)+len(ci.CreatorOrganizations)+len(ci.CreatorTools) == 0 {
+		r.add(SeverityError, "missing-field", "", "at least one Creator is required")
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05Z", ci.Created); err != nil {
+		r.add(SeverityError, "missing-field", "", "Created %q is not in YYYY-MM-DDThh:mm:ssZ format", ci.Created)
+	}
+
+	ids := map[string]bool{spdxDocumentID: true}
+	checkID := func(id spdx.ElementID) {
+		if !validElementID(string(id)) {
+			r.add(SeverityError, "invalid-id", string(id), "element IDs may only contain letters, numbers, . and -")
+		}
+		if ids[string(id)] {
+			r.add(SeverityError, "duplicate-id", string(id), "element ID is used more than once")
+		}
+		ids[string(id)] = true
+	}
+	for _, id := range sortedElementIDs(doc.Packages) {
+		p := doc.Packages[id]
+		checkID(id)
+		if id != p.PackageSPDXIdentifier {
+			r.add(SeverityError, "invalid-id", string(id), "package is stored under a different ID than its SPDXID %v", p.PackageSPDXIdentifier)
+		}
+		validatePackage(r, p)
+		for fid := range p.Files {
+			checkID(fid)
+		}
+	}
+	for fid := range doc.UnpackagedFiles {
+		checkID(fid)
+	}
+
+	known := func(d spdx.DocElementID) bool {
+		return d.SpecialID != "" || d.DocumentRefID != "" || ids[string(d.ElementRefID)]
+	}
+	for _, rel := range doc.Relationships {
+		for _, ref := range []spdx.DocElementID{rel.RefA, rel.RefB} {
+			if !known(ref) {
+				r.add(SeverityError, "dangling-ref", spdx.RenderDocElementID(ref), "%v relationship refers to an element that isn't in the document", rel.Relationship)
+			}
+		}
+	}
+	for _, a := range doc.Annotations {
+		if !known(a.AnnotationSPDXIdentifier) {
+			r.add(SeverityError, "dangling-ref", spdx.RenderDocElementID(a.AnnotationSPDXIdentifier), "annotation refers to an element that isn't in the document")
+		}
+	}
+	r.sort()
+	return r
+}
+
+var uniquePattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{32,}`)
+
+func validateNamespace(r *ValidationReport, ns string) {
+	if ns == "" {
+		r.add(SeverityError, "missing-field", "", "DocumentNamespace is required")
+		return
+	}
+	u, err := url.Parse(ns)
+	if err != nil || u.Scheme == "" {
+		r.add(SeverityError, "invalid-namespace", "", "DocumentNamespace %q is not an absolute URI", ns)
+		return
+	}
+	if strings.Contains(ns, "#") {
+		r.add(SeverityError, "invalid-namespace", "", "DocumentNamespace %q must not contain #", ns)
+	}
+	if !uniquePattern.MatchString(ns) {
+		r.add(SeverityWarning, "namespace-not-unique", "", "DocumentNamespace %q has no UUID or hash so is unlikely to be unique to this document", ns)
+	}
+}
+
+var elementIDPattern = regexp.MustCompile(`^[A-Za-z0-9.\-]+$`)
+
+func validElementID(id string) bool {
+	return elementIDPattern.MatchString(id)
+}
+
+func sortedElementIDs(packages map[spdx.ElementID]*spdx.Package2_2) []spdx.ElementID {
+	ids := make([]spdx.ElementID, 0, len(packages))
+	for id := range packages {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func validatePackage(r *ValidationReport, p *spdx.Package2_2) {
+	id := string(p.PackageSPDXIdentifier)
+	required := map[string]string{
+		"PackageName":             p.PackageName,
+		"PackageDownloadLocation": p.PackageDownloadLocation,
+		"PackageLicenseConcluded": p.PackageLicenseConcluded,
+		"PackageLicenseDeclared":  p.PackageLicenseDeclared,
+		"PackageCopyrightText":    p.PackageCopyrightText,
+	}
+	for _, field := range []string{"PackageName", "PackageDownloadLocation", "PackageLicenseConcluded", "PackageLicenseDeclared", "PackageCopyrightText"} {
+		if required[field] == "" {
+			r.add(SeverityError, "missing-field", id, "%v is required", field)
+		}
+	}
+	if p.FilesAnalyzed && p.PackageVerificationCode == "" {
+		r.add(SeverityError, "missing-field", id, "PackageVerificationCode is required when FilesAnalyzed is true")
+	}
+	for _, l := range []string{p.PackageLicenseConcluded, p.PackageLicenseDeclared} {
+		validateLicense(r, id, l)
+	}
+	for _, ext := range p.PackageExternalReferences {
+		switch ext.RefType {
+		case string(syft.PurlExternalRefType):
+			validatePURL(r, id, ext.Locator)
+		case string(syft.Cpe23ExternalRefType), string(syft.Cpe22ExternalRefType):
+			validateCPE(r, id, ext.Locator)
+		}
+	}
+}
+
+func validateLicense(r *ValidationReport, element string, expression string) {
+	if expression == "" || expression == "NONE" || expression == "NOASSERTION" {
+		return
+	}
+	if _, err := license.Parse(expression); err != nil {
+		r.add(SeverityError, "invalid-license", element, "%v", err)
+	}
+}
+
+func validatePURL(r *ValidationReport, element string, purl string) {
+	if _, err := packageurl.FromString(purl); err != nil {
+		r.add(SeverityError, "invalid-purl", element, "invalid PURL %q: %v", purl, err)
+	}
+}
+
+// cpePattern is the CPE 2.2 URI / 2.3 formatted string pattern from the CycloneDX schema
+var cpePattern = regexp.MustCompile(`^(([c][pP][eE]:/[AHOaho]?(:[A-Za-z0-9\._\-~%]*){0,6})|(cpe:2\.3:[aho\*\-](:(((\?*|\*?)([a-zA-Z0-9\-\._]|(\\[\\\*\?!"#$$%&'\(\)\+,/:;<=>@\[\]\^` + "`" + `\{\|}~]))+(\?*|\*?))|[\*\-])){5}(:(([a-zA-Z]{2,3}(-([a-zA-Z]{2}|[0-9]{3}))?)|[\*\-]))(:(((\?*|\*?)([a-zA-Z0-9\-\._]|(\\[\\\*\?!"#$$%&'\(\)\+,/:;<=>@\[\]\^` + "`" + `\{\|}~]))+(\?*|\*?))|[\*\-])){4}))$`)
+
+func validateCPE(r *ValidationReport, element string, cpe string) {
+	if !cpePattern.MatchString(cpe) {
+		r.add(SeverityError, "invalid-cpe", element, "invalid CPE %q", cpe)
+	}
+}
+
+// ValidateCycloneDX checks an in memory CycloneDX BOM against the schema of its spec
+// version and the semantic rules of CycloneDX
+func ValidateCycloneDX(bom *cyclonedx.BOM) *ValidationReport {
+	r := validateCycloneDXSemantics(bom)
+	b, err := encodeCycloneDXJSON(bom, r.SpecVersion)
+	if err != nil {
+		r.add(SeverityError, "schema", "", "unable to encode BOM: %v", err)
+	} else {
+		r.Findings = append(schemaFindings(cycloneDXSchema(r.SpecVersion), b), r.Findings...)
+	}
+	r.sort()
+	return r
+}
+
+// cycloneDXSpecVersion works out the spec version from the JSON field or the XML namespace
+func cycloneDXSpecVersion(bom *cyclonedx.BOM) string {
+	if bom.SpecVersion != "" {
+		return bom.SpecVersion
+	}
+	if i := strings.LastIndex(bom.XMLNS, "/"); i >= 0 && bom.XMLNS != "" {
+		return bom.XMLNS[i+1:]
+	}
+	return cyclonedx.SpecVersion
+}
+
+func cycloneDXSchema(specVersion string) string {
+	return fmt.Sprintf("schema/cyclonedx-%v.schema.json", specVersion)
+}
+
+// encodeCycloneDXJSON writes the BOM as JSON, filling in the fields that only exist in the JSON encoding
+func encodeCycloneDXJSON(bom *cyclonedx.BOM, specVersion string) ([]byte, error) {
+	tmp := *bom
+	tmp.BOMFormat = cyclonedx.BOMFormat
+	tmp.SpecVersion = specVersion
+	buf := &bytes.Buffer{}
+	err := cyclonedx.NewBOMEncoder(buf, cyclonedx.BOMFileFormatJSON).Encode(&tmp)
+	return buf.Bytes(), err
+}
+
+func validateCycloneDXSemantics(bom *cyclonedx.BOM) *ValidationReport {
+	r := &ValidationReport{
+		Format:      FormatCycloneDX,
+		SpecVersion: cycloneDXSpecVersion(bom),
+		Findings:    make([]Finding, 0),
+	}
+	if bom.SerialNumber == "" {
+		r.add(SeverityWarning, "missing-field", "", "serialNumber is recommended so the BOM can be referenced")
+	}
+
+	refs := make(map[string]bool)
+	var visit func(c cyclonedx.Component)
+	visit = func(c cyclonedx.Component) {
+		element := c.BOMRef
+		if element == "" {
+			element = c.Name
+		}
+		if c.BOMRef != "" {
+			if refs[c.BOMRef] {
+				r.add(SeverityError, "duplicate-id", c.BOMRef, "bom-ref is used more than once")
+			}
+			refs[c.BOMRef] = true
+		}
+		if c.Name == "" {
+			r.add(SeverityError, "missing-field", element, "component name is required")
+		}
+		if c.Type == "" {
+			r.add(SeverityError, "missing-field", element, "component type is required")
+		}
+		if c.PackageURL != "" {
+			validatePURL(r, element, c.PackageURL)
+		}
+		if c.CPE != "" {
+			validateCPE(r, element, c.CPE)
+		}
+		if c.Licenses != nil {
+			for _, l := range *c.Licenses {
+				validateLicense(r, element, l.Expression)
+			}
+		}
+		if c.Components != nil {
+			for _, child := range *c.Components {
+				visit(child)
+			}
+		}
+	}
+	if bom.Metadata != nil {
+		if bom.Metadata.Timestamp != "" {
+			if _, err := time.Parse(time.RFC3339, bom.Metadata.Timestamp); err != nil {
+				r.add(SeverityError, "invalid-timestamp", "", "metadata timestamp %q is not RFC 3339", bom.Metadata.Timestamp)
+			}
+		}
+		if bom.Metadata.Component != nil {
+			visit(*bom.Metadata.Component)
+		}
+	}
+	if bom.Components != nil {
+		for _, c := range *bom.Components {
+			visit(c)
+		}
+	}
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			if !refs[dep.Ref] {
+				r.add(SeverityError, "dangling-ref", dep.Ref, "dependency refers to a bom-ref that isn't in the BOM")
+			}
+			if dep.Dependencies == nil {
+				continue
+			}
+			for _, d := range *dep.Dependencies {
+				if !refs[d.Ref] {
+					r.add(SeverityError, "dangling-ref", d.Ref, "%v depends on a bom-ref that isn't in the BOM", dep.Ref)
+				}
+			}
+		}
+	}
+	return r
+}
+
+// referencedSchemas are the schemas the CycloneDX schemas refer to by their $id
+var referencedSchemas = []string{"schema/cyclonedx-spdx.schema.json", "schema/jsf-0.82.schema.json"}
+
+// schemaFindings validates the JSON document against one of the embedded schemas
+func schemaFindings(schemaFile string, doc []byte) []Finding {
+	s, err := schemas.ReadFile(schemaFile)
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Rule: "schema", Message: fmt.Sprintf("no schema available (%v)", schemaFile)}}
+	}
+	loader := gojsonschema.NewSchemaLoader()
+	for _, f := range referencedSchemas {
+		ref, err := schemas.ReadFile(f)
+		if err != nil {
+			return []Finding{{Severity: SeverityError, Rule: "schema", Message: err.Error()}}
+		}
+		if err := loader.AddSchemas(gojsonschema.NewBytesLoader(ref)); err != nil {
+			return []Finding{{Severity: SeverityError, Rule: "schema", Message: fmt.Sprintf("%v: %v", f, err)}}
+		}
+	}
+	schema, err := loader.Compile(gojsonschema.NewBytesLoader(s))
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Rule: "schema", Message: fmt.Sprintf("%v: %v", schemaFile, err)}}
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(doc))
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Rule: "schema", Message: err.Error()}}
+	}
+	findings := make([]Finding, 0)
+	for _, e := range result.Errors() {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Rule:     "schema",
+			Element:  e.Field(),
+			Message:  e.Description(),
+		})
+	}
+	return findings
+}
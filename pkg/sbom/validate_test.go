@@ -0,0 +1,160 @@
+package sbom_test
+
+import (
+	"testing"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/spdx/tools-golang/spdx"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+func TestValidateCycloneDXSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		bom   string
+		valid bool
+	}{
+		{"1.3 with versions", `{"bomFormat": "CycloneDX", "specVersion": "1.3", "version": 1,
+			"components": [{"type": "library", "name": "openssl", "version": "1.1.1k"}]}`, true},
+		{"1.3 without a component version", `{"bomFormat": "CycloneDX", "specVersion": "1.3", "version": 1,
+			"components": [{"type": "library", "name": "openssl"}]}`, false},
+		{"1.4 without a component version", `{"bomFormat": "CycloneDX", "specVersion": "1.4", "version": 1,
+			"components": [{"type": "library", "name": "openssl"}]}`, true},
+		{"1.4 with an unknown license", `{"bomFormat": "CycloneDX", "specVersion": "1.4", "version": 1,
+			"components": [{"type": "library", "name": "openssl", "licenses": [{"license": {"id": "NotALicense"}}]}]}`, false},
+		{"without a spec version", `{"bomFormat": "CycloneDX", "version": 1}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := sbom.Validate([]byte(tt.bom))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Valid() != tt.valid {
+				t.Errorf("valid is %v, want %v: %+v", r.Valid(), tt.valid, r.Findings)
+			}
+		})
+	}
+}
+
+func TestValidateConvertedSPDX(t *testing.T) {
+	b := generateSPDX(t, generatortest.Scanner())
+	bom, err := sbom.ParseCycloneDX(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := sbom.ValidateCycloneDX(bom); !r.Valid() {
+		t.Errorf("the converted BOM is not valid: %+v", r.Findings)
+	}
+}
+
+// hasFinding reports whether the report has an error for the rule and element
+func hasFinding(r *sbom.ValidationReport, rule string, element string) bool {
+	for _, f := range r.Findings {
+		if f.Severity == sbom.SeverityError && f.Rule == rule && f.Element == element {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateSPDXRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		breakIt func(doc *spdx.Document2_2)
+		rule    string
+		element string
+	}{
+		{"dangling relationship", func(doc *spdx.Document2_2) {
+			doc.Relationships = append(doc.Relationships, &spdx.Relationship2_2{
+				RefA:         spdx.MakeDocElementID("", "chart-demo"),
+				RefB:         spdx.MakeDocElementID("", "missing"),
+				Relationship: "CONTAINS",
+			})
+		}, "dangling-ref", "SPDXRef-missing"},
+		{"duplicate ID", func(doc *spdx.Document2_2) {
+			doc.Packages["chart-demo"].Files = map[spdx.ElementID]*spdx.File2_2{"deb-redis-6.2.6": {FileSPDXIdentifier: "deb-redis-6.2.6"}}
+		}, "duplicate-id", "deb-redis-6.2.6"},
+		{"invalid ID", func(doc *spdx.Document2_2) {
+			p := doc.Packages["deb-redis-6.2.6"]
+			delete(doc.Packages, "deb-redis-6.2.6")
+			p.PackageSPDXIdentifier = "deb_redis"
+			doc.Packages["deb_redis"] = p
+		}, "invalid-id", "deb_redis"},
+		{"invalid license expression", func(doc *spdx.Document2_2) {
+			doc.Packages["deb-redis-6.2.6"].PackageLicenseDeclared = "BSD-3-Clause OR"
+		}, "invalid-license", "deb-redis-6.2.6"},
+		{"invalid PURL", func(doc *spdx.Document2_2) {
+			doc.Packages["deb-redis-6.2.6"].PackageExternalReferences[0].Locator = "deb/debian/redis@6.2.6"
+		}, "invalid-purl", "deb-redis-6.2.6"},
+		{"invalid CPE", func(doc *spdx.Document2_2) {
+			doc.Packages["deb-redis-6.2.6"].PackageExternalReferences = append(doc.Packages["deb-redis-6.2.6"].PackageExternalReferences, &spdx.PackageExternalReference2_2{
+				Category: "SECURITY",
+				RefType:  "cpe23Type",
+				Locator:  "cpe:2.3:redis",
+			})
+		}, "invalid-cpe", "deb-redis-6.2.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := generatortest.Generate(t, generatortest.Chart()).SPDX
+			if r := sbom.ValidateSPDX(doc); !r.Valid() {
+				t.Fatalf("the document is not valid to start with: %+v", r.Findings)
+			}
+			tt.breakIt(doc)
+			if r := sbom.ValidateSPDX(doc); !hasFinding(r, tt.rule, tt.element) {
+				t.Errorf("findings %+v, want a %v error for %v", r.Findings, tt.rule, tt.element)
+			}
+		})
+	}
+}
+
+func TestValidateCycloneDXRules(t *testing.T) {
+	redis := func(bom *cyclonedx.BOM) *cyclonedx.Component {
+		for i, c := range *bom.Components {
+			if c.BOMRef == "deb-redis-6.2.6" {
+				return &(*bom.Components)[i]
+			}
+		}
+		t.Fatal("no redis component")
+		return nil
+	}
+	tests := []struct {
+		name    string
+		breakIt func(bom *cyclonedx.BOM)
+		rule    string
+		element string
+	}{
+		{"dangling dependency", func(bom *cyclonedx.BOM) {
+			deps := append(*bom.Dependencies, cyclonedx.Dependency{Ref: "deb-redis-6.2.6", Dependencies: &[]cyclonedx.Dependency{{Ref: "missing"}}})
+			bom.Dependencies = &deps
+		}, "dangling-ref", "missing"},
+		{"duplicate bom-ref", func(bom *cyclonedx.BOM) {
+			components := append(*bom.Components, *redis(bom))
+			bom.Components = &components
+		}, "duplicate-id", "deb-redis-6.2.6"},
+		{"invalid license expression", func(bom *cyclonedx.BOM) {
+			redis(bom).Licenses = &cyclonedx.Licenses{{Expression: "(BSD-3-Clause"}}
+		}, "invalid-license", "deb-redis-6.2.6"},
+		{"invalid PURL", func(bom *cyclonedx.BOM) {
+			redis(bom).PackageURL = "deb/debian/redis@6.2.6"
+		}, "invalid-purl", "deb-redis-6.2.6"},
+		{"invalid CPE", func(bom *cyclonedx.BOM) {
+			redis(bom).CPE = "cpe:2.3:redis"
+		}, "invalid-cpe", "deb-redis-6.2.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bom := generatortest.Generate(t, generatortest.Chart()).CycloneDX
+			if r := sbom.ValidateCycloneDX(bom); !r.Valid() {
+				t.Fatalf("the BOM is not valid to start with: %+v", r.Findings)
+			}
+			tt.breakIt(bom)
+			if r := sbom.ValidateCycloneDX(bom); !hasFinding(r, tt.rule, tt.element) {
+				t.Errorf("findings %+v, want a %v error for %v", r.Findings, tt.rule, tt.element)
+			}
+		})
+	}
+}
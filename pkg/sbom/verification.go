@@ -0,0 +1,21 @@
+package sbom
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spdx/tools-golang/spdx"
+)
+
+// VerificationCode is the SPDX package verification code of files: the SHA1
+// of their sorted SHA1s, so it changes when any file does
+func VerificationCode(files map[spdx.ElementID]*spdx.File2_2) string {
+	sums := make([]string, 0, len(files))
+	for _, f := range files {
+		sums = append(sums, strings.ToLower(f.FileChecksums[spdx.SHA1].Value))
+	}
+	sort.Strings(sums)
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(sums, ""))))
+}
@@ -0,0 +1,35 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// AttachedScanner uses the SBOM attached to an image in its registry, as an
+// OCI referrer or under the cosign sbom tag, and scans the image with
+// Fallback when there is none or the registry does not have the image, e.g.
+// for an image only the local daemon has. Any other error asking the
+// registry fails the scan rather than silently scanning instead.
+type AttachedScanner struct {
+	Client   *oci.Client
+	Fallback Scanner
+}
+
+// Scan implements Scanner
+func (s *AttachedScanner) Scan(ctx context.Context, image string) (*Image, error) {
+	a, err := s.Client.FindSBOM(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("looking for the SBOM attached to %v: %w", image, err)
+	}
+	if a == nil {
+		return s.Fallback.Scan(ctx, image)
+	}
+	doc, err := sbom.Parse(a.SBOM)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{Packages: packages(doc), Source: a.Source + " " + a.Ref, Digest: a.Digest}, nil
+}
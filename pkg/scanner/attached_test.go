@@ -0,0 +1,131 @@
+package scanner_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/oci"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/scanner"
+)
+
+const attachedBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "version": 1,
+  "components": [
+    {"type": "library", "bom-ref": "deb-openssl", "name": "openssl", "version": "1.1.1k", "purl": "pkg:deb/debian/openssl@1.1.1k"}
+  ]
+}`
+
+// testRegistry runs an in-process registry and returns its host
+func testRegistry(t *testing.T) string {
+	t.Helper()
+	ts := httptest.NewServer(registry.New())
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+// pushImage pushes a random image
+func pushImage(t *testing.T, image string) {
+	t.Helper()
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testClient() *oci.Client {
+	return &oci.Client{Insecure: true, Keychain: authn.NewMultiKeychain()}
+}
+
+func fallback() *scanner.Fake {
+	return &scanner.Fake{Packages: map[string][]sbom.Component{}}
+}
+
+func TestAttachedScannerUsesAttachedSBOM(t *testing.T) {
+	ctx := context.Background()
+	image := testRegistry(t) + "/library/nginx:1.21"
+	pushImage(t, image)
+	client := testClient()
+	if _, err := client.Push(ctx, image, oci.MediaTypeCycloneDXJSON, []byte(attachedBOM), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	fb := fallback()
+	s := &scanner.AttachedScanner{Client: client, Fallback: fb}
+	scanned, err := s.Scan(ctx, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fb.Scanned()) != 0 {
+		t.Errorf("scanned %v, want the attached SBOM used instead", fb.Scanned())
+	}
+	if len(scanned.Packages) != 1 || scanned.Packages[0].Name != "openssl" || scanned.Packages[0].Version != "1.1.1k" {
+		t.Errorf("packages %+v, want openssl 1.1.1k", scanned.Packages)
+	}
+	if !strings.HasPrefix(scanned.Source, oci.SourceReferrer+" ") || !strings.HasPrefix(scanned.Digest, "sha256:") {
+		t.Errorf("source %q and digest %q, want a referrer and the image's digest", scanned.Source, scanned.Digest)
+	}
+}
+
+func TestAttachedScannerFallsBack(t *testing.T) {
+	ctx := context.Background()
+	host := testRegistry(t)
+	pushImage(t, host+"/library/redis:6.2")
+	tests := map[string]string{
+		"no attached SBOM":      host + "/library/redis:6.2",
+		"image not in registry": host + "/library/missing:1.0",
+	}
+	for test, image := range tests {
+		t.Run(test, func(t *testing.T) {
+			fb := fallback()
+			fb.Packages[image] = []sbom.Component{{Ref: "deb-redis", Name: "redis", Version: "6.2.6"}}
+			s := &scanner.AttachedScanner{Client: testClient(), Fallback: fb}
+			scanned, err := s.Scan(ctx, image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scanned.Source != "fake" {
+				t.Errorf("source %q, want the fallback's", scanned.Source)
+			}
+		})
+	}
+}
+
+func TestAttachedScannerReturnsRegistryErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			return
+		}
+		http.Error(w, "broken", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	image := strings.TrimPrefix(ts.URL, "http://") + "/library/nginx:1.21"
+
+	fb := fallback()
+	fb.Packages[image] = []sbom.Component{{Ref: "deb-nginx", Name: "nginx"}}
+	s := &scanner.AttachedScanner{Client: testClient(), Fallback: fb}
+	if _, err := s.Scan(context.Background(), image); err == nil {
+		t.Errorf("scanning with a broken registry succeeded, want its error")
+	}
+	if len(fb.Scanned()) != 0 {
+		t.Errorf("scanned %v, want no fallback on a registry error", fb.Scanned())
+	}
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Fake returns canned packages, for tests that should not pull images
+type Fake struct {
+	// Packages are returned for each image; an image without an entry fails
+	Packages map[string][]sbom.Component
+	// Err, if set, is returned for every image
+	Err error
+
+	mu      sync.Mutex
+	scanned []string
+}
+
+// Scan implements Scanner
+func (f *Fake) Scan(ctx context.Context, image string) (*Image, error) {
+	f.mu.Lock()
+	f.scanned = append(f.scanned, image)
+	f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	p, ok := f.Packages[image]
+	if !ok {
+		return nil, fmt.Errorf("fake scanner has no packages for %v", image)
+	}
+	return &Image{Packages: p, Source: "fake"}, nil
+}
+
+// Scanned are the images Scan was called with, in order
+func (f *Fake) Scanned() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.scanned...)
+}
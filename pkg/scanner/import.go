@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// ImportScanner reads the packages of an image from an SBOM someone else made,
+// e.g. one the vendor ships with the image, instead of scanning it. The SBOM
+// can be SPDX or CycloneDX in any encoding.
+type ImportScanner struct {
+	// Files are the SBOM files by image reference
+	Files map[string]string
+	// Dir holds SBOMs named after the image, with /, : and @ replaced by _
+	// and one of Extensions, e.g. registry1.dso.mil_ironbank_opensource_istio_pilot_1.11.2.spdx
+	Dir string
+}
+
+// Extensions are the ones an SBOM in Dir can have, in the order they are tried
+var Extensions = []string{".spdx", ".spdx.json", ".cdx.json", ".cdx.xml", ".json", ".xml"}
+
+// FileName is the name, without extension, the SBOM of an image has in Dir
+func FileName(image string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(image)
+}
+
+// Scan implements Scanner
+func (s *ImportScanner) Scan(ctx context.Context, image string) (*Image, error) {
+	file, err := s.file(image)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := sbom.Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading the SBOM of %v: %w", image, err)
+	}
+	// the base name, so the SBOM does not depend on where the files were
+	return &Image{Packages: packages(doc), Source: Import + " " + filepath.Base(file)}, nil
+}
+
+func (s *ImportScanner) file(image string) (string, error) {
+	if f, ok := s.Files[image]; ok {
+		return f, nil
+	}
+	if s.Dir != "" {
+		name := filepath.Join(s.Dir, FileName(image))
+		for _, ext := range Extensions {
+			if _, err := os.Stat(name + ext); err == nil {
+				return name + ext, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no SBOM to import for %v", image)
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/syft"
+)
+
+// Scanner finds the packages in a container image. The packages come back in
+// the format neutral model of pkg/sbom, so backends need not produce SPDX.
+type Scanner interface {
+	Scan(ctx context.Context, image string) (*Image, error)
+}
+
+// Image is what a scanner found in an image
+type Image struct {
+	Packages []sbom.Component
+	// Source says where the packages came from, e.g. syft, or the file or
+	// registry artifact an SBOM was read from
+	Source string
+	// Digest is the digest of the image, when the scanner learnt it
+	Digest string
+}
+
+// Names of the backends, for --scanner
+const (
+	Syft   = "syft"
+	Import = "import"
+)
+
+// Names are the backends that can be selected by name
+var Names = []string{Syft, Import}
+
+// SyftScanner scans images with syft, pulling them from their registry
+type SyftScanner struct{}
+
+// Scan implements Scanner. Only the packages of syft's document are kept, so
+// its namespace and creator are syft's defaults; the generator makes the
+// documents it writes itself.
+func (SyftScanner) Scan(ctx context.Context, image string) (*Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := syft.Scan(image, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &Image{Packages: packages(sbom.FromSPDX(doc)), Source: Syft}, nil
+}
+
+// packages are the components of a document that are not images
+func packages(doc *sbom.Document) []sbom.Component {
+	out := make([]sbom.Component, 0, len(doc.Components))
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			continue
+		}
+		c.Images = nil
+		out = append(out, c)
+	}
+	return out
+}
+
+// New returns the backend with the given name. importFiles and importDir
+// configure the import backend, see ImportScanner.
+func New(name string, importFiles map[string]string, importDir string) (Scanner, error) {
+	switch name {
+	case "", Syft:
+		return SyftScanner{}, nil
+	case Import:
+		if len(importFiles) == 0 && importDir == "" {
+			return nil, fmt.Errorf("the import scanner needs image SBOM files or a directory of them")
+		}
+		return &ImportScanner{Files: importFiles, Dir: importDir}, nil
+	}
+	return nil, fmt.Errorf("unknown scanner %v, must be one of %v", name, Names)
+}
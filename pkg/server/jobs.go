@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/helm"
+)
+
+// Job statuses
+const (
+	Queued    = "queued"
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// Generated is the SBOM made from a chart archive
+type Generated struct {
+	Chart   string
+	Version string
+	SBOM    []byte
+}
+
+// GenerateFunc makes an SBOM in the given format from a chart archive
+type GenerateFunc func(ctx context.Context, archive []byte, format string) (*Generated, error)
+
+// GenerateWith does what create does, for a chart archive sent to serve
+func GenerateWith(gen *generator.Generator) GenerateFunc {
+	return func(ctx context.Context, archive []byte, format string) (*Generated, error) {
+		chart, err := helm.LoadArchive(bytes.NewReader(archive))
+		if err != nil {
+			return nil, fmt.Errorf("reading the chart archive: %w", err)
+		}
+		res, err := gen.GenerateChart(ctx, chart, generator.WithFormats(format))
+		if err != nil {
+			return nil, err
+		}
+		return &Generated{Chart: chart.Metadata.Name, Version: chart.Metadata.Version, SBOM: res.Encoded[format]}, nil
+	}
+}
+
+// Job is an SBOM generation request. Jobs run in the background, at most
+// MaxScans at a time, and are polled for their status.
+type Job struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Format   string `json:"format"`
+	Chart    string `json:"chart,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Created  string `json:"created"`
+	Started  string `json:"started,omitempty"`
+	Finished string `json:"finished,omitempty"`
+	// SBOM is where to get the result once the job has succeeded
+	SBOM string `json:"sbom,omitempty"`
+
+	result   []byte
+	finished time.Time
+}
+
+// jobs holds the jobs of a server in memory; they do not survive a restart,
+// use the inventory to keep SBOMs. Finished jobs are forgotten once they are
+// older than ttl.
+type jobs struct {
+	mu   sync.Mutex
+	byID map[string]*Job
+	ttl  time.Duration
+	// sem bounds the number of jobs running at once
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+func newJobs(maxScans int, ttl time.Duration) *jobs {
+	if maxScans < 1 {
+		maxScans = 1
+	}
+	return &jobs{byID: make(map[string]*Job), ttl: ttl, sem: make(chan struct{}, maxScans)}
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// start queues a job that runs fn once a slot is free, and calls done with
+// its outcome
+func (js *jobs) start(ctx context.Context, format string, fn func(ctx context.Context) (*Generated, error), done func(*Job, *Generated)) Job {
+	j := &Job{ID: uuid.New().String(), Status: Queued, Format: format, Created: now()}
+	js.mu.Lock()
+	js.prune()
+	js.byID[j.ID] = j
+	snapshot := *j
+	js.mu.Unlock()
+
+	js.wg.Add(1)
+	go func() {
+		defer js.wg.Done()
+		select {
+		case js.sem <- struct{}{}:
+		case <-ctx.Done():
+			js.finish(j, nil, ctx.Err())
+			return
+		}
+		defer func() { <-js.sem }()
+		js.update(j, func(j *Job) {
+			j.Status = Running
+			j.Started = now()
+		})
+		g, err := fn(ctx)
+		if err == nil && done != nil {
+			done(j, g)
+		}
+		js.finish(j, g, err)
+	}()
+	return snapshot
+}
+
+func (js *jobs) update(j *Job, fn func(*Job)) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	fn(j)
+}
+
+func (js *jobs) finish(j *Job, g *Generated, err error) {
+	js.update(j, func(j *Job) {
+		j.finished = time.Now()
+		j.Finished = j.finished.UTC().Format(time.RFC3339)
+		if err != nil {
+			j.Status = Failed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = Succeeded
+		j.Chart = g.Chart
+		j.Version = g.Version
+		j.SBOM = fmt.Sprintf("/v1/jobs/%v/sbom", j.ID)
+		j.result = g.SBOM
+	})
+}
+
+// prune forgets the jobs that finished more than ttl ago; js.mu must be held
+func (js *jobs) prune() {
+	for id, j := range js.byID {
+		if !j.finished.IsZero() && time.Since(j.finished) > js.ttl {
+			delete(js.byID, id)
+		}
+	}
+}
+
+// get returns a copy of the job, safe to read while it runs
+func (js *jobs) get(id string) (Job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.prune()
+	j, ok := js.byID[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+func (js *jobs) list() []Job {
+	js.mu.Lock()
+	js.prune()
+	out := make([]Job, 0, len(js.byID))
+	for _, j := range js.byID {
+		out = append(out, *j)
+	}
+	js.mu.Unlock()
+	sort.Slice(out, func(i, k int) bool {
+		if out[i].Created != out[k].Created {
+			return out[i].Created < out[k].Created
+		}
+		return out[i].ID < out[k].ID
+	})
+	return out
+}
+
+// wait blocks until every job has finished
+func (js *jobs) wait() {
+	js.wg.Wait()
+}
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/inventory"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// OpenAPI is the spec of the API, served at /openapi.yaml
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// Options configure a Server
+type Options struct {
+	// Generate makes the SBOM for a chart archive; required
+	Generate GenerateFunc
+	// Inventory, if set, keeps every generated SBOM and serves GET /v1/sboms
+	Inventory *inventory.Store
+	// MaxScans is how many generation jobs run at once, 1 if not set
+	MaxScans int
+	// MaxUpload is the largest request body accepted, 64MiB if not set
+	MaxUpload int64
+	// JobTTL is how long finished jobs and their SBOMs are kept, an hour if
+	// not set
+	JobTTL time.Duration
+}
+
+// Server serves the REST API
+type Server struct {
+	opts   Options
+	jobs   *jobs
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a server. Close it to cancel the jobs still queued or running.
+func New(opts Options) *Server {
+	if opts.MaxUpload <= 0 {
+		opts.MaxUpload = 64 << 20
+	}
+	if opts.JobTTL <= 0 {
+		opts.JobTTL = time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{opts: opts, jobs: newJobs(opts.MaxScans, opts.JobTTL), ctx: ctx, cancel: cancel}
+}
+
+// Close cancels the jobs and waits for them to stop
+func (s *Server) Close() {
+	s.cancel()
+	s.jobs.wait()
+}
+
+// Handler routes the API:
+//
+//	GET  /openapi.yaml
+//	POST /v1/sboms?format=        generate from a chart archive, returns a job
+//	GET  /v1/sboms                the SBOMs in the inventory
+//	GET  /v1/sboms/{chart}/{version}
+//	GET  /v1/jobs
+//	GET  /v1/jobs/{id}
+//	GET  /v1/jobs/{id}/sbom
+//	POST /v1/diff?format=         multipart old and new
+//	POST /v1/merge?name=&format=  multipart sbom, repeated
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.yaml", s.openAPI)
+	mux.HandleFunc("/v1/sboms", s.sboms)
+	mux.HandleFunc("/v1/sboms/", s.storedSBOM)
+	mux.HandleFunc("/v1/jobs", s.listJobs)
+	mux.HandleFunc("/v1/jobs/", s.job)
+	mux.HandleFunc("/v1/diff", s.diff)
+	mux.HandleFunc("/v1/merge", s.merge)
+	return mux
+}
+
+// httpError is an error with the status to answer it with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	if errors.As(err, &he) {
+		status = he.status
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, errorf(http.StatusMethodNotAllowed, "%v is not allowed on %v", r.Method, r.URL.Path))
+	return false
+}
+
+// contentTypes are the media types of the SBOM formats
+var contentTypes = map[string]string{
+	sbom.FormatSPDX:          "text/spdx",
+	sbom.FormatSPDXJSON:      "application/spdx+json",
+	sbom.FormatCycloneDX:     "application/vnd.cyclonedx+xml",
+	sbom.FormatCycloneDXJSON: "application/vnd.cyclonedx+json",
+}
+
+func writeSBOM(w http.ResponseWriter, b []byte) {
+	format, err := sbom.DetectFormat(b)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Write(b)
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(OpenAPI)
+}
+
+func (s *Server) sboms(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.generate(w, r)
+	case http.MethodGet:
+		if s.opts.Inventory == nil {
+			writeError(w, errorf(http.StatusNotImplemented, "the server has no inventory"))
+			return
+		}
+		entries, err := s.opts.Inventory.List(r.URL.Query().Get("chart"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, errorf(http.StatusMethodNotAllowed, "%v is not allowed on %v", r.Method, r.URL.Path))
+	}
+}
+
+// generate reads a chart archive, either as the body or as the chart part of
+// a multipart form, and starts a job to make its SBOM
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = sbom.FormatSPDX
+	}
+	switch format {
+	case sbom.FormatSPDX, sbom.FormatCycloneDX, sbom.FormatCycloneDXJSON:
+	default:
+		writeError(w, errorf(http.StatusBadRequest, "format must be spdx, cyclonedx or cyclonedx-json, not %v", format))
+		return
+	}
+	var archive []byte
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		archive, err = s.part(w, r, "chart")
+	} else {
+		archive, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, s.opts.MaxUpload))
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(archive) == 0 {
+		writeError(w, errorf(http.StatusBadRequest, "no chart archive in the request"))
+		return
+	}
+	job := s.jobs.start(s.ctx, format, func(ctx context.Context) (*Generated, error) {
+		return s.opts.Generate(ctx, archive, format)
+	}, s.keep)
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// keep adds a generated SBOM to the inventory, if there is one
+func (s *Server) keep(j *Job, g *Generated) {
+	if s.opts.Inventory == nil {
+		return
+	}
+	if _, err := s.opts.Inventory.Add(g.SBOM, g.Chart, g.Version, "job "+j.ID); err != nil {
+		// the SBOM is still served from the job
+		s.jobs.update(j, func(j *Job) {
+			j.Error = fmt.Sprintf("not added to the inventory: %v", err)
+		})
+	}
+}
+
+func (s *Server) storedSBOM(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	if s.opts.Inventory == nil {
+		writeError(w, errorf(http.StatusNotImplemented, "the server has no inventory"))
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/sboms/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		writeError(w, errorf(http.StatusNotFound, "expected /v1/sboms/{chart}/{version}"))
+		return
+	}
+	b, err := s.opts.Inventory.Raw(parts[0], parts[1])
+	if err != nil {
+		writeError(w, &httpError{status: http.StatusNotFound, err: err})
+		return
+	}
+	writeSBOM(w, b)
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.jobs.list())
+}
+
+func (s *Server) job(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/v1/jobs/")
+	id := strings.TrimSuffix(rest, "/sbom")
+	j, ok := s.jobs.get(id)
+	if !ok || strings.Contains(id, "/") {
+		writeError(w, errorf(http.StatusNotFound, "no job %v", id))
+		return
+	}
+	if id == rest {
+		writeJSON(w, http.StatusOK, j)
+		return
+	}
+	switch j.Status {
+	case Succeeded:
+		writeSBOM(w, j.result)
+	case Failed:
+		writeError(w, errorf(http.StatusUnprocessableEntity, "job %v failed: %v", id, j.Error))
+	default:
+		writeError(w, errorf(http.StatusConflict, "job %v is %v", id, j.Status))
+	}
+}
+
+// parts reads the files of a multipart form field
+func (s *Server) parts(w http.ResponseWriter, r *http.Request, field string) ([][]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxUpload)
+	if err := r.ParseMultipartForm(s.opts.MaxUpload); err != nil {
+		return nil, errorf(http.StatusBadRequest, "reading the form: %v", err)
+	}
+	out := make([][]byte, 0)
+	for _, fh := range r.MultipartForm.File[field] {
+		b, err := readPart(fh)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func (s *Server) part(w http.ResponseWriter, r *http.Request, field string) ([]byte, error) {
+	files, err := s.parts(w, r, field)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 {
+		return nil, errorf(http.StatusBadRequest, "expected one %v file, got %v", field, len(files))
+	}
+	return files[0], nil
+}
+
+func readPart(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+func (s *Server) diff(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	docs := make([]*sbom.Document, 0, 2)
+	for _, field := range []string{"old", "new"} {
+		b, err := s.part(w, r, field)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		doc, err := sbom.Parse(b)
+		if err != nil {
+			writeError(w, errorf(http.StatusBadRequest, "%v: %v", field, err))
+			return
+		}
+		docs = append(docs, doc)
+	}
+	buf := &bytes.Buffer{}
+	if err := sbom.Compare(docs[0], docs[1]).Write(buf, format); err != nil {
+		writeError(w, errorf(http.StatusBadRequest, "%v", err))
+		return
+	}
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown")
+	default:
+		w.Header().Set("Content-Type", "text/plain")
+	}
+	io.Copy(w, buf)
+}
+
+func (s *Server) merge(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "merged"
+	}
+	fileFormat := cyclonedx.BOMFileFormatJSON
+	switch format := r.URL.Query().Get("format"); format {
+	case "", sbom.FormatCycloneDXJSON:
+	case sbom.FormatCycloneDX:
+		fileFormat = cyclonedx.BOMFileFormatXML
+	default:
+		writeError(w, errorf(http.StatusBadRequest, "merged SBOMs are CycloneDX, format must be cyclonedx or cyclonedx-json, not %v", format))
+		return
+	}
+	files, err := s.parts(w, r, "sbom")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(files) < 2 {
+		writeError(w, errorf(http.StatusBadRequest, "expected at least two sbom files, got %v", len(files)))
+		return
+	}
+	boms := make([]*cyclonedx.BOM, 0, len(files))
+	for i, b := range files {
+		bom, err := sbom.ParseCycloneDX(b)
+		if err != nil {
+			writeError(w, errorf(http.StatusBadRequest, "sbom %v: %v", i+1, err))
+			return
+		}
+		boms = append(boms, bom)
+	}
+	merged, err := sbom.MergeCycloneDX(boms, name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := cyclonedx.NewBOMEncoder(buf, fileFormat).Encode(merged); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeSBOM(w, buf.Bytes())
+}
@@ -0,0 +1,240 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/server"
+)
+
+// packageChart makes the archive of a chart
+func packageChart(t *testing.T, c *chart.Chart) []byte {
+	t.Helper()
+	filename, err := chartutil.Save(c, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// testServer serves generation with the fake scanner, as serve does
+func testServer(t *testing.T, opts server.Options) *httptest.Server {
+	t.Helper()
+	opts.Generate = server.GenerateWith(generatortest.Generator())
+	s := server.New(opts)
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts
+}
+
+func getJob(t *testing.T, ts *httptest.Server, id string) (server.Job, int) {
+	t.Helper()
+	resp, err := http.Get(ts.URL + "/v1/jobs/" + id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var j server.Job
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return j, resp.StatusCode
+}
+
+// startJob posts the archive and polls the job it starts until it has finished
+func startJob(t *testing.T, ts *httptest.Server, format string, archive []byte) server.Job {
+	t.Helper()
+	resp, err := http.Post(ts.URL+"/v1/sboms?format="+format, "application/gzip", bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /v1/sboms answered %v, want %v", resp.StatusCode, http.StatusAccepted)
+	}
+	var j server.Job
+	if err := json.NewDecoder(resp.Body).Decode(&j); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		j, _ = getJob(t, ts, j.ID)
+		if j.Status == server.Succeeded || j.Status == server.Failed {
+			return j
+		}
+	}
+	t.Fatalf("job %v is still %v", j.ID, j.Status)
+	return j
+}
+
+func get(t *testing.T, url string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, b
+}
+
+// checkBOM fails the test unless b is a valid CycloneDX JSON BOM with its
+// format, spec version and version set
+func checkBOM(t *testing.T, b []byte) {
+	t.Helper()
+	var bom struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		Version     int    `json:"version"`
+	}
+	if err := json.Unmarshal(b, &bom); err != nil {
+		t.Fatal(err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion == "" || bom.Version != 1 {
+		t.Errorf("the BOM is %q %q version %v, want CycloneDX with a spec version and version 1", bom.BOMFormat, bom.SpecVersion, bom.Version)
+	}
+	r, err := sbom.Validate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range r.Findings {
+		if f.Severity == sbom.SeverityError {
+			t.Errorf("%v %v: %v", f.Rule, f.Element, f.Message)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ts := testServer(t, server.Options{})
+	j := startJob(t, ts, sbom.FormatCycloneDXJSON, packageChart(t, generatortest.Chart()))
+	if j.Status != server.Succeeded {
+		t.Fatalf("job %v, want it to succeed", j.Error)
+	}
+	if j.Chart != "demo" || j.Version != "0.1.0" {
+		t.Errorf("job is for %v %v, want demo 0.1.0", j.Chart, j.Version)
+	}
+
+	resp, b := get(t, ts.URL+j.SBOM)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %v answered %v: %s", j.SBOM, resp.StatusCode, b)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/vnd.cyclonedx+json" {
+		t.Errorf("content type %v, want application/vnd.cyclonedx+json", ct)
+	}
+	checkBOM(t, b)
+	doc, err := sbom.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, c := range doc.Components {
+		found = found || (c.Name == "nginx" && c.Version == "1.21.3")
+	}
+	if !found {
+		t.Errorf("nginx 1.21.3 is not a component of %+v", doc.Components)
+	}
+}
+
+func TestGenerateFails(t *testing.T) {
+	ts := testServer(t, server.Options{})
+	c := generatortest.Chart()
+	c.Metadata.Annotations["helm.sh/images"] = "- image: unknown:1.0\n"
+
+	j := startJob(t, ts, sbom.FormatSPDX, packageChart(t, c))
+	if j.Status != server.Failed || j.Error == "" {
+		t.Fatalf("job is %v, want it to fail with an error", j.Status)
+	}
+	if resp, b := get(t, ts.URL+"/v1/jobs/"+j.ID+"/sbom"); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("GET the SBOM of a failed job answered %v: %s, want %v", resp.StatusCode, b, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGenerateBadArchive(t *testing.T) {
+	ts := testServer(t, server.Options{})
+	j := startJob(t, ts, sbom.FormatSPDX, []byte("not a chart"))
+	if j.Status != server.Failed || !strings.Contains(j.Error, "reading the chart archive") {
+		t.Errorf("job is %v with error %q, want it to fail reading the archive", j.Status, j.Error)
+	}
+}
+
+func TestFinishedJobsExpire(t *testing.T) {
+	ts := testServer(t, server.Options{JobTTL: 50 * time.Millisecond})
+	j := startJob(t, ts, sbom.FormatSPDX, packageChart(t, generatortest.Chart()))
+	if j.Status != server.Succeeded {
+		t.Fatalf("job %v, want it to succeed", j.Error)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, status := getJob(t, ts, j.ID); status != http.StatusNotFound {
+		t.Errorf("GET an expired job answered %v, want %v", status, http.StatusNotFound)
+	}
+	resp, b := get(t, ts.URL+"/v1/jobs")
+	var jobs []server.Job
+	if err := json.Unmarshal(b, &jobs); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /v1/jobs answered %v: %s", resp.StatusCode, b)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("jobs %+v, want the expired job gone", jobs)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ts := testServer(t, server.Options{})
+	tgz := packageChart(t, generatortest.Chart())
+	boms := make([][]byte, 0, 2)
+	for _, format := range []string{sbom.FormatSPDX, sbom.FormatCycloneDX} {
+		j := startJob(t, ts, format, tgz)
+		if j.Status != server.Succeeded {
+			t.Fatalf("job %v, want it to succeed", j.Error)
+		}
+		_, b := get(t, ts.URL+j.SBOM)
+		boms = append(boms, b)
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for i, b := range boms {
+		part, err := w.CreateFormFile("sbom", fmt.Sprintf("sbom-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(b)
+	}
+	w.Close()
+	resp, err := http.Post(ts.URL+"/v1/merge?name=demo", w.FormDataContentType(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /v1/merge answered %v: %s", resp.StatusCode, b)
+	}
+	checkBOM(t, b)
+}
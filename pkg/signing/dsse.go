@@ -0,0 +1,56 @@
+package signing
+
+import (
+	"crypto"
+	"fmt"
+)
+
+// Envelope is a DSSE envelope, https://github.com/secure-systems-lab/dsse
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of an Envelope
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   []byte `json:"sig"`
+}
+
+// PAE is the pre-authentication encoding of a payload, which is what DSSE
+// signs
+func PAE(payloadType string, payload []byte) []byte {
+	return append([]byte(fmt.Sprintf("DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))), payload...)
+}
+
+// SignEnvelope wraps the payload in an envelope signed by the key
+func SignEnvelope(key crypto.Signer, payloadType string, payload []byte) (*Envelope, error) {
+	sig, err := Sign(key, PAE(payloadType, payload))
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := KeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		PayloadType: payloadType,
+		Payload:     payload,
+		Signatures:  []Signature{{KeyID: keyID, Sig: sig}},
+	}, nil
+}
+
+// Verify checks that one of the envelope's signatures was made by the key
+func (e *Envelope) Verify(pub crypto.PublicKey) error {
+	if len(e.Signatures) == 0 {
+		return fmt.Errorf("the envelope is not signed")
+	}
+	pae := PAE(e.PayloadType, e.Payload)
+	for _, s := range e.Signatures {
+		if err := Verify(pub, pae, s.Sig); err == nil {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
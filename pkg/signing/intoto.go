@@ -0,0 +1,143 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// PayloadTypeInToto is the DSSE payload type of in-toto statements
+const PayloadTypeInToto = "application/vnd.in-toto+json"
+
+// StatementType is the _type of in-toto statements
+const StatementType = "https://in-toto.io/Statement/v0.1"
+
+// Predicate types of SBOM attestations
+const (
+	PredicateSPDX      = "https://spdx.dev/Document"
+	PredicateCycloneDX = "https://cyclonedx.org/bom"
+)
+
+// Subject is an artifact a statement is about
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Statement is an in-toto statement with an SBOM as its predicate
+type Statement struct {
+	Type          string          `json:"_type"`
+	PredicateType string          `json:"predicateType"`
+	Subject       []Subject       `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// NewStatement makes a statement about the subjects with the SBOM as its
+// predicate. JSON SBOMs are embedded as they are, tag-value and XML ones as
+// a string.
+func NewStatement(b []byte, subjects []Subject) (*Statement, error) {
+	format, err := sbom.DetectFormat(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("an attestation needs at least one subject")
+	}
+	s := &Statement{Type: StatementType, Subject: subjects}
+	switch format {
+	case sbom.FormatSPDX, sbom.FormatSPDXJSON:
+		s.PredicateType = PredicateSPDX
+	default:
+		s.PredicateType = PredicateCycloneDX
+	}
+	if format == sbom.FormatSPDXJSON || format == sbom.FormatCycloneDXJSON {
+		s.Predicate = json.RawMessage(b)
+	} else if s.Predicate, err = json.Marshal(string(b)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SBOM returns the SBOM a statement's predicate holds
+func (s *Statement) SBOM() ([]byte, error) {
+	var text string
+	if err := json.Unmarshal(s.Predicate, &text); err == nil {
+		return []byte(text), nil
+	}
+	return s.Predicate, nil
+}
+
+// Attest signs a statement into a DSSE envelope
+func Attest(key crypto.Signer, s *Statement) (*Envelope, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return SignEnvelope(key, PayloadTypeInToto, payload)
+}
+
+// VerifyAttestation checks the envelope's signature and returns the
+// statement inside it
+func VerifyAttestation(pub crypto.PublicKey, e *Envelope) (*Statement, error) {
+	if e.PayloadType != PayloadTypeInToto {
+		return nil, fmt.Errorf("the envelope holds %v, not an in-toto statement", e.PayloadType)
+	}
+	if err := e.Verify(pub); err != nil {
+		return nil, err
+	}
+	s := &Statement{}
+	if err := json.Unmarshal(e.Payload, s); err != nil {
+		return nil, fmt.Errorf("reading the statement: %w", err)
+	}
+	if s.Type != StatementType {
+		return nil, fmt.Errorf("unsupported statement type %v", s.Type)
+	}
+	return s, nil
+}
+
+// FileSubject is a subject for a file, e.g. the chart archive, named after
+// its base name
+func FileSubject(path string) (Subject, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Subject{}, err
+	}
+	sum := sha256.Sum256(b)
+	return Subject{Name: filepath.Base(path), Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])}}, nil
+}
+
+// ImageSubject is a subject for an image with a digest like sha256:<hex>
+func ImageSubject(image, digest string) (Subject, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Subject{}, fmt.Errorf("digest %q of %v is not algorithm:hex", digest, image)
+	}
+	return Subject{Name: image, Digest: map[string]string{parts[0]: parts[1]}}, nil
+}
+
+// HasSubject reports whether the statement is about a subject with the same
+// name and digests
+func (s *Statement) HasSubject(subject Subject) bool {
+	for _, have := range s.Subject {
+		if have.Name != subject.Name {
+			continue
+		}
+		match := len(subject.Digest) > 0
+		for alg, d := range subject.Digest {
+			if have.Digest[alg] != d {
+				match = false
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
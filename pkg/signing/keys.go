@@ -0,0 +1,159 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PEM block types of the keys ParsePrivateKey and ParsePublicKey read
+const (
+	pemPrivateKey         = "PRIVATE KEY"
+	pemECPrivateKey       = "EC PRIVATE KEY"
+	pemPublicKey          = "PUBLIC KEY"
+	pemCosignPrivateKey   = "ENCRYPTED COSIGN PRIVATE KEY"
+	pemSigstorePrivateKey = "ENCRYPTED SIGSTORE PRIVATE KEY"
+)
+
+// ErrPassword is returned when a cosign key does not decrypt with the password
+var ErrPassword = errors.New("wrong password for the cosign key")
+
+// LoadPrivateKey reads a private key file, see ParsePrivateKey
+func LoadPrivateKey(path string, password []byte) (crypto.Signer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(b, password)
+}
+
+// ParsePrivateKey reads an ECDSA or ed25519 private key, either PEM encoded
+// (PKCS #8 or SEC 1) or as written by cosign generate-key-pair, which the
+// password decrypts
+func ParsePrivateKey(b []byte, password []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in the private key")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case pemPrivateKey:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case pemECPrivateKey:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case pemCosignPrivateKey, pemSigstorePrivateKey:
+		var der []byte
+		if der, err = decryptCosign(block.Bytes, password); err == nil {
+			key, err = x509.ParsePKCS8PrivateKey(der)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("unsupported private key %T, only ECDSA and ed25519 keys can sign", key)
+}
+
+// LoadPublicKey reads a public key file, see ParsePublicKey
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(b)
+}
+
+// ParsePublicKey reads a PEM encoded ECDSA or ed25519 public key, as written
+// by openssl or cosign
+func ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in the public key")
+	}
+	if block.Type != pemPublicKey {
+		return nil, fmt.Errorf("unsupported public key type %q", block.Type)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported public key %T, only ECDSA and ed25519 keys can verify", key)
+}
+
+// KeyID identifies a public key by the SHA-256 of its DER encoding
+func KeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cosignKey is the JSON cosign encrypts private keys into
+type cosignKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// decryptCosign decrypts a cosign private key to its PKCS #8 DER encoding
+func decryptCosign(b []byte, password []byte) ([]byte, error) {
+	k := cosignKey{}
+	if err := json.Unmarshal(b, &k); err != nil {
+		return nil, fmt.Errorf("reading the cosign key: %w", err)
+	}
+	if k.KDF.Name != "scrypt" || k.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported cosign key encryption %v with %v", k.Cipher.Name, k.KDF.Name)
+	}
+	if len(k.Cipher.Nonce) != 24 {
+		return nil, fmt.Errorf("the cosign key nonce is %v bytes, not 24", len(k.Cipher.Nonce))
+	}
+	secret, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	var nonce [24]byte
+	copy(key[:], secret)
+	copy(nonce[:], k.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, ErrPassword
+	}
+	return der, nil
+}
@@ -0,0 +1,64 @@
+// Package signing signs SBOMs with local keys: detached signatures over the
+// file, and in-toto attestations in DSSE envelopes. Verifying needs nothing
+// but the public key.
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSignature is returned when a signature does not match the content
+// and key
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Sign signs content the way cosign sign-blob does: ECDSA keys sign its
+// SHA-256 digest, ed25519 keys the content itself
+func Sign(key crypto.Signer, content []byte) ([]byte, error) {
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		sum := sha256.Sum256(content)
+		return key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	case ed25519.PrivateKey:
+		return key.Sign(rand.Reader, content, crypto.Hash(0))
+	}
+	return nil, fmt.Errorf("unsupported private key %T", key)
+}
+
+// Verify checks a signature Sign made
+func Verify(pub crypto.PublicKey, content, sig []byte) error {
+	ok := false
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(content)
+		ok = ecdsa.VerifyASN1(k, sum[:], sig)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, content, sig)
+	default:
+		return fmt.Errorf("unsupported public key %T", pub)
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// EncodeSignature encodes a detached signature as cosign writes it, base64
+func EncodeSignature(sig []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(sig))
+}
+
+// DecodeSignature reads a detached signature, base64 encoded or raw
+func DecodeSignature(b []byte) []byte {
+	if sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b))); err == nil {
+		return sig
+	}
+	return b
+}
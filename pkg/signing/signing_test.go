@@ -0,0 +1,314 @@
+package signing_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/signing"
+)
+
+// testdata/cosign.key is an ECDSA P-256 key encrypted the way cosign
+// generate-key-pair writes it (scrypt N=32768 r=8 p=1, nacl/secretbox), with
+// testdata/cosign.pub its public key
+const cosignPassword = "correct horse battery staple"
+
+// testKeys are an ECDSA and an ed25519 key, by name
+func testKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"ecdsa": ec, "ed25519": ed}
+}
+
+func TestSignVerify(t *testing.T) {
+	content := []byte(`{"spdxVersion": "SPDX-2.2"}`)
+	for name, key := range testKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			sig, err := signing.Sign(key, content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signing.Verify(key.Public(), content, sig); err != nil {
+				t.Errorf("verifying: %v", err)
+			}
+			// cosign writes detached signatures base64 encoded, and both forms read back
+			if err := signing.Verify(key.Public(), content, signing.DecodeSignature(signing.EncodeSignature(sig))); err != nil {
+				t.Errorf("verifying the encoded signature: %v", err)
+			}
+			if err := signing.Verify(key.Public(), content, signing.DecodeSignature(sig)); err != nil {
+				t.Errorf("verifying the raw signature: %v", err)
+			}
+
+			tampered := append([]byte{}, content...)
+			tampered[len(tampered)-2] = '3'
+			if err := signing.Verify(key.Public(), tampered, sig); !errors.Is(err, signing.ErrInvalidSignature) {
+				t.Errorf("verifying a tampered payload got %v, want ErrInvalidSignature", err)
+			}
+			other := testKeys(t)[name]
+			if err := signing.Verify(other.Public(), content, sig); !errors.Is(err, signing.ErrInvalidSignature) {
+				t.Errorf("verifying with another key got %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) string {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	keys := testKeys(t)
+	ec := keys["ecdsa"].(*ecdsa.PrivateKey)
+	sec1, err := x509.MarshalECPrivateKey(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"sec1": write("ec.key", "EC PRIVATE KEY", sec1)}
+	for name, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[name] = write(name+".key", "PRIVATE KEY", der)
+	}
+	for name, file := range files {
+		t.Run(name, func(t *testing.T) {
+			key, err := signing.LoadPrivateKey(file, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			der, err := x509.MarshalPKIXPublicKey(key.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := signing.LoadPublicKey(write(name+".pub", "PUBLIC KEY", der))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := signing.Sign(key, []byte("sbom"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signing.Verify(pub, []byte("sbom"), sig); err != nil {
+				t.Errorf("verifying with the loaded public key: %v", err)
+			}
+		})
+	}
+
+	for name, b := range map[string][]byte{
+		"not pem":     []byte("not a key"),
+		"certificate": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0}}),
+		"rsa":         pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte{0}}),
+	} {
+		if _, err := signing.ParsePrivateKey(b, nil); err == nil {
+			t.Errorf("%v: parsed a private key, want an error", name)
+		}
+		if _, err := signing.ParsePublicKey(b); err == nil {
+			t.Errorf("%v: parsed a public key, want an error", name)
+		}
+	}
+}
+
+func TestCosignKey(t *testing.T) {
+	key, err := signing.LoadPrivateKey("testdata/cosign.key", []byte(cosignPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); !ok {
+		t.Fatalf("cosign key is a %T, want ECDSA", key)
+	}
+	pub, err := signing.LoadPublicKey("testdata/cosign.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signing.Sign(key, []byte("sbom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signing.Verify(pub, []byte("sbom"), sig); err != nil {
+		t.Errorf("verifying with cosign.pub: %v", err)
+	}
+	id, err := signing.KeyID(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID, _ := signing.KeyID(key.Public()); keyID != id || len(id) != 64 {
+		t.Errorf("key IDs %v and %v, want the same SHA-256", keyID, id)
+	}
+
+	for _, password := range []string{"", "Correct horse battery staple"} {
+		if _, err := signing.LoadPrivateKey("testdata/cosign.key", []byte(password)); !errors.Is(err, signing.ErrPassword) {
+			t.Errorf("password %q got %v, want ErrPassword", password, err)
+		}
+	}
+}
+
+func TestPAE(t *testing.T) {
+	// the test vector of the DSSE protocol
+	got := signing.PAE("http://example.com/HelloWorld", []byte("hello world"))
+	if want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"; string(got) != want {
+		t.Errorf("PAE %q, want %q", got, want)
+	}
+	if got := signing.PAE("", nil); string(got) != "DSSEv1 0  0 " {
+		t.Errorf("PAE of nothing %q", got)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	for name, key := range testKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			e, err := signing.SignEnvelope(key, "text/plain", []byte("hello world"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyID, _ := signing.KeyID(key.Public())
+			if len(e.Signatures) != 1 || e.Signatures[0].KeyID != keyID {
+				t.Errorf("signatures %+v, want one by %v", e.Signatures, keyID)
+			}
+			// the envelope is JSON with base64 payload and signatures
+			b, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read := &signing.Envelope{}
+			if err := json.Unmarshal(b, read); err != nil {
+				t.Fatal(err)
+			}
+			if err := read.Verify(key.Public()); err != nil {
+				t.Errorf("verifying: %v", err)
+			}
+			// the signature covers the payload type as well as the payload
+			read.PayloadType = "application/json"
+			if err := read.Verify(key.Public()); !errors.Is(err, signing.ErrInvalidSignature) {
+				t.Errorf("verifying another payload type got %v, want ErrInvalidSignature", err)
+			}
+			read.Signatures = nil
+			if err := read.Verify(key.Public()); err == nil {
+				t.Error("verified an unsigned envelope")
+			}
+		})
+	}
+}
+
+func TestAttestation(t *testing.T) {
+	key := testKeys(t)["ecdsa"]
+	chart, err := signing.ImageSubject("demo-0.1.0.tgz", "sha256:0123abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sbomJSON := []byte(`{"bomFormat":"CycloneDX","specVersion":"1.3","version":1}`)
+	s, err := signing.NewStatement(sbomJSON, []signing.Subject{chart})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := signing.Attest(key, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := signing.VerifyAttestation(key.Public(), e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.PredicateType != signing.PredicateCycloneDX || verified.Type != signing.StatementType {
+		t.Errorf("statement %v of %v, want an in-toto CycloneDX statement", verified.Type, verified.PredicateType)
+	}
+	if b, err := verified.SBOM(); err != nil || string(b) != string(sbomJSON) {
+		t.Errorf("SBOM %s (%v), want the JSON embedded as it is", b, err)
+	}
+
+	tests := map[string]struct {
+		subject signing.Subject
+		want    bool
+	}{
+		"same":            {chart, true},
+		"other name":      {signing.Subject{Name: "other-0.1.0.tgz", Digest: chart.Digest}, false},
+		"other digest":    {signing.Subject{Name: chart.Name, Digest: map[string]string{"sha256": "ffff"}}, false},
+		"other algorithm": {signing.Subject{Name: chart.Name, Digest: map[string]string{"sha512": "0123abcd"}}, false},
+		"no digest":       {signing.Subject{Name: chart.Name}, false},
+	}
+	for name, test := range tests {
+		if got := verified.HasSubject(test.subject); got != test.want {
+			t.Errorf("%v: has subject %v, want %v", name, got, test.want)
+		}
+	}
+
+	other := testKeys(t)["ecdsa"]
+	if _, err := signing.VerifyAttestation(other.Public(), e); !errors.Is(err, signing.ErrInvalidSignature) {
+		t.Errorf("verifying with another key got %v, want ErrInvalidSignature", err)
+	}
+	e.Payload = append(e.Payload[:len(e.Payload)-1], ' ', '}')
+	if _, err := signing.VerifyAttestation(key.Public(), e); !errors.Is(err, signing.ErrInvalidSignature) {
+		t.Errorf("verifying a tampered statement got %v, want ErrInvalidSignature", err)
+	}
+	notInToto, err := signing.SignEnvelope(key, "text/plain", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signing.VerifyAttestation(key.Public(), notInToto); err == nil {
+		t.Error("verified a plain text envelope as an attestation")
+	}
+}
+
+func TestStatementTagValue(t *testing.T) {
+	tagValue := []byte("SPDXVersion: SPDX-2.2\nDataLicense: CC0-1.0\n")
+	subject, err := signing.ImageSubject("docker.io/library/nginx", "sha256:abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := signing.NewStatement(tagValue, []signing.Subject{subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.PredicateType != signing.PredicateSPDX {
+		t.Errorf("predicate type %v, want SPDX", s.PredicateType)
+	}
+	// tag-value isn't JSON, so the predicate is a string of it
+	var text string
+	if err := json.Unmarshal(s.Predicate, &text); err != nil || text != string(tagValue) {
+		t.Errorf("predicate %s, want the tag-value as a string", s.Predicate)
+	}
+	if b, _ := s.SBOM(); string(b) != string(tagValue) {
+		t.Errorf("SBOM %q, want the tag-value back", b)
+	}
+	if _, err := signing.NewStatement(tagValue, nil); err == nil {
+		t.Error("made a statement without subjects")
+	}
+	if _, err := signing.ImageSubject("nginx", "abcd"); err == nil {
+		t.Error("made a subject of a digest without an algorithm")
+	}
+}
+
+func TestFileSubject(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "demo-0.1.0.tgz")
+	if err := ioutil.WriteFile(filename, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := signing.FileSubject(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	if s.Name != "demo-0.1.0.tgz" || s.Digest["sha256"] != want {
+		t.Errorf("subject %+v, want demo-0.1.0.tgz with sha256 %v", s, want)
+	}
+}
@@ -2,8 +2,11 @@ package syft
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/spdx/tools-golang/spdx"
 
 	"github.com/anchore/stereoscope/pkg/image"
@@ -13,8 +16,15 @@ import (
 	"github.com/anchore/syft/syft/source"
 )
 
-//Scan perform a syft scan of the image
-func Scan(imageName string) (*spdx.Document2_2, error) {
+// DefaultNamespaceBase is the URI SPDX document namespaces are made from
+// unless one is configured
+const DefaultNamespaceBase = "https://bigbang.dev"
+
+var unsafeInNamespace = regexp.MustCompile(`[^A-Za-z0-9.\-]`)
+
+//Scan perform a syft scan of the image, whose document gets a namespace under
+//<namespaceBase>/image/ and names tool, e.g. sbom-cli-v0.1.0, as its creator
+func Scan(imageName string, namespaceBase string, tool string) (*spdx.Document2_2, error) {
 	scope := source.SquashedScope
 	src, cleanup, err := source.New(imageName, &image.RegistryOptions{})
 	if err != nil {
@@ -26,14 +36,21 @@ func Scan(imageName string) (*spdx.Document2_2, error) {
 	if err != nil {
 		return nil, err
 	}
-	doc := CreateSPDX(src, catalog, distro)
+	doc := CreateSPDX(src, catalog, distro, namespaceBase, tool)
 	return &doc, nil
 }
 
-func CreateSPDX(src *source.Source, catalog *pkg.Catalog, distro *distro.Distro) spdx.Document2_2 {
+func CreateSPDX(src *source.Source, catalog *pkg.Catalog, distro *distro.Distro, namespaceBase string, tool string) spdx.Document2_2 {
 	//mostly copied from here: https://github.com/anchore/syft/blob/0395c4744581a3d33bf80656f092b19dd75b32fb/internal/presenter/packages/spdx_tag_value_presenter.go#L34
 
 	packages := Packages(catalog)
+	if namespaceBase == "" {
+		namespaceBase = DefaultNamespaceBase
+	}
+	if tool == "" {
+		tool = "sbom-cli"
+	}
+	name := unsafeInNamespace.ReplaceAllString(src.Metadata.ImageMetadata.UserInput, "-")
 
 	doc := spdx.Document2_2{
 		CreationInfo: &spdx.CreationInfo2_2{
@@ -70,7 +87,7 @@ func CreateSPDX(src *source.Source, catalog *pkg.Catalog, distro *distro.Distro)
 			// In many cases, the URI will point to a web accessible document, but this should not be assumed
 			// to be the case.
 
-			DocumentNamespace: fmt.Sprintf("https://anchore.com/syft/image/%s", src.Metadata.ImageMetadata.UserInput),
+			DocumentNamespace: fmt.Sprintf("%s/image/%s-%s", strings.TrimSuffix(namespaceBase, "/"), name, uuid.New()),
 
 			// 2.6: External Document References
 			// Cardinality: optional, one or many
@@ -84,8 +101,8 @@ func CreateSPDX(src *source.Source, catalog *pkg.Catalog, distro *distro.Distro)
 			//      and/or Tool
 			// Cardinality: mandatory, one or many
 			CreatorPersons:       nil,
-			CreatorOrganizations: []string{"Defenuse Unicorns"},
-			CreatorTools:         []string{"sbom-cli"},
+			CreatorOrganizations: []string{"Defense Unicorns"},
+			CreatorTools:         []string{tool},
 
 			// 2.9: Created: data format YYYY-MM-DDThh:mm:ssZ
 			// Cardinality: mandatory, one
@@ -0,0 +1,95 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// apkSuffixes are the Alpine pre and post release suffixes, in order. A
+// version without a suffix sorts between rc and cvs.
+var apkSuffixes = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+type apkVersion struct {
+	numbers  []string
+	letter   byte
+	suffixes [][2]int
+	revision uint64
+}
+
+func parseAPK(v string) apkVersion {
+	var out apkVersion
+	if i := strings.LastIndex(v, "-r"); i >= 0 {
+		if r, err := strconv.ParseUint(v[i+2:], 10, 64); err == nil {
+			out.revision = r
+			v = v[:i]
+		}
+	}
+	parts := strings.Split(v, "_")
+	for _, s := range parts[1:] {
+		name := strings.TrimRightFunc(s, func(r rune) bool { return r >= '0' && r <= '9' })
+		n, _ := strconv.Atoi(s[len(name):])
+		out.suffixes = append(out.suffixes, [2]int{apkSuffixes[name], n})
+	}
+	base := parts[0]
+	if l := len(base); l > 0 && !isDigit(base[l-1]) && base[l-1] != '.' {
+		out.letter = base[l-1]
+		base = base[:l-1]
+	}
+	out.numbers = strings.Split(base, ".")
+	return out
+}
+
+// compareAPK orders Alpine package versions (1.2.3a_rc1-r2) the way apk does
+func compareAPK(a, b string) int {
+	va, vb := parseAPK(a), parseAPK(b)
+	for i := 0; i < len(va.numbers) || i < len(vb.numbers); i++ {
+		if i >= len(va.numbers) {
+			return -1
+		}
+		if i >= len(vb.numbers) {
+			return 1
+		}
+		x, y := va.numbers[i], vb.numbers[i]
+		// after the first component a leading zero means a fractional comparison
+		if i > 0 && (strings.HasPrefix(x, "0") || strings.HasPrefix(y, "0")) {
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+			continue
+		}
+		xn, _ := strconv.ParseUint(x, 10, 64)
+		yn, _ := strconv.ParseUint(y, 10, 64)
+		if c := cmpUint(xn, yn); c != 0 {
+			return c
+		}
+	}
+	if va.letter != vb.letter {
+		return sign(int(va.letter) - int(vb.letter))
+	}
+	for i := 0; i < len(va.suffixes) || i < len(vb.suffixes); i++ {
+		var x, y [2]int
+		if i < len(va.suffixes) {
+			x = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			y = vb.suffixes[i]
+		}
+		if x[0] != y[0] {
+			return sign(x[0] - y[0])
+		}
+		if x[1] != y[1] {
+			return sign(x[1] - y[1])
+		}
+	}
+	return cmpUint(va.revision, vb.revision)
+}
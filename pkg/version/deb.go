@@ -0,0 +1,106 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareDeb orders Debian package versions ([epoch:]upstream[-revision]) the way dpkg does
+func compareDeb(a, b string) int {
+	ea, ua, ra := splitDeb(a)
+	eb, ub, rb := splitDeb(b)
+	if ea != eb {
+		return cmpUint(ea, eb)
+	}
+	if c := verrevcmp(ua, ub); c != 0 {
+		return c
+	}
+	return verrevcmp(ra, rb)
+}
+
+func splitDeb(v string) (uint64, string, string) {
+	var epoch uint64
+	if i := strings.Index(v, ":"); i >= 0 {
+		epoch, _ = strconv.ParseUint(v[:i], 10, 64)
+		v = v[i+1:]
+	}
+	revision := ""
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		revision = v[i+1:]
+		v = v[:i]
+	}
+	return epoch, v, revision
+}
+
+// debOrder is the weight dpkg gives a character in the non-digit part of a version:
+// ~ sorts before everything, even the end of the string, then letters, then the rest
+func debOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c >= '0' && c <= '9':
+		return 0
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return int(c)
+	}
+	return int(c) + 256
+}
+
+// verrevcmp is dpkg's comparison of an upstream version or revision
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		firstDiff := 0
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := 0, 0
+			if i < len(a) {
+				ac = debOrder(a[i])
+			}
+			if j < len(b) {
+				bc = debOrder(b[j])
+			}
+			if ac != bc {
+				return sign(ac - bc)
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return sign(firstDiff)
+		}
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mavenQualifiers are the well known Maven qualifiers, in order. Unknown
+// qualifiers sort after all of them, lexically.
+var mavenQualifiers = map[string]int{
+	"alpha":     1,
+	"a":         1,
+	"beta":      2,
+	"b":         2,
+	"milestone": 3,
+	"m":         3,
+	"rc":        4,
+	"cr":        4,
+	"snapshot":  5,
+	"":          6,
+	"ga":        6,
+	"final":     6,
+	"release":   6,
+	"sp":        7,
+}
+
+// compareMaven orders Maven versions following ComparableVersion: numbers
+// numerically, qualifiers by their well known order, and trailing zeros and
+// release qualifiers ignored, so 1.0 == 1.0.0 == 1-final
+func compareMaven(a, b string) int {
+	as, bs := mavenItems(a), mavenItems(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareMavenItem(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// mavenItems splits a version into its items. Like ComparableVersion it drops
+// the zeros that end each sublist, one ending at a - or where a number meets a
+// qualifier, so 1.0-alpha == 1-alpha and 1.0-1 == 1-1
+func mavenItems(v string) []string {
+	items := make([]string, 0)
+	for _, sublist := range strings.Split(strings.ToLower(v), "-") {
+		for _, item := range segments(sublist) {
+			if _, err := strconv.ParseUint(item, 10, 64); err != nil {
+				items = trimMavenZeros(items)
+			}
+			items = append(items, item)
+		}
+		items = trimMavenZeros(items)
+	}
+	// trailing release qualifiers carry no weight either
+	for len(items) > 0 && isMavenRelease(items[len(items)-1]) {
+		items = trimMavenZeros(items[:len(items)-1])
+	}
+	return items
+}
+
+// trimMavenZeros drops the zeros at the end of the items
+func trimMavenZeros(items []string) []string {
+	for len(items) > 0 {
+		if n, err := strconv.ParseUint(items[len(items)-1], 10, 64); err != nil || n != 0 {
+			break
+		}
+		items = items[:len(items)-1]
+	}
+	return items
+}
+
+func isMavenRelease(item string) bool {
+	q, ok := mavenQualifiers[item]
+	return ok && q == mavenQualifiers[""]
+}
+
+func compareMavenItem(x, y string) int {
+	xn, xerr := strconv.ParseUint(x, 10, 64)
+	yn, yerr := strconv.ParseUint(y, 10, 64)
+	switch {
+	case xerr == nil && yerr == nil:
+		return cmpUint(xn, yn)
+	case xerr == nil:
+		// a number is newer than any qualifier, and a missing item is 0
+		if y == "" {
+			return cmpUint(xn, 0)
+		}
+		return 1
+	case yerr == nil:
+		if x == "" {
+			return cmpUint(0, yn)
+		}
+		return -1
+	}
+	xq, xok := mavenQualifiers[x]
+	yq, yok := mavenQualifiers[y]
+	switch {
+	case xok && yok:
+		return sign(xq - yq)
+	case xok:
+		return -1
+	case yok:
+		return 1
+	}
+	return strings.Compare(x, y)
+}
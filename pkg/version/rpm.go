@@ -0,0 +1,138 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compareRPM orders RPM versions ([epoch:]version[-release]) the way rpmvercmp does
+func compareRPM(a, b string) int {
+	ea, va, ra := splitRPM(a)
+	eb, vb, rb := splitRPM(b)
+	if ea != eb {
+		return cmpUint(ea, eb)
+	}
+	if c := rpmvercmp(va, vb); c != 0 {
+		return c
+	}
+	if ra == "" || rb == "" {
+		// a missing release matches any release
+		return 0
+	}
+	return rpmvercmp(ra, rb)
+}
+
+func splitRPM(v string) (uint64, string, string) {
+	var epoch uint64
+	if i := strings.Index(v, ":"); i >= 0 {
+		epoch, _ = strconv.ParseUint(v[:i], 10, 64)
+		v = v[i+1:]
+	}
+	release := ""
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		release = v[i+1:]
+		v = v[:i]
+	}
+	return epoch, v, release
+}
+
+func isAlnum(c byte) bool {
+	return c < 128 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isAlnum(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isAlnum(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		// ~ sorts before everything
+		aTilde, bTilde := i < len(a) && a[i] == '~', j < len(b) && b[j] == '~'
+		if aTilde || bTilde {
+			if !aTilde {
+				return 1
+			}
+			if !bTilde {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		// ^ sorts after the end of the string but before anything else
+		aCaret, bCaret := i < len(a) && a[i] == '^', j < len(b) && b[j] == '^'
+		if aCaret || bCaret {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if !aCaret {
+				return 1
+			}
+			if !bCaret {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		numeric := isDigit(a[i])
+		si, sj := i, j
+		if numeric {
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+		} else {
+			for i < len(a) && isAlnum(a[i]) && !isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isAlnum(b[j]) && !isDigit(b[j]) {
+				j++
+			}
+		}
+		x, y := a[si:i], b[sj:j]
+		if y == "" {
+			// numeric segments are newer than alpha ones
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+		if numeric {
+			x = strings.TrimLeft(x, "0")
+			y = strings.TrimLeft(y, "0")
+			if len(x) != len(y) {
+				return sign(len(x) - len(y))
+			}
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case i >= len(a) && j >= len(b):
+		return 0
+	case i >= len(a):
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,55 @@
+package version
+
+import (
+	"github.com/Masterminds/semver/v3"
+)
+
+// Scheme is a versioning scheme with its own ordering rules
+type Scheme string
+
+const (
+	Generic Scheme = "generic"
+	Deb     Scheme = "deb"
+	RPM     Scheme = "rpm"
+	APK     Scheme = "apk"
+	Semver  Scheme = "semver"
+	Maven   Scheme = "maven"
+)
+
+// SchemeFor returns the versioning scheme used by a PURL type
+func SchemeFor(purlType string) Scheme {
+	switch purlType {
+	case "deb":
+		return Deb
+	case "rpm":
+		return RPM
+	case "apk":
+		return APK
+	case "maven":
+		return Maven
+	case "npm", "golang", "cargo", "nuget", "composer", "hex", "pub", "swift":
+		return Semver
+	}
+	return Generic
+}
+
+// CompareWith compares two versions using the rules of the scheme and returns -1, 0 or 1
+func CompareWith(scheme Scheme, a, b string) int {
+	switch scheme {
+	case Deb:
+		return compareDeb(a, b)
+	case RPM:
+		return compareRPM(a, b)
+	case APK:
+		return compareAPK(a, b)
+	case Maven:
+		return compareMaven(a, b)
+	case Semver:
+		va, erra := semver.NewVersion(a)
+		vb, errb := semver.NewVersion(b)
+		if erra == nil && errb == nil {
+			return va.Compare(vb)
+		}
+	}
+	return Compare(a, b)
+}
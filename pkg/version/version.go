@@ -0,0 +1,90 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Compare compares two version strings segment by segment and returns -1, 0 or 1.
+// Runs of digits are compared numerically and everything else lexically, which
+// gives a sensible ordering for most version schemes found in images without
+// knowing which ecosystem they came from.
+func Compare(a, b string) int {
+	as, bs := segments(a), segments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareSegment(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// segments splits a version into alternating runs of digits and non-digits,
+// dropping separators
+func segments(v string) []string {
+	v = strings.TrimPrefix(v, "v")
+	out := make([]string, 0)
+	cur := ""
+	digit := false
+	for _, r := range v {
+		if r == '.' || r == '-' || r == '_' || r == '+' || r == ':' || r == '~' {
+			if cur != "" {
+				out = append(out, cur)
+			}
+			cur = ""
+			continue
+		}
+		if cur != "" && unicode.IsDigit(r) != digit {
+			out = append(out, cur)
+			cur = ""
+		}
+		digit = unicode.IsDigit(r)
+		cur += string(r)
+	}
+	if cur != "" {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func compareSegment(x, y string) int {
+	xn, xerr := strconv.ParseUint(x, 10, 64)
+	yn, yerr := strconv.ParseUint(y, 10, 64)
+	switch {
+	case xerr == nil && yerr == nil:
+		return cmpUint(xn, yn)
+	case x == "" && yerr == nil:
+		return -1
+	case y == "" && xerr == nil:
+		return 1
+	case x == "":
+		// 1.0 > 1.0rc1
+		return 1
+	case y == "":
+		return -1
+	case xerr == nil:
+		// numbers sort after words: 1.0.1 > 1.0.beta
+		return 1
+	case yerr == nil:
+		return -1
+	}
+	return strings.Compare(x, y)
+}
+
+func cmpUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
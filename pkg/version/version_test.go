@@ -0,0 +1,122 @@
+package version_test
+
+import (
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+func TestCompareWith(t *testing.T) {
+	tests := []struct {
+		scheme version.Scheme
+		a, b   string
+		want   int
+	}{
+		// dpkg: ~ sorts before everything, even the end of the version
+		{version.Deb, "1.0~rc1", "1.0", -1},
+		{version.Deb, "1.0~rc1", "1.0~rc2", -1},
+		{version.Deb, "1.0~~", "1.0~", -1},
+		{version.Deb, "1.0", "1.0+deb1", -1},
+		{version.Deb, "1.0a", "1.0+", -1},
+		{version.Deb, "1.1.1k-1+deb11u1", "1.1.1n-0+deb11u2", -1},
+		{version.Deb, "1.1.1n-0+deb10u2", "1.1.1n-0+deb11u1", -1},
+		{version.Deb, "2.10-1", "2.9-1", 1},
+		{version.Deb, "1.0-1", "1.0-1", 0},
+		// the epoch outweighs everything else
+		{version.Deb, "1:1.0", "2.0", 1},
+		{version.Deb, "0:1.0", "1.0", 0},
+		{version.Deb, "1:1.0-1", "1:1.0-2", -1},
+
+		// rpm: ~ sorts before the end and ^ after it
+		{version.RPM, "1.0~rc1", "1.0", -1},
+		{version.RPM, "1.0^git1", "1.0", 1},
+		{version.RPM, "1.0^git1", "1.0.1", -1},
+		{version.RPM, "1.0~rc1", "1.0~rc1^git1", -1},
+		{version.RPM, "1.1.1k-5.el8_5", "1.1.1k-6.el8_5", -1},
+		{version.RPM, "1.0a", "1.0.1", -1},
+		{version.RPM, "10", "9", 1},
+		{version.RPM, "1:1.0-1", "2.0-1", 1},
+		// a missing release matches any release
+		{version.RPM, "1.0", "1.0-5", 0},
+
+		// apk: pre-release suffixes sort before the release, others after
+		{version.APK, "1.2.12_rc1", "1.2.12", -1},
+		{version.APK, "1.2.12_alpha1", "1.2.12_beta1", -1},
+		{version.APK, "1.2.12_rc1", "1.2.12_rc2", -1},
+		{version.APK, "1.2.12", "1.2.12_p1", -1},
+		{version.APK, "1.2.12_git20220101", "1.2.12_p1", -1},
+		{version.APK, "1.2.12-r1", "1.2.12-r2", -1},
+		{version.APK, "1.2.12_rc1-r9", "1.2.12-r0", -1},
+		{version.APK, "1.2.12a", "1.2.12b", -1},
+		{version.APK, "1.2.12", "1.2.12a", -1},
+		{version.APK, "1.10", "1.9", 1},
+		{version.APK, "1.2.12-r0", "1.2.12", 0},
+
+		// maven: qualifiers in their well known order, release ones ignored
+		{version.Maven, "1.0-alpha1", "1.0-beta1", -1},
+		{version.Maven, "1.0-beta1", "1.0-milestone1", -1},
+		{version.Maven, "1.0-m1", "1.0-rc1", -1},
+		{version.Maven, "1.0-rc1", "1.0-snapshot", -1},
+		{version.Maven, "1.0-snapshot", "1.0", -1},
+		{version.Maven, "1.0", "1.0-sp1", -1},
+		{version.Maven, "1.0-sp1", "1.0.1", -1},
+		{version.Maven, "1.0-cr1", "1.0-rc1", 0},
+		{version.Maven, "1.0-a1", "1.0-alpha1", 0},
+		{version.Maven, "1.0-zzz", "1.0-sp", 1},
+		{version.Maven, "1.0-xyz", "1.0-zzz", -1},
+		{version.Maven, "2.0-rc1", "2.0", -1},
+		{version.Maven, "2.0-rc1", "2.0-rc2", -1},
+		{version.Maven, "2.17.1", "2.17.0", 1},
+		{version.Maven, "2.0.10", "2.0.9", 1},
+		// zeros before a qualifier or the end of a sublist carry no weight
+		{version.Maven, "1.0", "1.0.0", 0},
+		{version.Maven, "1.0", "1-final", 0},
+		{version.Maven, "1.0.ga", "1", 0},
+		{version.Maven, "1.0-alpha", "1-alpha", 0},
+		{version.Maven, "1.0.0-alpha1", "1-alpha1", 0},
+		{version.Maven, "1.0alpha1", "1-alpha1", 0},
+		{version.Maven, "1.0-1", "1-1", 0},
+		{version.Maven, "1.0.1-alpha", "1.1-alpha", -1},
+		{version.Maven, "2.0-alpha", "1.0", 1},
+
+		// semver
+		{version.Semver, "1.0.0-rc.1", "1.0.0", -1},
+		{version.Semver, "1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{version.Semver, "1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{version.Semver, "1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{version.Semver, "v0.3.7", "0.3.6", 1},
+		{version.Semver, "1.0.0+build.1", "1.0.0+build.2", 0},
+
+		// anything else is compared segment by segment
+		{version.Generic, "1.21.3", "1.21.10", -1},
+		{version.Generic, "1.0rc1", "1.0", -1},
+		{version.Generic, "1.0.beta", "1.0.1", -1},
+		{version.Generic, "v1.2", "1.2", 0},
+	}
+	for _, tt := range tests {
+		if got := version.CompareWith(tt.scheme, tt.a, tt.b); got != tt.want {
+			t.Errorf("%v: %v vs %v is %v, want %v", tt.scheme, tt.a, tt.b, got, tt.want)
+		}
+		if got := version.CompareWith(tt.scheme, tt.b, tt.a); got != -tt.want {
+			t.Errorf("%v: %v vs %v is %v, want %v", tt.scheme, tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSchemeFor(t *testing.T) {
+	tests := map[string]version.Scheme{
+		"deb":    version.Deb,
+		"rpm":    version.RPM,
+		"apk":    version.APK,
+		"maven":  version.Maven,
+		"npm":    version.Semver,
+		"golang": version.Semver,
+		"pypi":   version.Generic,
+		"":       version.Generic,
+	}
+	for purlType, want := range tests {
+		if got := version.SchemeFor(purlType); got != want {
+			t.Errorf("%q has scheme %v, want %v", purlType, got, want)
+		}
+	}
+}
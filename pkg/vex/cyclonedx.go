@@ -0,0 +1,167 @@
+package vex
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+)
+
+// CycloneDX analysis states and justifications are richer than OpenVEX's,
+// these map between the two vocabularies
+var (
+	stateToStatus = map[cyclonedx.ImpactAnalysisState]string{
+		cyclonedx.IASNotAffected:          StatusNotAffected,
+		cyclonedx.IASFalsePositive:        StatusNotAffected,
+		cyclonedx.IASResolved:             StatusFixed,
+		cyclonedx.IASResolvedWithPedigree: StatusFixed,
+		cyclonedx.IASExploitable:          StatusAffected,
+		cyclonedx.IASInTriage:             StatusUnderInvestigation,
+	}
+	statusToState = map[string]cyclonedx.ImpactAnalysisState{
+		StatusNotAffected:        cyclonedx.IASNotAffected,
+		StatusFixed:              cyclonedx.IASResolved,
+		StatusAffected:           cyclonedx.IASExploitable,
+		StatusUnderInvestigation: cyclonedx.IASInTriage,
+	}
+	cdxToJustification = map[cyclonedx.ImpactAnalysisJustification]string{
+		cyclonedx.IAJCodeNotPresent:               VulnerableCodeNotPresent,
+		cyclonedx.IAJCodeNotReachable:             VulnerableCodeNotInExecutePath,
+		cyclonedx.IAJRequiresConfiguration:        VulnerableCodeCannotBeControlledByAdversary,
+		cyclonedx.IAJRequiresDependency:           VulnerableCodeCannotBeControlledByAdversary,
+		cyclonedx.IAJRequiresEnvironment:          VulnerableCodeCannotBeControlledByAdversary,
+		cyclonedx.IAJProtectedByCompiler:          InlineMitigationsAlreadyExist,
+		cyclonedx.IAJProtectedAtRuntime:           InlineMitigationsAlreadyExist,
+		cyclonedx.IAJProtectedAtPerimeter:         InlineMitigationsAlreadyExist,
+		cyclonedx.IAJProtectedByMitigatingControl: InlineMitigationsAlreadyExist,
+	}
+	justificationToCDX = map[string]cyclonedx.ImpactAnalysisJustification{
+		ComponentNotPresent:                         cyclonedx.IAJCodeNotPresent,
+		VulnerableCodeNotPresent:                    cyclonedx.IAJCodeNotPresent,
+		VulnerableCodeNotInExecutePath:              cyclonedx.IAJCodeNotReachable,
+		VulnerableCodeCannotBeControlledByAdversary: cyclonedx.IAJRequiresEnvironment,
+		InlineMitigationsAlreadyExist:               cyclonedx.IAJProtectedByMitigatingControl,
+	}
+)
+
+// Analysis converts a status, justification and impact to a CycloneDX analysis
+func Analysis(status, justification, impact string) *cyclonedx.VulnerabilityAnalysis {
+	return &cyclonedx.VulnerabilityAnalysis{
+		State:         statusToState[status],
+		Justification: justificationToCDX[justification],
+		Detail:        impact,
+	}
+}
+
+func parseCycloneDX(b []byte, xml bool) (*Document, error) {
+	fileFormat := cyclonedx.BOMFileFormatJSON
+	if xml {
+		fileFormat = cyclonedx.BOMFileFormatXML
+	}
+	bom := &cyclonedx.BOM{}
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(b), fileFormat).Decode(bom); err != nil {
+		return nil, err
+	}
+	doc := &Document{ID: bom.SerialNumber, Statements: make([]Statement, 0)}
+	if bom.Metadata != nil {
+		doc.Timestamp = bom.Metadata.Timestamp
+		if bom.Metadata.Authors != nil && len(*bom.Metadata.Authors) > 0 {
+			doc.Author = (*bom.Metadata.Authors)[0].Name
+		}
+	}
+	if bom.Vulnerabilities == nil {
+		return doc, nil
+	}
+	for _, v := range *bom.Vulnerabilities {
+		if v.Analysis == nil || v.Analysis.State == "" {
+			continue
+		}
+		s := Statement{
+			Vulnerability: v.ID,
+			Status:        stateToStatus[v.Analysis.State],
+			Justification: cdxToJustification[v.Analysis.Justification],
+			Impact:        v.Analysis.Detail,
+			Timestamp:     v.Updated,
+		}
+		if s.Timestamp == "" {
+			s.Timestamp = doc.Timestamp
+		}
+		if v.References != nil {
+			for _, r := range *v.References {
+				s.Aliases = append(s.Aliases, r.ID)
+			}
+		}
+		if v.Analysis.Response != nil {
+			for _, r := range *v.Analysis.Response {
+				if s.ActionStatement != "" {
+					s.ActionStatement += ", "
+				}
+				s.ActionStatement += string(r)
+			}
+		}
+		if s.ActionStatement == "" {
+			s.ActionStatement = v.Recommendation
+		}
+		if v.Affects != nil {
+			for _, a := range *v.Affects {
+				s.Products = append(s.Products, bomLinkRef(a.Ref))
+			}
+		}
+		doc.Statements = append(doc.Statements, s)
+	}
+	return doc, nil
+}
+
+// bomLinkRef is the BOMRef a BOM-Link (urn:cdx:serial/version#ref) points at
+func bomLinkRef(ref string) string {
+	if i := strings.LastIndex(ref, "#"); i >= 0 && strings.HasPrefix(ref, "urn:cdx:") {
+		return ref[i+1:]
+	}
+	return ref
+}
+
+// WriteCycloneDX writes the document as a CycloneDX 1.4 VEX BOM, with one
+// vulnerability per statement affecting the products' BOMRefs, or the
+// subcomponents' when the statement is narrowed down to them
+func (d *Document) WriteCycloneDX(w io.Writer, fileFormat cyclonedx.BOMFileFormat) error {
+	d.sortStatements()
+	bom := cyclonedx.NewBOM()
+	bom.SerialNumber = d.ID
+	bom.Metadata = &cyclonedx.Metadata{Timestamp: d.Timestamp}
+	if d.Author != "" {
+		bom.Metadata.Authors = &[]cyclonedx.OrganizationalContact{{Name: d.Author}}
+	}
+	vulns := make([]cyclonedx.Vulnerability, 0)
+	for _, s := range d.Statements {
+		refs := s.Products
+		if len(s.Subcomponents) > 0 {
+			refs = s.Subcomponents
+		}
+		affects := make([]cyclonedx.Affects, 0)
+		for _, p := range refs {
+			affects = append(affects, cyclonedx.Affects{Ref: p})
+		}
+		v := cyclonedx.Vulnerability{
+			ID:       s.Vulnerability,
+			Updated:  s.Timestamp,
+			Analysis: Analysis(s.Status, s.Justification, s.Impact),
+			Affects:  &affects,
+		}
+		if s.ActionStatement != "" {
+			v.Recommendation = s.ActionStatement
+		}
+		if len(s.Aliases) > 0 {
+			refs := make([]cyclonedx.VulnerabilityReference, 0)
+			for _, a := range s.Aliases {
+				refs = append(refs, cyclonedx.VulnerabilityReference{ID: a})
+			}
+			v.References = &refs
+		}
+		vulns = append(vulns, v)
+	}
+	bom.Vulnerabilities = &vulns
+	encoder := cyclonedx.NewBOMEncoder(w, fileFormat)
+	encoder.SetPretty(true)
+	return encoder.Encode(bom)
+}
@@ -0,0 +1,182 @@
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OpenVEXContext is the context written on generated OpenVEX documents
+const OpenVEXContext = "https://openvex.dev/ns/v0.2.0"
+
+type openVEXDocument struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  string             `json:"timestamp"`
+	Version    int                `json:"version"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability   json.RawMessage `json:"vulnerability"`
+	Products        json.RawMessage `json:"products,omitempty"`
+	Subcomponents   json.RawMessage `json:"subcomponents,omitempty"`
+	Status          string          `json:"status"`
+	Justification   string          `json:"justification,omitempty"`
+	Impact          string          `json:"impact_statement,omitempty"`
+	ActionStatement string          `json:"action_statement,omitempty"`
+	Timestamp       string          `json:"timestamp,omitempty"`
+}
+
+// openVEXVulnerability is the v0.2 form, earlier versions use a plain string
+type openVEXVulnerability struct {
+	ID      string   `json:"@id,omitempty"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// openVEXComponent is the v0.2 form of a product or subcomponent, earlier
+// versions use a plain string
+type openVEXComponent struct {
+	ID            string             `json:"@id,omitempty"`
+	Identifiers   map[string]string  `json:"identifiers,omitempty"`
+	Subcomponents []openVEXComponent `json:"subcomponents,omitempty"`
+}
+
+func (c openVEXComponent) ids() []string {
+	ids := make([]string, 0)
+	if c.ID != "" {
+		ids = append(ids, c.ID)
+	}
+	for _, id := range c.Identifiers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func parseOpenVEX(b []byte) (*Document, error) {
+	raw := openVEXDocument{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	if !strings.Contains(raw.Context, "openvex") {
+		return nil, fmt.Errorf("unsupported VEX context %q", raw.Context)
+	}
+	doc := &Document{ID: raw.ID, Author: raw.Author, Timestamp: raw.Timestamp, Statements: make([]Statement, 0)}
+	for _, rs := range raw.Statements {
+		s := Statement{
+			Status:          rs.Status,
+			Justification:   rs.Justification,
+			Impact:          rs.Impact,
+			ActionStatement: rs.ActionStatement,
+			Timestamp:       rs.Timestamp,
+		}
+		if s.Timestamp == "" {
+			s.Timestamp = raw.Timestamp
+		}
+
+		var name string
+		if err := json.Unmarshal(rs.Vulnerability, &name); err == nil {
+			s.Vulnerability = name
+		} else {
+			v := openVEXVulnerability{}
+			if err := json.Unmarshal(rs.Vulnerability, &v); err != nil {
+				return nil, fmt.Errorf("statement vulnerability: %w", err)
+			}
+			s.Vulnerability = v.Name
+			if s.Vulnerability == "" {
+				s.Vulnerability = v.ID
+			}
+			s.Aliases = v.Aliases
+		}
+
+		products, subcomponents, err := openVEXComponents(rs.Products)
+		if err != nil {
+			return nil, fmt.Errorf("%v products: %w", s.Vulnerability, err)
+		}
+		s.Products = products
+		s.Subcomponents = subcomponents
+		if len(rs.Subcomponents) > 0 {
+			ids, _, err := openVEXComponents(rs.Subcomponents)
+			if err != nil {
+				return nil, fmt.Errorf("%v subcomponents: %w", s.Vulnerability, err)
+			}
+			s.Subcomponents = append(s.Subcomponents, ids...)
+		}
+		doc.Statements = append(doc.Statements, s)
+	}
+	return doc, nil
+}
+
+// openVEXComponents reads a list of products in either the string or the
+// object form, returning their ids and those of their subcomponents
+func openVEXComponents(raw json.RawMessage) ([]string, []string, error) {
+	ids := make([]string, 0)
+	subs := make([]string, 0)
+	if len(raw) == 0 {
+		return ids, subs, nil
+	}
+	var names []string
+	if err := json.Unmarshal(raw, &names); err == nil {
+		return names, subs, nil
+	}
+	components := make([]openVEXComponent, 0)
+	if err := json.Unmarshal(raw, &components); err != nil {
+		return nil, nil, err
+	}
+	for _, c := range components {
+		ids = append(ids, c.ids()...)
+		for _, sub := range c.Subcomponents {
+			subs = append(subs, sub.ids()...)
+		}
+	}
+	return ids, subs, nil
+}
+
+// WriteOpenVEX writes the document as OpenVEX v0.2.0
+func (d *Document) WriteOpenVEX(w io.Writer) error {
+	d.sortStatements()
+	out := openVEXDocument{
+		Context:    OpenVEXContext,
+		ID:         d.ID,
+		Author:     d.Author,
+		Timestamp:  d.Timestamp,
+		Version:    1,
+		Statements: make([]openVEXStatement, 0),
+	}
+	for _, s := range d.Statements {
+		vuln, err := json.Marshal(openVEXVulnerability{Name: s.Vulnerability, Aliases: s.Aliases})
+		if err != nil {
+			return err
+		}
+		products := make([]openVEXComponent, 0)
+		for _, p := range s.Products {
+			product := openVEXComponent{ID: p}
+			for _, sub := range s.Subcomponents {
+				product.Subcomponents = append(product.Subcomponents, openVEXComponent{ID: sub})
+			}
+			products = append(products, product)
+		}
+		rawProducts, err := json.Marshal(products)
+		if err != nil {
+			return err
+		}
+		out.Statements = append(out.Statements, openVEXStatement{
+			Vulnerability:   vuln,
+			Products:        rawProducts,
+			Status:          s.Status,
+			Justification:   s.Justification,
+			Impact:          s.Impact,
+			ActionStatement: s.ActionStatement,
+			Timestamp:       s.Timestamp,
+		})
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
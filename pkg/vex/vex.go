@@ -0,0 +1,198 @@
+package vex
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Statuses a statement can give a vulnerability, using the OpenVEX vocabulary
+const (
+	StatusNotAffected        = "not_affected"
+	StatusAffected           = "affected"
+	StatusFixed              = "fixed"
+	StatusUnderInvestigation = "under_investigation"
+)
+
+// Justifications for a not_affected status, using the OpenVEX vocabulary
+const (
+	ComponentNotPresent                         = "component_not_present"
+	VulnerableCodeNotPresent                    = "vulnerable_code_not_present"
+	VulnerableCodeNotInExecutePath              = "vulnerable_code_not_in_execute_path"
+	VulnerableCodeCannotBeControlledByAdversary = "vulnerable_code_cannot_be_controlled_by_adversary"
+	InlineMitigationsAlreadyExist               = "inline_mitigations_already_exist"
+)
+
+// Statuses are the valid statuses
+var Statuses = []string{StatusNotAffected, StatusAffected, StatusFixed, StatusUnderInvestigation}
+
+// Justifications are the valid justifications
+var Justifications = []string{
+	ComponentNotPresent,
+	VulnerableCodeNotPresent,
+	VulnerableCodeNotInExecutePath,
+	VulnerableCodeCannotBeControlledByAdversary,
+	InlineMitigationsAlreadyExist,
+}
+
+// Document is a format neutral set of VEX statements
+type Document struct {
+	ID         string      `json:"id,omitempty"`
+	Author     string      `json:"author,omitempty"`
+	Timestamp  string      `json:"timestamp,omitempty"`
+	Statements []Statement `json:"statements"`
+}
+
+// Statement says how a vulnerability affects a set of products, typically the
+// image components of a chart SBOM, identified by BOMRef, image name or PURL.
+// Subcomponents narrow it down to the packages within those products.
+type Statement struct {
+	Vulnerability   string   `json:"vulnerability"`
+	Aliases         []string `json:"aliases,omitempty"`
+	Products        []string `json:"products,omitempty"`
+	Subcomponents   []string `json:"subcomponents,omitempty"`
+	Status          string   `json:"status"`
+	Justification   string   `json:"justification,omitempty"`
+	Impact          string   `json:"impact,omitempty"`
+	ActionStatement string   `json:"actionStatement,omitempty"`
+	Timestamp       string   `json:"timestamp,omitempty"`
+}
+
+// Validate checks the statement uses a known status and justification, and
+// that a not_affected statement says why
+func (s Statement) Validate() error {
+	if s.Vulnerability == "" {
+		return fmt.Errorf("statement has no vulnerability")
+	}
+	if !contains(Statuses, s.Status) {
+		return fmt.Errorf("%v: unknown status %q, must be one of %v", s.Vulnerability, s.Status, strings.Join(Statuses, ", "))
+	}
+	if s.Justification != "" && !contains(Justifications, s.Justification) {
+		return fmt.Errorf("%v: unknown justification %q, must be one of %v", s.Vulnerability, s.Justification, strings.Join(Justifications, ", "))
+	}
+	if s.Status == StatusNotAffected && s.Justification == "" && s.Impact == "" {
+		return fmt.Errorf("%v: a not_affected statement needs a justification or an impact statement", s.Vulnerability)
+	}
+	return nil
+}
+
+// Subject is what a statement is matched against: a vulnerability found in a
+// component of one or more products
+type Subject struct {
+	Vulnerability string
+	Aliases       []string
+	// Products are every identifier of the products the component is in
+	Products []string
+	// Component are the identifiers of the component itself
+	Component []string
+}
+
+// Lookup returns the statement that applies to the subject, if any. When
+// several statements apply, the last one wins, so later documents (and later
+// statements in a document) override earlier ones.
+func Lookup(docs []*Document, subject Subject) (Statement, bool) {
+	var found Statement
+	ok := false
+	for _, doc := range docs {
+		for _, s := range doc.Statements {
+			if s.applies(subject) {
+				found, ok = s, true
+			}
+		}
+	}
+	return found, ok
+}
+
+func (s Statement) applies(subject Subject) bool {
+	ids := append([]string{subject.Vulnerability}, subject.Aliases...)
+	vulns := append([]string{s.Vulnerability}, s.Aliases...)
+	if !anyMatch(vulns, ids) {
+		return false
+	}
+	if len(s.Products) > 0 && !anyMatch(s.Products, append(subject.Products, subject.Component...)) {
+		return false
+	}
+	if len(s.Subcomponents) > 0 && !anyMatch(s.Subcomponents, subject.Component) {
+		return false
+	}
+	return true
+}
+
+func anyMatch(want []string, have []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if sameID(w, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameID compares identifiers, treating PURLs that only differ in their
+// qualifiers as the same
+func sameID(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	if strings.HasPrefix(a, "pkg:") && strings.HasPrefix(b, "pkg:") {
+		return stripQualifiers(a) == stripQualifiers(b)
+	}
+	return false
+}
+
+func stripQualifiers(purl string) string {
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		return purl[:i]
+	}
+	return purl
+}
+
+// Load reads an OpenVEX document or a CycloneDX VEX BOM (XML or JSON)
+func Load(filename string) (*Document, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse reads an OpenVEX document or a CycloneDX VEX BOM from its content
+func Parse(b []byte) (*Document, error) {
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCycloneDX(trimmed, true)
+	case bytes.Contains(trimmed, []byte(`"bomFormat"`)):
+		return parseCycloneDX(trimmed, false)
+	case bytes.Contains(trimmed, []byte(`"@context"`)):
+		return parseOpenVEX(trimmed)
+	}
+	return nil, fmt.Errorf("unable to determine the format of the VEX document, expected OpenVEX or CycloneDX")
+}
+
+// sortStatements orders statements by vulnerability then product so
+// generated documents are stable
+func (d *Document) sortStatements() {
+	sort.SliceStable(d.Statements, func(i, j int) bool {
+		a, b := d.Statements[i], d.Statements[j]
+		if a.Vulnerability != b.Vulnerability {
+			return a.Vulnerability < b.Vulnerability
+		}
+		return strings.Join(a.Products, ",") < strings.Join(b.Products, ",")
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
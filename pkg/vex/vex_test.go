@@ -0,0 +1,214 @@
+package vex_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/vex"
+)
+
+const (
+	nginx = "image-docker.io-library-nginx-1.21"
+	redis = "image-docker.io-library-redis-6.2"
+)
+
+// normalize makes empty lists nil, the formats don't agree on which they use
+func normalize(statements []vex.Statement) []vex.Statement {
+	out := make([]vex.Statement, 0, len(statements))
+	for _, s := range statements {
+		for _, list := range []*[]string{&s.Aliases, &s.Products, &s.Subcomponents} {
+			if len(*list) == 0 {
+				*list = nil
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestLoad(t *testing.T) {
+	// CycloneDX can't say which packages of the image it is about, and its
+	// justifications map onto the OpenVEX ones
+	cycloneDX := []vex.Statement{
+		{
+			Vulnerability: "CVE-2022-1292",
+			Aliases:       []string{"DSA-5139-1"},
+			Products:      []string{nginx},
+			Status:        vex.StatusNotAffected,
+			Justification: vex.VulnerableCodeNotInExecutePath,
+			Impact:        "c_rehash is not run",
+			Timestamp:     "2021-10-01T00:00:00Z",
+		},
+		{
+			Vulnerability:   "CVE-2021-23017",
+			Products:        []string{redis},
+			Status:          vex.StatusAffected,
+			ActionStatement: "update, workaround_available",
+			Timestamp:       "2021-10-02T00:00:00Z",
+		},
+	}
+	tests := []struct {
+		file       string
+		id         string
+		statements []vex.Statement
+	}{
+		{
+			file: "testdata/openvex.json",
+			id:   "https://example.com/vex/demo-2021-10-01",
+			statements: []vex.Statement{
+				{
+					Vulnerability: "CVE-2022-1292",
+					Aliases:       []string{"DSA-5139-1"},
+					Products:      []string{nginx},
+					Subcomponents: []string{"pkg:deb/debian/openssl@1.1.1k"},
+					Status:        vex.StatusNotAffected,
+					Justification: vex.VulnerableCodeNotInExecutePath,
+					Impact:        "c_rehash is not run",
+					Timestamp:     "2021-10-01T00:00:00Z",
+				},
+				{
+					Vulnerability:   "CVE-2021-23017",
+					Products:        []string{"pkg:oci/redis?repository_url=docker.io/library/redis&tag=6.2"},
+					Status:          vex.StatusAffected,
+					ActionStatement: "Upgrade to 1.21.6",
+					Timestamp:       "2021-10-02T00:00:00Z",
+				},
+			},
+		},
+		{file: "testdata/vex.cdx.json", id: "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", statements: cycloneDX},
+		{file: "testdata/vex.cdx.xml", id: "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", statements: cycloneDX},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			doc, err := vex.Load(test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.ID != test.id || doc.Author != "Defense Unicorns" || doc.Timestamp != "2021-10-01T00:00:00Z" {
+				t.Errorf("document %q by %q at %q, want %q by Defense Unicorns at 2021-10-01T00:00:00Z", doc.ID, doc.Author, doc.Timestamp, test.id)
+			}
+			if got := normalize(doc.Statements); !reflect.DeepEqual(got, test.statements) {
+				t.Errorf("statements\n%+v\nwant\n%+v", got, test.statements)
+			}
+		})
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	for _, b := range []string{`{"statements": []}`, `{"@context": "https://example.com/ns"}`} {
+		if _, err := vex.Parse([]byte(b)); err == nil {
+			t.Errorf("parsed %v, want an error", b)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	doc, err := vex.Load("testdata/openvex.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := map[string]func(w *bytes.Buffer) error{
+		"openvex":        func(w *bytes.Buffer) error { return doc.WriteOpenVEX(w) },
+		"cyclonedx":      func(w *bytes.Buffer) error { return doc.WriteCycloneDX(w, cyclonedx.BOMFileFormatXML) },
+		"cyclonedx-json": func(w *bytes.Buffer) error { return doc.WriteCycloneDX(w, cyclonedx.BOMFileFormatJSON) },
+	}
+	for format, write := range write {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			read, err := vex.Parse(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			// writing sorts the statements by vulnerability
+			got, want := normalize(read.Statements), normalize(doc.Statements)
+			if format != "openvex" {
+				// CycloneDX affects the subcomponents in place of the products
+				if len(got) != 2 || !reflect.DeepEqual(got[1].Products, []string{"pkg:deb/debian/openssl@1.1.1k"}) {
+					t.Fatalf("statements %+v, want CVE-2022-1292 to affect openssl", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("statements\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement vex.Statement
+		valid     bool
+	}{
+		{"justified", vex.Statement{Vulnerability: "CVE-1", Status: vex.StatusNotAffected, Justification: vex.ComponentNotPresent}, true},
+		{"impact only", vex.Statement{Vulnerability: "CVE-1", Status: vex.StatusNotAffected, Impact: "not used"}, true},
+		{"affected", vex.Statement{Vulnerability: "CVE-1", Status: vex.StatusAffected}, true},
+		{"unexplained", vex.Statement{Vulnerability: "CVE-1", Status: vex.StatusNotAffected}, false},
+		{"no vulnerability", vex.Statement{Status: vex.StatusFixed}, false},
+		{"unknown status", vex.Statement{Vulnerability: "CVE-1", Status: "ignored"}, false},
+		{"unknown justification", vex.Statement{Vulnerability: "CVE-1", Status: vex.StatusNotAffected, Justification: "trust me"}, false},
+	}
+	for _, test := range tests {
+		if err := test.statement.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: got %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	openssl := vex.Subject{
+		Vulnerability: "DSA-5139-1",
+		Aliases:       []string{"CVE-2022-1292"},
+		Products:      []string{"chart-demo", nginx, "docker.io/library/nginx:1.21"},
+		Component:     []string{"deb-openssl-1.1.1k", "pkg:deb/debian/openssl@1.1.1k?distro=debian-11", "openssl@1.1.1k"},
+	}
+	statement := func(status string, products, subcomponents []string) vex.Statement {
+		return vex.Statement{Vulnerability: "CVE-2022-1292", Status: status, Products: products, Subcomponents: subcomponents}
+	}
+	tests := []struct {
+		name       string
+		statements []vex.Statement
+		want       string
+	}{
+		{"no statements", nil, ""},
+		{"other vulnerability", []vex.Statement{{Vulnerability: "CVE-2021-3711", Status: vex.StatusFixed}}, ""},
+		{"any product", []vex.Statement{statement(vex.StatusFixed, nil, nil)}, vex.StatusFixed},
+		{"image bomref", []vex.Statement{statement(vex.StatusFixed, []string{nginx}, nil)}, vex.StatusFixed},
+		{"image name", []vex.Statement{statement(vex.StatusFixed, []string{"docker.io/library/nginx:1.21"}, nil)}, vex.StatusFixed},
+		{"component purl", []vex.Statement{statement(vex.StatusFixed, []string{"pkg:deb/debian/openssl@1.1.1k"}, nil)}, vex.StatusFixed},
+		{"other image", []vex.Statement{statement(vex.StatusFixed, []string{redis}, nil)}, ""},
+		{"subcomponent", []vex.Statement{statement(vex.StatusFixed, []string{nginx}, []string{"openssl@1.1.1k"})}, vex.StatusFixed},
+		{"other subcomponent", []vex.Statement{statement(vex.StatusFixed, []string{nginx}, []string{"pkg:deb/debian/zlib@1.2.11"})}, ""},
+		{"last wins", []vex.Statement{
+			statement(vex.StatusUnderInvestigation, nil, nil),
+			statement(vex.StatusAffected, []string{nginx}, nil),
+			statement(vex.StatusFixed, []string{redis}, nil),
+		}, vex.StatusAffected},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, ok := vex.Lookup([]*vex.Document{{Statements: test.statements}}, openssl)
+			if ok != (test.want != "") || s.Status != test.want {
+				t.Errorf("got %q (found %v), want %q", s.Status, ok, test.want)
+			}
+		})
+	}
+}
+
+func TestLookupLaterDocumentWins(t *testing.T) {
+	subject := vex.Subject{Vulnerability: "CVE-2022-1292", Products: []string{nginx}}
+	docs := []*vex.Document{
+		{Statements: []vex.Statement{{Vulnerability: "CVE-2022-1292", Status: vex.StatusAffected}}},
+		{Statements: []vex.Statement{{Vulnerability: "CVE-2022-1292", Status: vex.StatusFixed}}},
+	}
+	if s, _ := vex.Lookup(docs, subject); s.Status != vex.StatusFixed {
+		t.Errorf("got %q, want the second document's fixed", s.Status)
+	}
+}
@@ -0,0 +1,158 @@
+package vulndb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+// DB is a self contained vulnerability database that can be carried to a
+// disconnected network. It is built on the connected side from OSV and NVD
+// dumps with `vulndb import` and read by `scan-vulns`.
+type DB struct {
+	Built           string          `json:"built"`
+	Sources         []string        `json:"sources,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Vulnerability is one advisory and the packages it affects
+type Vulnerability struct {
+	ID         string     `json:"id"`
+	Aliases    []string   `json:"aliases,omitempty"`
+	Source     string     `json:"source,omitempty"`
+	Summary    string     `json:"summary,omitempty"`
+	Details    string     `json:"details,omitempty"`
+	Severity   string     `json:"severity,omitempty"`
+	Score      float64    `json:"score,omitempty"`
+	Vector     string     `json:"vector,omitempty"`
+	References []string   `json:"references,omitempty"`
+	Published  string     `json:"published,omitempty"`
+	Modified   string     `json:"modified,omitempty"`
+	Affected   []Affected `json:"affected"`
+}
+
+// Affected identifies a package, either by ecosystem and name (OSV) or by
+// CPE vendor and product (NVD), and the versions of it that are vulnerable
+type Affected struct {
+	Ecosystem string         `json:"ecosystem,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Vendor    string         `json:"vendor,omitempty"`
+	Product   string         `json:"product,omitempty"`
+	Scheme    version.Scheme `json:"scheme,omitempty"`
+	Ranges    []Range        `json:"ranges,omitempty"`
+	Versions  []string       `json:"versions,omitempty"`
+}
+
+// Range is a span of vulnerable versions. An empty Start means every version
+// before End, an empty End means every version from Start on.
+type Range struct {
+	Start         string `json:"start,omitempty"`
+	StartExcluded bool   `json:"startExcluded,omitempty"`
+	End           string `json:"end,omitempty"`
+	EndIncluded   bool   `json:"endIncluded,omitempty"`
+}
+
+// Contains reports whether v falls inside the range
+func (r Range) Contains(scheme version.Scheme, v string) bool {
+	if r.Start != "" && r.Start != "0" {
+		c := version.CompareWith(scheme, v, r.Start)
+		if c < 0 || (c == 0 && r.StartExcluded) {
+			return false
+		}
+	}
+	if r.End != "" {
+		c := version.CompareWith(scheme, v, r.End)
+		if c > 0 || (c == 0 && !r.EndIncluded) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fixed is the version that fixes the range, if there is one
+func (r Range) Fixed() string {
+	if r.EndIncluded {
+		return ""
+	}
+	return r.End
+}
+
+// Includes reports whether the given version is vulnerable
+func (a Affected) Includes(v string) bool {
+	for _, listed := range a.Versions {
+		if version.CompareWith(a.Scheme, v, listed) == 0 {
+			return true
+		}
+	}
+	for _, r := range a.Ranges {
+		if r.Contains(a.Scheme, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// New returns an empty database
+func New() *DB {
+	return &DB{
+		Built:           time.Now().UTC().Format(time.RFC3339),
+		Vulnerabilities: make([]Vulnerability, 0),
+	}
+}
+
+// Load reads a database written by Save
+func Load(filename string) (*DB, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{}
+	if err := json.Unmarshal(b, db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Save writes the database to a file
+func (db *DB) Save(filename string) error {
+	sort.Slice(db.Vulnerabilities, func(i, j int) bool {
+		return db.Vulnerabilities[i].ID < db.Vulnerabilities[j].ID
+	})
+	b, err := json.MarshalIndent(db, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, os.FileMode(0644))
+}
+
+// Add adds vulnerabilities to the database. A vulnerability that is already
+// present, by ID, has the affected packages of the new one merged into it.
+func (db *DB) Add(source string, vulns []Vulnerability) {
+	if source != "" {
+		db.Sources = append(db.Sources, source)
+	}
+	index := make(map[string]int)
+	for i, v := range db.Vulnerabilities {
+		index[v.ID] = i
+	}
+	for _, v := range vulns {
+		i, ok := index[v.ID]
+		if !ok {
+			index[v.ID] = len(db.Vulnerabilities)
+			db.Vulnerabilities = append(db.Vulnerabilities, v)
+			continue
+		}
+		existing := &db.Vulnerabilities[i]
+		existing.Affected = append(existing.Affected, v.Affected...)
+		if existing.Severity == "" {
+			existing.Severity, existing.Score, existing.Vector = v.Severity, v.Score, v.Vector
+		}
+		if existing.Summary == "" {
+			existing.Summary = v.Summary
+		}
+	}
+}
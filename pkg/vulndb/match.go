@@ -0,0 +1,296 @@
+package vulndb
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+)
+
+// Severities from least to most severe
+var Severities = []string{"unknown", "none", "low", "medium", "high", "critical"}
+
+// SeverityRank orders a severity for sorting and thresholds
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	// OSV distro feeds use their own words, e.g. "moderate" or "important"
+	switch strings.ToLower(severity) {
+	case "negligible", "unimportant":
+		return 1
+	case "moderate":
+		return 3
+	case "important":
+		return 4
+	}
+	return 0
+}
+
+// Finding is a vulnerability that affects a component of the SBOM
+type Finding struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Severity  string   `json:"severity,omitempty"`
+	Score     float64  `json:"score,omitempty"`
+	Summary   string   `json:"summary,omitempty"`
+	Ref       string   `json:"ref"`
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	PURL      string   `json:"purl,omitempty"`
+	Images    []string `json:"images,omitempty"`
+	FixedIn   string   `json:"fixedIn,omitempty"`
+	MatchedBy string   `json:"matchedBy"`
+	// Status, Justification and Impact come from VEX statements
+	Status        string `json:"status,omitempty"`
+	Justification string `json:"justification,omitempty"`
+	Impact        string `json:"impact,omitempty"`
+}
+
+// purlEcosystems maps a PURL type to the OSV ecosystem its packages live in
+var purlEcosystems = map[string]string{
+	"deb":      "debian",
+	"apk":      "alpine",
+	"maven":    "maven",
+	"npm":      "npm",
+	"pypi":     "pypi",
+	"golang":   "go",
+	"gem":      "rubygems",
+	"nuget":    "nuget",
+	"cargo":    "crates.io",
+	"composer": "packagist",
+	"hex":      "hex",
+	"pub":      "pub",
+}
+
+// Matcher finds the vulnerabilities affecting SBOM components
+type Matcher struct {
+	byPackage map[string][]affectedRef
+	byProduct map[string][]affectedRef
+}
+
+type affectedRef struct {
+	vuln     *Vulnerability
+	affected *Affected
+}
+
+// NewMatcher indexes the database by package and by CPE product
+func NewMatcher(db *DB) *Matcher {
+	m := &Matcher{
+		byPackage: make(map[string][]affectedRef),
+		byProduct: make(map[string][]affectedRef),
+	}
+	for i := range db.Vulnerabilities {
+		v := &db.Vulnerabilities[i]
+		for j := range v.Affected {
+			a := &v.Affected[j]
+			ref := affectedRef{vuln: v, affected: a}
+			if a.Name != "" {
+				key := ecosystemBase(a.Ecosystem) + "/" + a.Name
+				m.byPackage[key] = append(m.byPackage[key], ref)
+			}
+			if a.Product != "" {
+				key := a.Vendor + ":" + a.Product
+				m.byProduct[key] = append(m.byProduct[key], ref)
+			}
+		}
+	}
+	return m
+}
+
+// Match returns the findings for every component of the document, sorted by
+// severity and then by component
+func (m *Matcher) Match(doc *sbom.Document) []Finding {
+	findings := make([]Finding, 0)
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			continue
+		}
+		matched := make(map[string]bool)
+		for _, f := range m.matchPURL(c) {
+			matched[f.ID] = true
+			for _, a := range f.Aliases {
+				matched[a] = true
+			}
+			findings = append(findings, f)
+		}
+		for _, f := range m.matchCPE(c) {
+			if matched[f.ID] {
+				continue
+			}
+			matched[f.ID] = true
+			findings = append(findings, f)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if ra, rb := SeverityRank(a.Severity), SeverityRank(b.Severity); ra != rb {
+			return ra > rb
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Ref < b.Ref
+	})
+	return findings
+}
+
+// matchPURL matches a component on its package ecosystem, name and version,
+// and for distro packages on the distro release too
+func (m *Matcher) matchPURL(c sbom.Component) []Finding {
+	findings := make([]Finding, 0)
+	if c.PURL == "" {
+		return findings
+	}
+	purl, err := packageurl.FromString(c.PURL)
+	if err != nil {
+		return findings
+	}
+	ecosystem, ok := purlEcosystems[purl.Type]
+	if purl.Type == "deb" && strings.EqualFold(purl.Namespace, "ubuntu") {
+		ecosystem = "ubuntu"
+	}
+	if purl.Type == "rpm" {
+		ecosystem, ok = rpmEcosystem(purl.Namespace), true
+	}
+	if !ok {
+		return findings
+	}
+	qualifiers := purl.Qualifiers.Map()
+	release := distroRelease(qualifiers["distro"])
+	version := purl.Version
+	if version == "" {
+		version = c.Version
+	}
+
+	names := []string{packageName(purl)}
+	// distro advisories are written against the source package
+	for _, q := range []string{"upstream", "source"} {
+		if src := qualifiers[q]; src != "" {
+			names = append(names, strings.SplitN(src, "@", 2)[0])
+		}
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		for _, ref := range m.byPackage[ecosystem+"/"+name] {
+			if seen[ref.vuln.ID] || !sameRelease(ref.affected.Ecosystem, release) || !ref.affected.Includes(version) {
+				continue
+			}
+			seen[ref.vuln.ID] = true
+			findings = append(findings, newFinding(ref, c, version, "purl"))
+		}
+	}
+	return findings
+}
+
+// matchCPE matches a component's CPEs against NVD configurations
+func (m *Matcher) matchCPE(c sbom.Component) []Finding {
+	findings := make([]Finding, 0)
+	seen := make(map[string]bool)
+	for _, cpe := range c.CPEs {
+		parts := strings.Split(cpe, ":")
+		if len(parts) < 6 || parts[0] != "cpe" || parts[1] != "2.3" {
+			continue
+		}
+		version := unescapeCPE(parts[5])
+		if version == "*" || version == "-" || version == "" {
+			version = c.Version
+		}
+		for _, ref := range m.byProduct[unescapeCPE(parts[3])+":"+unescapeCPE(parts[4])] {
+			if seen[ref.vuln.ID] || !ref.affected.Includes(version) {
+				continue
+			}
+			seen[ref.vuln.ID] = true
+			findings = append(findings, newFinding(ref, c, version, "cpe"))
+		}
+	}
+	return findings
+}
+
+func newFinding(ref affectedRef, c sbom.Component, version string, by string) Finding {
+	f := Finding{
+		ID:        ref.vuln.ID,
+		Aliases:   ref.vuln.Aliases,
+		Severity:  ref.vuln.Severity,
+		Score:     ref.vuln.Score,
+		Summary:   ref.vuln.Summary,
+		Ref:       c.Ref,
+		Name:      c.Name,
+		Version:   version,
+		PURL:      c.PURL,
+		Images:    c.Images,
+		MatchedBy: by,
+	}
+	if f.Summary == "" {
+		f.Summary = firstLine(ref.vuln.Details)
+	}
+	for _, r := range ref.affected.Ranges {
+		if r.Contains(ref.affected.Scheme, version) {
+			f.FixedIn = r.Fixed()
+		}
+	}
+	return f
+}
+
+// packageName is the package name as OSV writes it for the PURL's ecosystem
+func packageName(purl packageurl.PackageURL) string {
+	if purl.Namespace == "" {
+		return purl.Name
+	}
+	switch purl.Type {
+	case "maven":
+		return purl.Namespace + ":" + purl.Name
+	case "npm", "golang", "composer":
+		return purl.Namespace + "/" + purl.Name
+	}
+	return purl.Name
+}
+
+func rpmEcosystem(namespace string) string {
+	switch strings.ToLower(namespace) {
+	case "rocky":
+		return "rocky linux"
+	case "almalinux":
+		return "almalinux"
+	case "redhat", "rhel", "centos":
+		return "red hat"
+	case "opensuse":
+		return "opensuse"
+	}
+	return strings.ToLower(namespace)
+}
+
+// ecosystemBase is the lower cased ecosystem without its release, "Debian:11" -> "debian"
+func ecosystemBase(ecosystem string) string {
+	return strings.ToLower(strings.SplitN(ecosystem, ":", 2)[0])
+}
+
+// distroRelease is the release from a distro qualifier, "debian-11" -> "11"
+func distroRelease(distro string) string {
+	if i := strings.LastIndex(distro, "-"); i >= 0 {
+		return distro[i+1:]
+	}
+	return ""
+}
+
+// sameRelease reports whether a package from the given distro release is
+// covered by an ecosystem such as "Debian:11" or "Alpine:v3.14". An ecosystem
+// without a release covers every release, as does an unknown release.
+func sameRelease(ecosystem string, release string) bool {
+	parts := strings.SplitN(ecosystem, ":", 2)
+	if len(parts) < 2 || release == "" {
+		return true
+	}
+	want := strings.TrimPrefix(parts[1], "v")
+	release = strings.TrimPrefix(release, "v")
+	// Alpine releases are written major.minor, images report major.minor.patch
+	return release == want || strings.HasPrefix(release, want+".")
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
@@ -0,0 +1,245 @@
+package vulndb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/generator/generatortest"
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/vulndb"
+)
+
+// testDB is the database of the OSV and NVD fixtures in testdata
+func testDB(t *testing.T) *vulndb.DB {
+	t.Helper()
+	db := vulndb.New()
+	osv, err := vulndb.ImportOSV("testdata/osv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Add("osv", osv)
+	nvd, err := vulndb.ImportNVD("testdata/nvd.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Add("nvd", nvd)
+	return db
+}
+
+func TestImportOSV(t *testing.T) {
+	vulns, err := vulndb.ImportOSV("testdata/osv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	severities := make(map[string]string)
+	for _, v := range vulns {
+		severities[v.ID] = v.Severity
+	}
+	want := map[string]string{
+		"ALPINE-CVE-2022-37434": "",
+		"DSA-5139-1":            "high",
+		"GHSA-jfh8-c2jp-5v3q":   "critical",
+		"GHSA-p6mc-m468-83gw":   "high",
+		"GO-2021-0113":          "moderate",
+		"PYSEC-2021-59":         "",
+		"RLSA-2022:1065":        "important",
+	}
+	if !reflect.DeepEqual(severities, want) {
+		t.Errorf("severities %v, want %v", severities, want)
+	}
+}
+
+func TestImportNVD(t *testing.T) {
+	vulns, err := vulndb.ImportNVD("testdata/nvd.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vulns) != 2 {
+		t.Fatalf("got %d vulnerabilities, want 2", len(vulns))
+	}
+	nginx := vulns[0]
+	if nginx.Severity != "high" || nginx.Score != 8.1 {
+		t.Errorf("%v has severity %q and score %v, want the CVSS v3 high and 8.1", nginx.ID, nginx.Severity, nginx.Score)
+	}
+	wantAffected := []vulndb.Affected{{
+		Vendor:   "f5",
+		Product:  "nginx",
+		Scheme:   "generic",
+		Ranges:   []vulndb.Range{{Start: "0.6.18", End: "1.20.1"}},
+		Versions: []string{"1.21.0"},
+	}}
+	if !reflect.DeepEqual(nginx.Affected, wantAffected) {
+		t.Errorf("affected %+v, want %+v", nginx.Affected, wantAffected)
+	}
+	if openssl := vulns[1]; openssl.Severity != "medium" {
+		t.Errorf("%v has severity %q, want the CVSS v2 medium", openssl.ID, openssl.Severity)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := vulndb.NewMatcher(testDB(t))
+	tests := []struct {
+		name      string
+		component sbom.Component
+		want      []string
+		fixedIn   string
+	}{
+		{
+			name:      "debian before fix",
+			component: sbom.Component{PURL: "pkg:deb/debian/openssl@1.1.1k-1+deb11u1?distro=debian-11"},
+			want:      []string{"DSA-5139-1"},
+			fixedIn:   "1.1.1n-0+deb11u2",
+		},
+		{
+			name:      "debian fixed",
+			component: sbom.Component{PURL: "pkg:deb/debian/openssl@1.1.1n-0+deb11u2?distro=debian-11"},
+		},
+		{
+			name:      "debian other release",
+			component: sbom.Component{PURL: "pkg:deb/debian/openssl@1.1.1n-0+deb10u1?distro=debian-10"},
+			want:      []string{"DSA-5139-1"},
+			fixedIn:   "1.1.1n-0+deb10u2",
+		},
+		{
+			name:      "debian binary of source package",
+			component: sbom.Component{PURL: "pkg:deb/debian/libssl1.1@1.1.1k-1+deb11u1?distro=debian-11&upstream=openssl"},
+			want:      []string{"DSA-5139-1"},
+			fixedIn:   "1.1.1n-0+deb11u2",
+		},
+		{
+			name:      "alpine patch release",
+			component: sbom.Component{PURL: "pkg:apk/alpine/zlib@1.2.12-r1?distro=alpine-3.16.2"},
+			want:      []string{"ALPINE-CVE-2022-37434"},
+			fixedIn:   "1.2.12-r2",
+		},
+		{
+			name:      "alpine other release",
+			component: sbom.Component{PURL: "pkg:apk/alpine/zlib@1.2.12-r1?distro=alpine-3.15.4"},
+		},
+		{
+			name:      "npm in range",
+			component: sbom.Component{PURL: "pkg:npm/lodash@4.17.15"},
+			want:      []string{"GHSA-p6mc-m468-83gw"},
+			fixedIn:   "4.17.19",
+		},
+		{
+			name:      "npm before range",
+			component: sbom.Component{PURL: "pkg:npm/lodash@3.6.0"},
+		},
+		{
+			name:      "go module",
+			component: sbom.Component{PURL: "pkg:golang/golang.org/x/text@v0.3.6"},
+			want:      []string{"GO-2021-0113"},
+			fixedIn:   "0.3.7",
+		},
+		{
+			name:      "maven qualifier orders before release",
+			component: sbom.Component{PURL: "pkg:maven/org.apache.logging.log4j/log4j-core@2.0-rc1"},
+			want:      []string{"GHSA-jfh8-c2jp-5v3q"},
+			fixedIn:   "2.15.0",
+		},
+		{
+			name:      "maven fixed",
+			component: sbom.Component{PURL: "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1"},
+		},
+		{
+			name:      "pypi last affected",
+			component: sbom.Component{PURL: "pkg:pypi/lxml@4.6.2"},
+			want:      []string{"PYSEC-2021-59"},
+		},
+		{
+			name:      "pypi after last affected",
+			component: sbom.Component{PURL: "pkg:pypi/lxml@4.6.3"},
+		},
+		{
+			name:      "rpm with epoch",
+			component: sbom.Component{PURL: "pkg:rpm/rocky/openssl@1:1.1.1k-5.el8_5?distro=rocky-8"},
+			want:      []string{"RLSA-2022:1065"},
+			fixedIn:   "1:1.1.1k-6.el8_5",
+		},
+		{
+			name:      "cpe in range",
+			component: sbom.Component{CPEs: []string{"cpe:2.3:a:f5:nginx:1.19.0:*:*:*:*:*:*:*"}},
+			want:      []string{"CVE-2021-23017"},
+			fixedIn:   "1.20.1",
+		},
+		{
+			name:      "cpe listed version",
+			component: sbom.Component{Version: "1.21.0", CPEs: []string{"cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*"}},
+			want:      []string{"CVE-2021-23017"},
+		},
+		{
+			name:      "cpe fixed",
+			component: sbom.Component{CPEs: []string{"cpe:2.3:a:f5:nginx:1.21.6:*:*:*:*:*:*:*"}},
+		},
+		{
+			name:      "cpe end included",
+			component: sbom.Component{CPEs: []string{"cpe:2.3:a:openssl:openssl:1.0.2h:*:*:*:*:*:*:*"}},
+			want:      []string{"CVE-2016-2183"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := test.component
+			c.Ref = "component"
+			findings := m.Match(&sbom.Document{Components: []sbom.Component{c}})
+			ids := make([]string, 0)
+			for _, f := range findings {
+				ids = append(ids, f.ID)
+			}
+			want := test.want
+			if want == nil {
+				want = []string{}
+			}
+			if !reflect.DeepEqual(ids, want) {
+				t.Fatalf("matched %v, want %v", ids, want)
+			}
+			for _, f := range findings {
+				if f.FixedIn != test.fixedIn {
+					t.Errorf("%v fixed in %q, want %q", f.ID, f.FixedIn, test.fixedIn)
+				}
+			}
+		})
+	}
+}
+
+// scanGenerated matches the SBOM the generator makes of the test chart in
+// the given format, in which openssl 1.1.1k has DSA-5139-1, the way
+// scan-vulns does
+func scanGenerated(t *testing.T, format string) (*sbom.Document, []vulndb.Finding) {
+	t.Helper()
+	res := generatortest.Generate(t, generatortest.Chart())
+	doc, err := sbom.Parse(res.Encoded[format])
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := vulndb.NewMatcher(testDB(t)).Match(doc)
+	if len(findings) != 1 || findings[0].ID != "DSA-5139-1" {
+		t.Fatalf("found %+v, want DSA-5139-1 in openssl", findings)
+	}
+	return doc, findings
+}
+
+func TestMatchGenerated(t *testing.T) {
+	for _, format := range []string{generator.FormatSPDX, generator.FormatCycloneDX, generator.FormatCycloneDXJSON} {
+		t.Run(format, func(t *testing.T) {
+			_, findings := scanGenerated(t, format)
+			if f := findings[0]; f.Ref != "deb-openssl-1.1.1k" || !reflect.DeepEqual(f.Images, []string{"docker.io/library/nginx", "docker.io/library/redis"}) {
+				t.Errorf("found %v in %v of %v, want deb-openssl-1.1.1k of both images", f.ID, f.Ref, f.Images)
+			}
+		})
+	}
+}
+
+func TestMatchSkipsImages(t *testing.T) {
+	m := vulndb.NewMatcher(testDB(t))
+	doc := &sbom.Document{Components: []sbom.Component{{
+		Ref:  "image-nginx",
+		Type: "container",
+		CPEs: []string{"cpe:2.3:a:f5:nginx:1.19.0:*:*:*:*:*:*:*"},
+	}}}
+	if findings := m.Match(doc); len(findings) != 0 {
+		t.Errorf("matched %+v on an image, want nothing", findings)
+	}
+}
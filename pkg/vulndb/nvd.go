@@ -0,0 +1,161 @@
+package vulndb
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+// nvdFeed is the part of the NVD 1.1 JSON feed (nvdcve-1.1-*.json) we use
+type nvdFeed struct {
+	Items []struct {
+		CVE struct {
+			Meta struct {
+				ID string `json:"ID"`
+			} `json:"CVE_data_meta"`
+			Description struct {
+				Data []struct {
+					Lang  string `json:"lang"`
+					Value string `json:"value"`
+				} `json:"description_data"`
+			} `json:"description"`
+			References struct {
+				Data []struct {
+					URL string `json:"url"`
+				} `json:"reference_data"`
+			} `json:"references"`
+		} `json:"cve"`
+		Configurations struct {
+			Nodes []nvdNode `json:"nodes"`
+		} `json:"configurations"`
+		Impact struct {
+			V3 struct {
+				CVSS struct {
+					Vector   string  `json:"vectorString"`
+					Score    float64 `json:"baseScore"`
+					Severity string  `json:"baseSeverity"`
+				} `json:"cvssV3"`
+			} `json:"baseMetricV3"`
+			V2 struct {
+				CVSS struct {
+					Vector string  `json:"vectorString"`
+					Score  float64 `json:"baseScore"`
+				} `json:"cvssV2"`
+				Severity string `json:"severity"`
+			} `json:"baseMetricV2"`
+		} `json:"impact"`
+		Published string `json:"publishedDate"`
+		Modified  string `json:"lastModifiedDate"`
+	} `json:"CVE_Items"`
+}
+
+type nvdNode struct {
+	Children []nvdNode `json:"children"`
+	CPEMatch []struct {
+		Vulnerable            bool   `json:"vulnerable"`
+		CPE                   string `json:"cpe23Uri"`
+		VersionStartIncluding string `json:"versionStartIncluding"`
+		VersionStartExcluding string `json:"versionStartExcluding"`
+		VersionEndIncluding   string `json:"versionEndIncluding"`
+		VersionEndExcluding   string `json:"versionEndExcluding"`
+	} `json:"cpe_match"`
+}
+
+// ImportNVD reads an NVD 1.1 JSON feed, gzipped or not
+func ImportNVD(filename string) ([]Vulnerability, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	feed := nvdFeed{}
+	if err := json.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	vulns := make([]Vulnerability, 0)
+	for _, item := range feed.Items {
+		v := Vulnerability{
+			ID:        item.CVE.Meta.ID,
+			Source:    "nvd",
+			Published: item.Published,
+			Modified:  item.Modified,
+			Affected:  make([]Affected, 0),
+		}
+		for _, d := range item.CVE.Description.Data {
+			if d.Lang == "en" {
+				v.Details = d.Value
+			}
+		}
+		for _, ref := range item.CVE.References.Data {
+			v.References = append(v.References, ref.URL)
+		}
+		switch {
+		case item.Impact.V3.CVSS.Vector != "":
+			v.Severity = strings.ToLower(item.Impact.V3.CVSS.Severity)
+			v.Score = item.Impact.V3.CVSS.Score
+			v.Vector = item.Impact.V3.CVSS.Vector
+		case item.Impact.V2.CVSS.Vector != "":
+			v.Severity = strings.ToLower(item.Impact.V2.Severity)
+			v.Score = item.Impact.V2.CVSS.Score
+			v.Vector = item.Impact.V2.CVSS.Vector
+		}
+
+		// one Affected per vendor:product, with every vulnerable version range under it
+		byProduct := make(map[string]int)
+		var walk func(nodes []nvdNode)
+		walk = func(nodes []nvdNode) {
+			for _, n := range nodes {
+				walk(n.Children)
+				for _, m := range n.CPEMatch {
+					parts := strings.Split(m.CPE, ":")
+					if !m.Vulnerable || len(parts) < 6 {
+						continue
+					}
+					key := parts[3] + ":" + parts[4]
+					i, ok := byProduct[key]
+					if !ok {
+						i = len(v.Affected)
+						byProduct[key] = i
+						v.Affected = append(v.Affected, Affected{Vendor: parts[3], Product: parts[4], Scheme: version.Generic})
+					}
+					a := &v.Affected[i]
+					r := Range{
+						Start:         m.VersionStartIncluding + m.VersionStartExcluding,
+						StartExcluded: m.VersionStartExcluding != "",
+						End:           m.VersionEndIncluding + m.VersionEndExcluding,
+						EndIncluded:   m.VersionEndIncluding != "",
+					}
+					switch {
+					case r.Start != "" || r.End != "":
+						a.Ranges = append(a.Ranges, r)
+					case parts[5] == "*":
+						a.Ranges = append(a.Ranges, Range{})
+					case parts[5] != "-":
+						a.Versions = append(a.Versions, unescapeCPE(parts[5]))
+					}
+				}
+			}
+		}
+		walk(item.Configurations.Nodes)
+		vulns = append(vulns, v)
+	}
+	return vulns, nil
+}
+
+func unescapeCPE(s string) string {
+	return strings.ReplaceAll(s, "\\", "")
+}
@@ -0,0 +1,253 @@
+package vulndb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/version"
+)
+
+// osvEntry is the part of the OSV schema (https://ossf.github.io/osv-schema/) we use
+type osvEntry struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Published string   `json:"published"`
+	Modified  string   `json:"modified"`
+	Withdrawn string   `json:"withdrawn"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string              `json:"type"`
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+		Versions          []string    `json:"versions"`
+		EcosystemSpecific osvSpecific `json:"ecosystem_specific"`
+		DatabaseSpecific  osvSpecific `json:"database_specific"`
+	} `json:"affected"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+	DatabaseSpecific osvSpecific `json:"database_specific"`
+}
+
+// osvSpecific picks the severity out of the free form *_specific objects,
+// which is where GitHub and most distro feeds put it
+type osvSpecific struct {
+	Severity string `json:"severity"`
+}
+
+func (s *osvSpecific) UnmarshalJSON(b []byte) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		// not an object, nothing we can use
+		return nil
+	}
+	if sev, ok := fields["severity"].(string); ok {
+		s.Severity = sev
+	}
+	return nil
+}
+
+// ImportOSV reads OSV advisories from a JSON file, a directory of JSON files
+// (as checked out from an OSV export) or a zip of them (as downloaded from the
+// OSV bucket, e.g. Debian/all.zip)
+func ImportOSV(path string) ([]Vulnerability, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	vulns := make([]Vulnerability, 0)
+	add := func(name string, b []byte) error {
+		v, err := parseOSV(b)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		vulns = append(vulns, v...)
+		return nil
+	}
+
+	switch {
+	case info.IsDir():
+		err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() || !strings.HasSuffix(p, ".json") {
+				return err
+			}
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return add(p, b)
+		})
+	case strings.HasSuffix(path, ".zip"):
+		var r *zip.ReadCloser
+		r, err = zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if !strings.HasSuffix(f.Name, ".json") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			b, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			if err := add(f.Name, b); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		var b []byte
+		b, err = ioutil.ReadFile(path)
+		if err == nil {
+			err = add(path, b)
+		}
+	}
+	return vulns, err
+}
+
+// parseOSV reads a single OSV entry or an array of them
+func parseOSV(b []byte) ([]Vulnerability, error) {
+	entries := make([]osvEntry, 0)
+	if trimmed := bytes.TrimSpace(b); bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, err
+		}
+	} else {
+		var e osvEntry
+		if err := json.Unmarshal(trimmed, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	vulns := make([]Vulnerability, 0)
+	for _, e := range entries {
+		if e.Withdrawn != "" {
+			continue
+		}
+		v := Vulnerability{
+			ID:        e.ID,
+			Aliases:   e.Aliases,
+			Source:    "osv",
+			Summary:   e.Summary,
+			Details:   e.Details,
+			Severity:  strings.ToLower(e.DatabaseSpecific.Severity),
+			Published: e.Published,
+			Modified:  e.Modified,
+			Affected:  make([]Affected, 0),
+		}
+		for _, s := range e.Severity {
+			if strings.HasPrefix(s.Type, "CVSS") {
+				v.Vector = s.Score
+			}
+		}
+		for _, r := range e.References {
+			v.References = append(v.References, r.URL)
+		}
+		for _, a := range e.Affected {
+			affected := Affected{
+				Ecosystem: a.Package.Ecosystem,
+				Name:      a.Package.Name,
+				Scheme:    schemeForEcosystem(a.Package.Ecosystem),
+				Versions:  a.Versions,
+			}
+			for _, r := range a.Ranges {
+				if r.Type == "GIT" {
+					continue
+				}
+				affected.Ranges = append(affected.Ranges, osvRanges(r.Events)...)
+			}
+			if v.Severity == "" {
+				v.Severity = strings.ToLower(firstNonEmpty(a.EcosystemSpecific.Severity, a.DatabaseSpecific.Severity))
+			}
+			v.Affected = append(v.Affected, affected)
+		}
+		vulns = append(vulns, v)
+	}
+	return vulns, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// osvRanges flattens an OSV event list (introduced, fixed, last_affected,
+// limit) into ranges
+func osvRanges(events []map[string]string) []Range {
+	ranges := make([]Range, 0)
+	var open *Range
+	for _, e := range events {
+		switch {
+		case e["introduced"] != "":
+			if open != nil {
+				ranges = append(ranges, *open)
+			}
+			open = &Range{Start: e["introduced"]}
+		case e["fixed"] != "", e["limit"] != "":
+			if open == nil {
+				open = &Range{}
+			}
+			open.End = e["fixed"] + e["limit"]
+			ranges = append(ranges, *open)
+			open = nil
+		case e["last_affected"] != "":
+			if open == nil {
+				open = &Range{}
+			}
+			open.End = e["last_affected"]
+			open.EndIncluded = true
+			ranges = append(ranges, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		ranges = append(ranges, *open)
+	}
+	return ranges
+}
+
+// schemeForEcosystem returns the versioning scheme of an OSV ecosystem such as
+// "Debian:11" or "npm"
+func schemeForEcosystem(ecosystem string) version.Scheme {
+	base := strings.SplitN(ecosystem, ":", 2)[0]
+	switch base {
+	case "Debian", "Ubuntu":
+		return version.Deb
+	case "Alpine":
+		return version.APK
+	case "Rocky Linux", "AlmaLinux", "Red Hat", "openSUSE", "SUSE":
+		return version.RPM
+	case "Maven":
+		return version.Maven
+	case "npm", "Go", "crates.io", "NuGet", "Packagist", "Hex", "Pub":
+		return version.Semver
+	}
+	return version.Generic
+}
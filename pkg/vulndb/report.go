@@ -0,0 +1,210 @@
+package vulndb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	cyclonedx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/vex"
+)
+
+// Report is the standalone result of scanning an SBOM
+type Report struct {
+	Document string         `json:"document"`
+	Database string         `json:"database"`
+	Summary  map[string]int `json:"summary"`
+	Findings []Finding      `json:"findings"`
+}
+
+// NewReport summarizes findings by severity
+func NewReport(document string, db *DB, findings []Finding) *Report {
+	r := &Report{
+		Document: document,
+		Database: db.Built,
+		Summary:  make(map[string]int),
+		Findings: findings,
+	}
+	for _, f := range findings {
+		if f.Suppressed() {
+			r.Summary["suppressed"]++
+			continue
+		}
+		r.Summary[Severities[SeverityRank(f.Severity)]]++
+	}
+	return r
+}
+
+// AtLeast returns the findings with at least the given severity, leaving out
+// those VEX says are not affected or fixed
+func (r *Report) AtLeast(severity string) []Finding {
+	out := make([]Finding, 0)
+	for _, f := range r.Findings {
+		if !f.Suppressed() && SeverityRank(f.Severity) >= SeverityRank(severity) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Write renders the report as text or json
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(r, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "text":
+		r.writeText(w)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+func (r *Report) writeText(w io.Writer) {
+	fmt.Fprintf(w, "%v vulnerabilities in %v (database built %v)\n", len(r.Findings), r.Document, r.Database)
+	counts := make([]string, 0)
+	for i := len(Severities) - 1; i >= 0; i-- {
+		if n := r.Summary[Severities[i]]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%v %v", n, Severities[i]))
+		}
+	}
+	if n := r.Summary["suppressed"]; n > 0 {
+		counts = append(counts, fmt.Sprintf("%v not affected or fixed per VEX", n))
+	}
+	if len(counts) > 0 {
+		fmt.Fprintf(w, "  %v\n", strings.Join(counts, ", "))
+	}
+	if len(r.Findings) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	for _, f := range r.Findings {
+		fixed := "no fix"
+		if f.FixedIn != "" {
+			fixed = "fixed in " + f.FixedIn
+		}
+		fmt.Fprintf(w, "  %-20v %-9v %v@%v (%v)\n", f.ID, Severities[SeverityRank(f.Severity)], f.Name, f.Version, fixed)
+		if len(f.Images) > 0 {
+			fmt.Fprintf(w, "  %-20v %-9v in %v\n", "", "", strings.Join(f.Images, ", "))
+		}
+		if f.Status != "" {
+			fmt.Fprintf(w, "  %-20v %-9v vex: %v\n", "", "", strings.Join(nonEmpty(f.Status, f.Justification, f.Impact), ", "))
+		}
+	}
+}
+
+// ToCycloneDX converts findings to CycloneDX vulnerabilities, one per
+// advisory and VEX analysis, affecting the bom-refs of the matched components
+func ToCycloneDX(db *DB, findings []Finding) []cyclonedx.Vulnerability {
+	byID := make(map[string]*Vulnerability)
+	for i := range db.Vulnerabilities {
+		byID[db.Vulnerabilities[i].ID] = &db.Vulnerabilities[i]
+	}
+	type key struct{ id, status, justification, impact string }
+	affects := make(map[key][]cyclonedx.Affects)
+	keys := make([]key, 0)
+	for _, f := range findings {
+		k := key{f.ID, f.Status, f.Justification, f.Impact}
+		if _, ok := affects[k]; !ok {
+			keys = append(keys, k)
+		}
+		affects[k] = append(affects[k], cyclonedx.Affects{
+			Ref: f.Ref,
+			Range: &[]cyclonedx.AffectedVersions{
+				{Version: f.Version, Status: cyclonedx.VulnerabilityStatusAffected},
+			},
+		})
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].id < keys[j].id
+	})
+
+	vulns := make([]cyclonedx.Vulnerability, 0)
+	for _, k := range keys {
+		a := affects[k]
+		v := byID[k.id]
+		cv := cyclonedx.Vulnerability{
+			ID:          k.id,
+			Source:      vulnerabilitySource(v),
+			Description: v.Summary,
+			Detail:      v.Details,
+			Published:   v.Published,
+			Updated:     v.Modified,
+			Affects:     &a,
+		}
+		if k.status != "" {
+			cv.Analysis = vex.Analysis(k.status, k.justification, k.impact)
+		}
+		if v.Severity != "" || v.Vector != "" {
+			rating := cyclonedx.VulnerabilityRating{
+				Severity: cyclonedx.Severity(Severities[SeverityRank(v.Severity)]),
+				Vector:   v.Vector,
+				Method:   scoringMethod(v.Vector),
+			}
+			if v.Score > 0 {
+				score := v.Score
+				rating.Score = &score
+			}
+			cv.Ratings = &[]cyclonedx.VulnerabilityRating{rating}
+		}
+		if len(v.Aliases) > 0 {
+			refs := make([]cyclonedx.VulnerabilityReference, 0)
+			for _, alias := range v.Aliases {
+				refs = append(refs, cyclonedx.VulnerabilityReference{ID: alias, Source: aliasSource(alias)})
+			}
+			cv.References = &refs
+		}
+		if len(v.References) > 0 {
+			advisories := make([]cyclonedx.Advisory, 0)
+			for _, url := range v.References {
+				advisories = append(advisories, cyclonedx.Advisory{URL: url})
+			}
+			cv.Advisories = &advisories
+		}
+		vulns = append(vulns, cv)
+	}
+	return vulns
+}
+
+func vulnerabilitySource(v *Vulnerability) *cyclonedx.Source {
+	if v.Source == "nvd" {
+		return &cyclonedx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + v.ID}
+	}
+	return &cyclonedx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/" + v.ID}
+}
+
+func aliasSource(id string) *cyclonedx.Source {
+	if strings.HasPrefix(id, "CVE-") {
+		return &cyclonedx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + id}
+	}
+	return &cyclonedx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/" + id}
+}
+
+func scoringMethod(vector string) cyclonedx.ScoringMethod {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.1"):
+		return cyclonedx.ScoringMethodCVSSv31
+	case strings.HasPrefix(vector, "CVSS:3"):
+		return cyclonedx.ScoringMethodCVSSv3
+	case vector != "":
+		return cyclonedx.ScoringMethodCVSSv2
+	}
+	return ""
+}
+
+func nonEmpty(values ...string) []string {
+	out := make([]string, 0)
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
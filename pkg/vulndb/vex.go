@@ -0,0 +1,43 @@
+package vulndb
+
+import (
+	"github.com/defenseunicorns/spdx-cli/pkg/sbom"
+	"github.com/defenseunicorns/spdx-cli/pkg/vex"
+)
+
+// ApplyVEX sets the status, justification and impact of every finding that a
+// VEX statement covers. Statements name the image components of the SBOM as
+// products, by BOMRef, image name or PURL, and optionally the packages within
+// them as subcomponents.
+func ApplyVEX(findings []Finding, doc *sbom.Document, docs []*vex.Document) []Finding {
+	imageIDs := make(map[string][]string)
+	for _, c := range doc.Components {
+		if c.IsImage() {
+			imageIDs[c.Name] = append(imageIDs[c.Name], c.Ref, c.Name, c.PURL)
+		}
+	}
+	out := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		subject := vex.Subject{
+			Vulnerability: f.ID,
+			Aliases:       f.Aliases,
+			Products:      []string{doc.Root, doc.Name},
+			Component:     []string{f.Ref, f.PURL, f.Name + "@" + f.Version},
+		}
+		for _, image := range f.Images {
+			subject.Products = append(subject.Products, imageIDs[image]...)
+		}
+		if s, ok := vex.Lookup(docs, subject); ok {
+			f.Status = s.Status
+			f.Justification = s.Justification
+			f.Impact = s.Impact
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// Suppressed reports whether VEX says the finding does not need action
+func (f Finding) Suppressed() bool {
+	return f.Status == vex.StatusNotAffected || f.Status == vex.StatusFixed
+}
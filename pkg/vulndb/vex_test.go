@@ -0,0 +1,88 @@
+package vulndb_test
+
+import (
+	"testing"
+
+	"github.com/defenseunicorns/spdx-cli/pkg/generator"
+	"github.com/defenseunicorns/spdx-cli/pkg/imageref"
+	"github.com/defenseunicorns/spdx-cli/pkg/vex"
+	"github.com/defenseunicorns/spdx-cli/pkg/vulndb"
+)
+
+// imageProduct is the product vex create makes of a chart image
+func imageProduct(t *testing.T, image string) string {
+	t.Helper()
+	ref, err := imageref.Parse(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref.ID()
+}
+
+func TestApplyVEX(t *testing.T) {
+	doc, findings := scanGenerated(t, generator.FormatCycloneDXJSON)
+	nginx := imageProduct(t, "nginx:1.21")
+	if _, ok := doc.Component(nginx); !ok {
+		t.Fatalf("vex create product %v is not a component of the generated SBOM", nginx)
+	}
+
+	tests := []struct {
+		name       string
+		statement  vex.Statement
+		suppressed bool
+	}{
+		{
+			name:       "vex create product",
+			statement:  vex.Statement{Vulnerability: "CVE-2022-1292", Products: []string{nginx}, Status: vex.StatusNotAffected},
+			suppressed: true,
+		},
+		{
+			name:       "image name",
+			statement:  vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{"docker.io/library/redis"}, Status: vex.StatusFixed},
+			suppressed: true,
+		},
+		{
+			name:       "image purl",
+			statement:  vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{"pkg:oci/redis"}, Status: vex.StatusFixed},
+			suppressed: true,
+		},
+		{
+			name:       "chart",
+			statement:  vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{"demo"}, Status: vex.StatusNotAffected},
+			suppressed: true,
+		},
+		{
+			name:       "subcomponent",
+			statement:  vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{nginx}, Subcomponents: []string{"pkg:deb/debian/openssl@1.1.1k"}, Status: vex.StatusNotAffected},
+			suppressed: true,
+		},
+		{
+			name:      "affected",
+			statement: vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{nginx}, Status: vex.StatusAffected},
+		},
+		{
+			name:      "other image",
+			statement: vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{imageProduct(t, "busybox:1.34")}, Status: vex.StatusNotAffected},
+		},
+		{
+			name:      "other subcomponent",
+			statement: vex.Statement{Vulnerability: "DSA-5139-1", Products: []string{nginx}, Subcomponents: []string{"pkg:deb/debian/nginx@1.21.3"}, Status: vex.StatusNotAffected},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.statement.Justification = vex.VulnerableCodeNotInExecutePath
+			docs := []*vex.Document{{Statements: []vex.Statement{test.statement}}}
+			got := vulndb.ApplyVEX(findings, doc, docs)
+			if len(got) != 1 {
+				t.Fatalf("got %d findings, want 1", len(got))
+			}
+			if got[0].Suppressed() != test.suppressed {
+				t.Errorf("suppressed %v with status %q, want %v", got[0].Suppressed(), got[0].Status, test.suppressed)
+			}
+		})
+	}
+	if findings[0].Status != "" {
+		t.Errorf("ApplyVEX changed the status of the findings it was given to %q", findings[0].Status)
+	}
+}